@@ -0,0 +1,107 @@
+package main
+
+import (
+	"Effective_Mobile/internal/config"
+	"Effective_Mobile/internal/importer"
+	"Effective_Mobile/internal/repository"
+	"Effective_Mobile/internal/service"
+	"Effective_Mobile/pkg/logger"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// runImport implements the import subcommand, which loads subscriptions from a CSV or NDJSON file:
+//
+//	app import [-format csv|ndjson] [-mapping field:column,...] [-delimiter ;] file
+//
+// The database settings are read from the same configuration as the server. The report is printed
+// to stdout as JSON. Returns the exit code: 1 if the import failed, 0 otherwise, even if rows were rejected.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "file format: csv or ndjson (default: taken from the file extension)")
+	mapping := flags.String("mapping", "", "column mapping, e.g. service_name:Service,price:Amount")
+	delimiter := flags.String("delimiter", ",", "CSV field delimiter")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import [-format csv|ndjson] [-mapping field:column,...] [-delimiter ,] file")
+		return 2
+	}
+	path := flags.Arg(0)
+
+	opts := importer.Options{Format: *format}
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			opts.Format = importer.FormatCSV
+		case ".ndjson", ".jsonl":
+			opts.Format = importer.FormatNDJSON
+		default:
+			fmt.Fprintf(os.Stderr, "cannot detect the format of %s, use -format\n", path)
+			return 2
+		}
+	}
+	var err error
+	if opts.Mapping, err = importer.ParseMapping(*mapping); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if opts.Comma, err = importer.ParseDelimiter(*delimiter); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg := config.MustLoad()
+	log, err := logger.NewLogger(cfg.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	reader, err := importer.NewReader(file, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid import file: %s\n", err)
+		return 1
+	}
+
+	storage, err := repository.NewStorage(cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, cfg.SSLMode, cfg.QueryTimeout, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to the database: %s\n", err)
+		return 1
+	}
+	defer storage.Close()
+
+	subService := service.NewSubscriptionService(storage.NewRepository(), cfg.Idempotency.TTL, newNotifier(cfg, log), log)
+
+	// Interrupting the command rolls the import back.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := subService.ImportSubs(ctx, reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed: %s\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
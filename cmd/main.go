@@ -2,12 +2,17 @@ package main
 
 import (
 	"Effective_Mobile/internal/config"
+	"Effective_Mobile/internal/notify"
 	"Effective_Mobile/internal/repository"
 	"Effective_Mobile/internal/router"
 	"Effective_Mobile/internal/router/handlers"
+	"Effective_Mobile/internal/scheduler"
 	"Effective_Mobile/internal/service"
 	"Effective_Mobile/pkg/logger"
+	"context"
 	"go.uber.org/zap"
+	"os"
+	"sync"
 )
 
 // @title Effective Mobile Subscription Service API
@@ -27,6 +32,10 @@ import (
 // It acts as the entry point for API requests, validating input, calling the service layer,
 // and sending appropriate HTTP responses.
 func main() {
+	// "import" runs the file import instead of the server.
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
 
 	cfg := config.MustLoad()
 
@@ -36,7 +45,7 @@ func main() {
 		panic(err)
 	}
 
-	storage, err := repository.NewStorage(cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, cfg.SSLMode, log)
+	storage, err := repository.NewStorage(cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName, cfg.SSLMode, cfg.QueryTimeout, log)
 	if err != nil {
 		log.Fatal("Error initializing storage")
 	}
@@ -44,12 +53,52 @@ func main() {
 
 	repo := storage.NewRepository()
 
-	subService := service.NewSubscriptionService(repo, log)
+	notifier := newNotifier(cfg, log)
+	subService := service.NewSubscriptionService(repo, cfg.Idempotency.TTL, notifier, log)
+
+	// The scheduler purges the expired idempotency keys and sends the reminders, if enabled, and the budget alerts;
+	// it runs until the server has shut down.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	if cfg.Scheduler.Enabled && notifier == nil {
+		log.Warn("Scheduler is enabled but no notifier is configured; reminders are queued but not sent")
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scheduler.NewScheduler(subService, cfg.Scheduler.Enabled, cfg.Scheduler.Interval, cfg.Scheduler.DeliveryInterval,
+			cfg.Scheduler.LeadDays, log).Run(ctx)
+	}()
 
 	handler := handlers.NewSubscriptionHandler(subService)
 	log.Info("addr", zap.String("addr", cfg.Addr))
 	rout := router.NewRouter(handler, log)
-	if err := rout.RunRouter(cfg.Addr, cfg.RequestPerSecond, cfg.Burst); err != nil {
+	err = rout.RunRouter(cfg.Addr, cfg.RequestPerSecond, cfg.Burst, cfg.ShutdownTimeout)
+	cancel()
+	wg.Wait()
+	if err != nil {
 		log.Fatal("Error initializing router")
 	}
 }
+
+// newNotifier returns the notifier budget alerts and reminders are sent to, selected by notifier.kind:
+// the webhook, email over SMTP or the log. Without a kind the webhook is used if its URL is configured;
+// otherwise nil is returned and nothing is sent.
+func newNotifier(cfg *config.Config, log *zap.Logger) service.Notifier {
+	switch cfg.Notifier.Kind {
+	case "webhook":
+		return notify.NewWebhook(cfg.Webhook.URL, cfg.Webhook.Secret, cfg.Webhook.Timeout)
+	case "smtp":
+		return notify.NewSMTP(cfg.SMTP.Addr, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From, cfg.SMTP.To, cfg.SMTP.Timeout)
+	case "log":
+		return notify.NewLog(log)
+	case "":
+		if cfg.Webhook.URL == "" {
+			return nil
+		}
+		return notify.NewWebhook(cfg.Webhook.URL, cfg.Webhook.Secret, cfg.Webhook.Timeout)
+	default:
+		log.Fatal("Unknown notifier kind", zap.String("kind", cfg.Notifier.Kind))
+		return nil
+	}
+}
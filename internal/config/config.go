@@ -5,12 +5,19 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Config struct {
 	Storage
 	Rest
 	RateLimit
+	Idempotency
+	Webhook
+	Notifier
+	// SMTP не встраивается: имена его полей совпадают с полями Storage и Rest.
+	SMTP SMTP `yaml:"smtp"`
+	Scheduler
 	LogLevel string `yaml:"log_level"`
 }
 type Storage struct {
@@ -20,15 +27,64 @@ type Storage struct {
 	Port     string `yaml:"port"`
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"ssl_mode"`
+	// QueryTimeout ограничивает время выполнения одного SQL-запроса, например "5s".
+	QueryTimeout time.Duration `yaml:"query_timeout"`
 }
 type Rest struct {
 	Addr string `yaml:"addr"`
+	// ShutdownTimeout — время на завершение активных запросов при остановке сервера,
+	// по истечении которого выполняющиеся запросы к базе данных отменяются.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 }
 type RateLimit struct {
 	RequestPerSecond int `yaml:"request_per_second"`
 	Burst            int `yaml:"burst"`
 }
 
+type Idempotency struct {
+	// TTL — время хранения ответа для ключа Idempotency-Key, например "24h".
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type Webhook struct {
+	// URL — адрес, на который отправляются уведомления о превышении бюджета; пустой адрес отключает отправку.
+	URL string `yaml:"url"`
+	// Secret — ключ подписи HMAC-SHA256 в заголовке X-Signature; без ключа запросы не подписываются.
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type Notifier struct {
+	// Kind — способ доставки уведомлений: webhook, smtp или log (запись в журнал). Пустое значение
+	// означает webhook, если задан webhook.url, иначе уведомления не отправляются.
+	Kind string `yaml:"kind"`
+}
+
+type SMTP struct {
+	// Addr — адрес SMTP-сервера в виде host:port, например локальной заглушки Mailpit.
+	Addr string `yaml:"addr"`
+	// Username и Password задаются, если сервер требует аутентификации (PLAIN).
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From — адрес отправителя, To — получатели уведомлений.
+	From    string        `yaml:"from"`
+	To      []string      `yaml:"to"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type Scheduler struct {
+	// Enabled включает фоновую отправку напоминаний о списаниях и окончании пробных периодов.
+	Enabled bool `yaml:"enabled"`
+	// Interval — период запуска планировщика, например "1h": постановки напоминаний в очередь
+	// и удаления устаревших ключей идемпотентности (выполняется и при выключенном Enabled).
+	Interval time.Duration `yaml:"interval"`
+	// DeliveryInterval — период отправки напоминаний и уведомлений о превышении бюджета, например "1m".
+	// Уведомления о превышении бюджета отправляются, если настроен способ доставки, даже при выключенном Enabled.
+	DeliveryInterval time.Duration `yaml:"delivery_interval"`
+	// LeadDays — за сколько дней до списания или окончания пробного периода отправляется напоминание.
+	LeadDays int `yaml:"lead_days"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
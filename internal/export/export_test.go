@@ -0,0 +1,64 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+
+	endDate := "12-2025"
+	var noEndDate *string
+	assert.NoError(t, writer.WriteRow("name", "price", "end_date"))
+	assert.NoError(t, writer.WriteRow("Yandex, Plus", 400, &endDate))
+	assert.NoError(t, writer.WriteRow("Netflix", int64(300), noEndDate))
+	assert.NoError(t, writer.WriteRow("Kinopoisk", Number("149.99"), nil))
+	// Text that would be evaluated as a formula is escaped; numbers are not
+	assert.NoError(t, writer.WriteRow("=HYPERLINK(\"http://example.com\")", Number("-1.00"), "@SUM(A1)"))
+	assert.NoError(t, writer.WriteRow("+7 Music", int64(-1), "-work"))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "name,price,end_date\n\"Yandex, Plus\",400,12-2025\nNetflix,300,\nKinopoisk,149.99,\n"+
+		"\"'=HYPERLINK(\"\"http://example.com\"\")\",-1.00,'@SUM(A1)\n'+7 Music,-1,'-work\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatXLSX, &buf)
+	require.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRow("name", "price"))
+	assert.NoError(t, writer.WriteRow("Tom & <Jerry>", 400, nil, Number("149.99")))
+	assert.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var names []string
+	var sheet []byte
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			sheet, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	assert.Equal(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+	assert.Contains(t, string(sheet), `<row><c t="inlineStr"><is><t xml:space="preserve">name</t></is></c><c t="inlineStr"><is><t xml:space="preserve">price</t></is></c></row>`)
+	assert.Contains(t, string(sheet), `<row><c t="inlineStr"><is><t xml:space="preserve">Tom &amp; &lt;Jerry&gt;</t></is></c><c><v>400</v></c><c/><c><v>149.99</v></c></row>`)
+	assert.Contains(t, string(sheet), `</sheetData></worksheet>`)
+}
+
+func TestNewWriter(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard)
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+// Package importer reads subscriptions from files exported by other billing systems.
+// Files are parsed row by row, so arbitrarily large files can be imported with constant memory.
+package importer
+
+import (
+	"Effective_Mobile/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported file formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Subscription fields that can be imported; they are also the default column names.
+const (
+	FieldServiceName     = "service_name"
+	FieldPrice           = "price"
+	FieldCurrency        = "currency"
+	FieldBillingInterval = "billing_interval"
+	FieldIntervalCount   = "interval_count"
+	FieldUserID          = "user_id"
+	FieldStartDate       = "start_date"
+	FieldEndDate         = "end_date"
+	FieldCategory        = "category"
+	FieldTags            = "tags"
+	FieldTrialEndDate    = "trial_end_date"
+)
+
+var fields = []string{
+	FieldServiceName, FieldPrice, FieldCurrency, FieldBillingInterval, FieldIntervalCount,
+	FieldUserID, FieldStartDate, FieldEndDate, FieldCategory, FieldTags, FieldTrialEndDate,
+}
+
+// optionalFields are the fields whose column may be missing from a CSV file.
+var optionalFields = map[string]bool{
+	FieldCurrency: true, FieldBillingInterval: true, FieldIntervalCount: true, FieldEndDate: true,
+	FieldCategory: true, FieldTags: true, FieldTrialEndDate: true,
+}
+
+// maxLineSize is the longest NDJSON line accepted.
+const maxLineSize = 1 << 20
+
+// Mapping maps subscription fields to the column names (CSV header cells or NDJSON keys) of the file.
+// Fields that are not mapped are read from the column with the same name as the field.
+type Mapping map[string]string
+
+// ParseMapping parses a mapping in the form "field:column,field:column",
+// e.g. "service_name:Service,price:Amount".
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected field:column", pair)
+		}
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(fields, ", "))
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// ParseDelimiter parses a CSV field delimiter: a single character other than a quote, a line break or NUL.
+func ParseDelimiter(s string) (rune, error) {
+	comma, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || comma == '"' || comma == '\r' || comma == '\n' || comma == 0 || comma == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q", s)
+	}
+	return comma, nil
+}
+
+// column returns the name of the column holding the field.
+func (m Mapping) column(field string) string {
+	if column, ok := m[field]; ok {
+		return column
+	}
+	return field
+}
+
+func isField(name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Row is a subscription read from a file. Line is the line of the file the row starts on.
+// Err is set if the row cannot be converted into a subscription; reading can continue past such rows.
+type Row struct {
+	Line int
+	Req  models.SubReq
+	Err  error
+}
+
+// Options configure a Reader.
+type Options struct {
+	Format  string
+	Mapping Mapping
+	// Comma is the CSV field delimiter, ',' if zero.
+	Comma rune
+}
+
+// Reader reads subscriptions from a CSV or NDJSON file.
+type Reader struct {
+	next func() (*Row, error)
+}
+
+// NewReader creates a Reader for the file in src.
+// For CSV files the header is read immediately; an error is returned if it lacks a mapped column.
+func NewReader(src io.Reader, opts Options) (*Reader, error) {
+	for field := range opts.Mapping {
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+	switch opts.Format {
+	case FormatCSV:
+		return newCSVReader(src, opts)
+	case FormatNDJSON:
+		return newNDJSONReader(src, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+}
+
+// Next returns the next row of the file, or io.EOF after the last one.
+// Any other error means the file cannot be read further.
+func (r *Reader) Next() (*Row, error) {
+	return r.next()
+}
+
+// newCSVReader reads the header of a CSV file and returns a Reader for its records.
+func newCSVReader(src io.Reader, opts Options) (*Reader, error) {
+	reader := csv.NewReader(src)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	// Rows with a wrong number of fields are reported per row instead of stopping the import.
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		positions[name] = i
+	}
+	index := make(map[string]int, len(fields))
+	for _, field := range fields {
+		i, ok := positions[opts.Mapping.column(field)]
+		if !ok {
+			if optionalFields[field] {
+				continue
+			}
+			return nil, fmt.Errorf("missing column %q for field %s", opts.Mapping.column(field), field)
+		}
+		index[field] = i
+	}
+
+	next := func() (*Row, error) {
+		record, err := reader.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &Row{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]string, len(index))
+		for field, i := range index {
+			if i >= len(record) {
+				return &Row{Line: line, Err: fmt.Errorf("wrong number of fields: %d", len(record))}, nil
+			}
+			values[field] = strings.TrimSpace(record[i])
+		}
+		row := &Row{Line: line}
+		row.Req, row.Err = toSubReq(values)
+		return row, nil
+	}
+	return &Reader{next: next}, nil
+}
+
+// newNDJSONReader returns a Reader for a file with a JSON object on every line. Blank lines are skipped.
+func newNDJSONReader(src io.Reader, opts Options) *Reader {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	line := 0
+
+	next := func() (*Row, error) {
+		for scanner.Scan() {
+			line++
+			data := scanner.Bytes()
+			if len(strings.TrimSpace(string(data))) == 0 {
+				continue
+			}
+
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal(data, &object); err != nil {
+				return &Row{Line: line, Err: errors.New("invalid JSON object")}, nil
+			}
+			values := make(map[string]string, len(fields))
+			for _, field := range fields {
+				raw, ok := object[opts.Mapping.column(field)]
+				if !ok {
+					continue
+				}
+				value, err := jsonValue(raw, field == FieldTags)
+				if err != nil {
+					return &Row{Line: line, Err: fmt.Errorf("invalid %s: %w", field, err)}, nil
+				}
+				values[field] = value
+			}
+			row := &Row{Line: line}
+			row.Req, row.Err = toSubReq(values)
+			return row, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+1, err)
+		}
+		return nil, io.EOF
+	}
+	return &Reader{next: next}
+}
+
+// jsonValue returns a JSON string, number or null as text; null becomes an empty string.
+// Numbers keep their original text, so that prices are not rounded through floating point.
+// If list is set, an array of strings is accepted as well and returned as a comma-separated list,
+// the form tags have in CSV files.
+func jsonValue(raw json.RawMessage, list bool) (string, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(v), nil
+	case json.Number:
+		return v.String(), nil
+	case []any:
+		if !list {
+			break
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return "", errors.New("expected an array of strings")
+			}
+			items[i] = text
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", errors.New("expected a string or a number")
+}
+
+// toSubReq converts the text values of a row into subscription data.
+// Only the conversion of types is checked here; the values are validated by the caller.
+func toSubReq(values map[string]string) (models.SubReq, error) {
+	req := models.SubReq{
+		ServiceName:     values[FieldServiceName],
+		Price:           models.Decimal(values[FieldPrice]),
+		Currency:        values[FieldCurrency],
+		BillingInterval: values[FieldBillingInterval],
+		StartDate:       values[FieldStartDate],
+		Category:        values[FieldCategory],
+	}
+	if count := values[FieldIntervalCount]; count != "" {
+		var err error
+		if req.IntervalCount, err = strconv.Atoi(count); err != nil {
+			return req, fmt.Errorf("invalid interval count %q", count)
+		}
+	}
+	if userID := values[FieldUserID]; userID != "" {
+		var err error
+		if req.UserID, err = uuid.Parse(userID); err != nil {
+			return req, fmt.Errorf("invalid user id %q", userID)
+		}
+	}
+	if endDate := values[FieldEndDate]; endDate != "" {
+		req.EndDate = &endDate
+	}
+	if trialEnd := values[FieldTrialEndDate]; trialEnd != "" {
+		req.TrialEndDate = &trialEnd
+	}
+	// Tags are a comma-separated list; they are normalized by the validation.
+	for _, tag := range strings.Split(values[FieldTags], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+	return req, nil
+}
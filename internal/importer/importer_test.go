@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"Effective_Mobile/internal/models"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll returns every row of the file.
+func readAll(t *testing.T, reader *Reader) []*Row {
+	var rows []*Row
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	userID := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	// Test case 1: Mapped columns, custom delimiter and line numbers of multi-line records
+	file := "Service;Amount;Customer;Since;Until\n" +
+		"Yandex Plus;400;" + userID + ";07-2025;\n" +
+		"\"Multi\nline\";abc;" + userID + ";07-2025;\n" +
+		"Netflix;300;" + userID + ";08-2025;12-2025\n" +
+		"Short;1\n"
+	mapping, err := ParseMapping("service_name:Service,price:Amount,user_id:Customer,start_date:Since,end_date:Until")
+	require.NoError(t, err)
+
+	reader, err := NewReader(strings.NewReader(file), Options{Format: FormatCSV, Mapping: mapping, Comma: ';'})
+	require.NoError(t, err)
+	rows := readAll(t, reader)
+
+	require.Len(t, rows, 4)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "Yandex Plus", rows[0].Req.ServiceName)
+	assert.Equal(t, models.Decimal("400"), rows[0].Req.Price)
+	assert.Equal(t, userID, rows[0].Req.UserID.String())
+	assert.Nil(t, rows[0].Req.EndDate)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.NoError(t, rows[1].Err, "prices are left to validation")
+	assert.Equal(t, models.Decimal("abc"), rows[1].Req.Price)
+	assert.Equal(t, 5, rows[2].Line)
+	assert.Equal(t, "12-2025", *rows[2].Req.EndDate)
+	assert.Equal(t, 6, rows[3].Line)
+	assert.Error(t, rows[3].Err)
+
+	// Test case 2: Default column names, end_date column is optional
+	reader, err = NewReader(strings.NewReader("start_date,user_id,price,currency,billing_interval,interval_count,service_name\n"+
+		"07-2025,"+userID+",400,USD,year,1,Yandex Plus\n"+
+		"07-2025,"+userID+",400,USD,week,two,Yandex Plus\n"), Options{Format: FormatCSV})
+	require.NoError(t, err)
+	rows = readAll(t, reader)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "07-2025", rows[0].Req.StartDate)
+	assert.Equal(t, "USD", rows[0].Req.Currency)
+	assert.Equal(t, models.IntervalYear, rows[0].Req.BillingInterval)
+	assert.Equal(t, 1, rows[0].Req.IntervalCount)
+	assert.EqualError(t, rows[1].Err, `invalid interval count "two"`)
+
+	// Test case 3: Missing column
+	_, err = NewReader(strings.NewReader("service_name,price\n"), Options{Format: FormatCSV})
+	assert.EqualError(t, err, `missing column "user_id" for field user_id`)
+
+	// Test case 4: Empty file
+	_, err = NewReader(strings.NewReader(""), Options{Format: FormatCSV})
+	assert.Error(t, err)
+
+	// Test case 5: Category and a comma-separated list of tags
+	reader, err = NewReader(strings.NewReader("service_name,price,user_id,start_date,category,tags\n"+
+		"Yandex Plus,400,"+userID+",07-2025,video,\"Family, work,\"\n"+
+		"Netflix,300,"+userID+",07-2025,,\n"), Options{Format: FormatCSV})
+	require.NoError(t, err)
+	rows = readAll(t, reader)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "video", rows[0].Req.Category)
+	assert.Equal(t, []string{"Family", "work"}, rows[0].Req.Tags)
+	assert.Empty(t, rows[1].Req.Category)
+	assert.Nil(t, rows[1].Req.Tags)
+
+	// Test case 6: Trial end date
+	reader, err = NewReader(strings.NewReader("service_name,price,user_id,start_date,trial_end_date\n"+
+		"Kinopoisk,299,"+userID+",2025-03-01,2025-03-14\n"+
+		"Kinopoisk,299,"+userID+",2025-03-01,\n"), Options{Format: FormatCSV})
+	require.NoError(t, err)
+	rows = readAll(t, reader)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "2025-03-14", *rows[0].Req.TrialEndDate)
+	assert.Nil(t, rows[1].Req.TrialEndDate)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	userID := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	file := `{"service":"Yandex Plus","price":149.99,"user_id":"` + userID + `","start_date":"07-2025","end_date":null}` + "\n" +
+		"\n" +
+		`{"service":"Netflix","price":"300","user_id":"` + userID + `","start_date":"08-2025","end_date":"12-2025"}` + "\n" +
+		`not json` + "\n" +
+		`{"service":"Kinopoisk","price":true}` + "\n" +
+		`{"service":"Spotify","price":"169","user_id":"` + userID + `","start_date":"08-2025","category":"music","tags":["family","work"]}` + "\n" +
+		`{"service":"Spotify","price":"169","user_id":"` + userID + `","start_date":"08-2025","tags":"family, work"}` + "\n" +
+		`{"service":"Spotify","price":"169","user_id":"` + userID + `","start_date":"08-2025","tags":[1]}` + "\n" +
+		`{"service":"Spotify","price":["169"]}`
+
+	reader, err := NewReader(strings.NewReader(file), Options{Format: FormatNDJSON, Mapping: Mapping{FieldServiceName: "service"}})
+	require.NoError(t, err)
+	rows := readAll(t, reader)
+
+	require.Len(t, rows, 8)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "Yandex Plus", rows[0].Req.ServiceName)
+	assert.Equal(t, models.Decimal("149.99"), rows[0].Req.Price)
+	assert.Nil(t, rows[0].Req.EndDate)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Equal(t, models.Decimal("300"), rows[1].Req.Price)
+	assert.Equal(t, "12-2025", *rows[1].Req.EndDate)
+	assert.Equal(t, 4, rows[2].Line)
+	assert.EqualError(t, rows[2].Err, "invalid JSON object")
+	assert.Equal(t, 5, rows[3].Line)
+	assert.EqualError(t, rows[3].Err, "invalid price: expected a string or a number")
+	assert.NoError(t, rows[4].Err)
+	assert.Equal(t, "music", rows[4].Req.Category)
+	assert.Equal(t, []string{"family", "work"}, rows[4].Req.Tags)
+	assert.Equal(t, []string{"family", "work"}, rows[5].Req.Tags)
+	assert.EqualError(t, rows[6].Err, "invalid tags: expected an array of strings")
+	assert.EqualError(t, rows[7].Err, "invalid price: expected a string or a number")
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping(" price : Amount ,service_name:Service")
+	assert.NoError(t, err)
+	assert.Equal(t, Mapping{FieldPrice: "Amount", FieldServiceName: "Service"}, mapping)
+
+	mapping, err = ParseMapping("")
+	assert.NoError(t, err)
+	assert.Empty(t, mapping)
+
+	_, err = ParseMapping("price")
+	assert.Error(t, err)
+
+	_, err = ParseMapping("cost:Amount")
+	assert.Error(t, err)
+}
+
+func TestParseDelimiter(t *testing.T) {
+	comma, err := ParseDelimiter(";")
+	assert.NoError(t, err)
+	assert.Equal(t, ';', comma)
+
+	comma, err = ParseDelimiter("\t")
+	assert.NoError(t, err)
+	assert.Equal(t, '\t', comma)
+
+	for _, delimiter := range []string{"", ";;", "\"", "\r", "\n", "\x00", "\xff"} {
+		_, err = ParseDelimiter(delimiter)
+		assert.Error(t, err, "delimiter %q", delimiter)
+	}
+}
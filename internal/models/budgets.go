@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Budget is a monthly spending limit of a user. A budget with an empty category limits the cost
+// of all subscriptions of the user and is the MonthlyBudget of the User; the others limit the cost
+// of the subscriptions of their category.
+type Budget struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Category string    `json:"category"`
+	// Amount is in minor units of Currency; it is written to JSON as a decimal string, see MarshalJSON.
+	Amount   int64  `json:"amount" swaggertype:"string" example:"500.00"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON writes the amount of the budget as a decimal in its currency.
+func (b Budget) MarshalJSON() ([]byte, error) {
+	type budget Budget
+	return json.Marshal(struct {
+		budget
+		Amount Decimal `json:"amount"`
+	}{budget: budget(b), Amount: FormatAmount(b.Amount, b.Currency)})
+}
+
+// BudgetReq is the body of a request setting the budget of a category.
+type BudgetReq struct {
+	Amount   Decimal `json:"amount" swaggertype:"string" example:"500.00"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// BudgetAlert records that the cost of the subscriptions limited by a budget exceeded it in a month.
+// Every budget raises at most one alert a month.
+type BudgetAlert struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Category string    `json:"category"`
+	// Month is in MM-YYYY format.
+	Month string `json:"month" example:"04-2025"`
+	// Budget and Spent are in minor units of Currency; they are written to JSON as decimal strings.
+	Budget    int64     `json:"budget" swaggertype:"string" example:"500.00"`
+	Spent     int64     `json:"spent" swaggertype:"string" example:"649.00"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarshalJSON writes the budget and the spent amount of the alert as decimals in its currency.
+func (a BudgetAlert) MarshalJSON() ([]byte, error) {
+	type alert BudgetAlert
+	return json.Marshal(struct {
+		alert
+		Budget Decimal `json:"budget"`
+		Spent  Decimal `json:"spent"`
+	}{alert: alert(a), Budget: FormatAmount(a.Budget, a.Currency), Spent: FormatAmount(a.Spent, a.Currency)})
+}
+
+// AlertFilter selects budget alerts. Nil and empty fields do not filter.
+type AlertFilter struct {
+	UserID *uuid.UUID
+	// Month is in MM-YYYY format.
+	Month string
+}
+
+// EventBudgetExceeded is the type of the event sent when a budget alert is raised; its data is the BudgetAlert.
+const EventBudgetExceeded = "budget.exceeded"
+
+// Event is a notification sent to external systems, e.g. by webhook.
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"strings"
+	"unicode"
+)
+
+// Service is an entry of the service catalog that subscriptions refer to.
+// Slug is the canonical form of Name that identifies the service, see Slug.
+type Service struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Slug     string    `json:"slug"`
+	Category string    `json:"category,omitempty"`
+	// DefaultPrice is the usual price of the service in minor units of Currency, 0 if unknown;
+	// it is written to JSON as a decimal string, see MarshalJSON.
+	DefaultPrice int64  `json:"default_price,omitempty" swaggertype:"string" example:"299.00"`
+	Currency     string `json:"currency"`
+	Website      string `json:"website,omitempty"`
+}
+
+// MarshalJSON writes the default price of the service as a decimal in its currency.
+func (s Service) MarshalJSON() ([]byte, error) {
+	type service Service
+	aux := struct {
+		service
+		DefaultPrice *Decimal `json:"default_price,omitempty"`
+	}{service: service(s)}
+	if s.DefaultPrice != 0 {
+		price := FormatAmount(s.DefaultPrice, s.Currency)
+		aux.DefaultPrice = &price
+	}
+	return json.Marshal(aux)
+}
+
+type ServiceReq struct {
+	Name         string   `json:"name"`
+	Category     string   `json:"category,omitempty"`
+	DefaultPrice *Decimal `json:"default_price,omitempty" swaggertype:"string" example:"299.00"`
+	Currency     string   `json:"currency,omitempty"`
+	Website      string   `json:"website,omitempty"`
+}
+
+// Slug returns the canonical form of a service name: its letters and digits in lower case, with every run
+// of other characters between them replaced by a single hyphen. Names that differ only in letter case,
+// spacing or punctuation, such as "Netflix", "netflix" and "Netflix ", have the same slug.
+func Slug(name string) string {
+	var b strings.Builder
+	separated := false
+	for _, r := range strings.ToLower(name) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separated = true
+			continue
+		}
+		if separated && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		separated = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "netflix", Slug("Netflix"))
+	assert.Equal(t, "netflix", Slug(" netflix. "))
+	assert.Equal(t, "yandex-plus", Slug("Yandex  Plus"))
+	assert.Equal(t, "yandex-plus", Slug("yandex_plus!"))
+	assert.Equal(t, "кинопоиск-hd", Slug("Кинопоиск HD"))
+	assert.Equal(t, "", Slug("+++"))
+}
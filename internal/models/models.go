@@ -1,25 +1,123 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Subscription struct {
-	ID          uuid.UUID `json:"id"`
+	ID uuid.UUID `json:"id"`
+	// ServiceID refers to the service in the catalog; ServiceName is the name of that service.
+	ServiceID   uuid.UUID `json:"service_id"`
 	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	// Price is in minor units of Currency; it is written to JSON as a decimal string, see MarshalJSON.
+	Price    int64  `json:"price" swaggertype:"string" example:"149.99"`
+	Currency string `json:"currency"`
+	// The subscription is charged every IntervalCount BillingIntervals, starting with StartDate.
+	BillingInterval string    `json:"billing_interval"`
+	IntervalCount   int       `json:"interval_count"`
+	UserID          uuid.UUID `json:"user_id"`
+	// StartDate and EndDate are dates in YYYY-MM-DD form; EndDate is the last day paid for.
+	StartDate string  `json:"start_date" example:"2025-01-15"`
+	EndDate   *string `json:"end_date,omitempty" example:"2025-12-31"`
+	// TrialEndDate is the last day of the free trial the subscription starts with; it is charged from the day after.
+	TrialEndDate *string `json:"trial_end_date,omitempty" example:"2025-01-29"`
+	// Version is incremented on every change and is used as the ETag of the subscription.
+	Version int `json:"version"`
+	// Status is the lifecycle state of the subscription; it is changed only by lifecycle operations.
+	Status string `json:"status"`
+	// Category is the category of the service in the catalog unless set for the subscription itself;
+	// Tags are free-form labels in lower case, sorted and without duplicates.
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// BaseCurrency is the currency of subscriptions created without one and of summaries requested
+// without one. Exchange rates are quoted in it.
+const BaseCurrency = "RUB"
+
+// ExchangeRate is the price of one unit of Currency in BaseCurrency. The rate applies from Month
+// until the month of the next rate of the currency. Rate is a decimal number such as "92.35".
+type ExchangeRate struct {
+	Currency string `json:"currency"`
+	Month    string `json:"month"`
+	Rate     string `json:"rate"`
+}
+
+// Billing intervals of a subscription.
+const (
+	IntervalWeek    = "week"
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+	IntervalYear    = "year"
+)
+
+// Lifecycle statuses of a subscription.
+const (
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusCanceled = "canceled"
+)
+
+// Cancellation modes: at the end of the current month, which is still paid,
+// or immediately, so that the current month is not paid.
+const (
+	CancelAtPeriodEnd = "period_end"
+	CancelImmediately = "immediate"
+)
+
+// Pause is a range of months in which a subscription is paused and not paid.
+// EndDate is nil while the subscription is still paused.
+type Pause struct {
+	StartDate string  `json:"start_date"`
+	EndDate   *string `json:"end_date,omitempty"`
+}
+
+// PricePeriod is an entry of the price history of a subscription:
+// the price is charged from EffectiveFrom until the month the next entry takes effect.
+type PricePeriod struct {
+	EffectiveFrom string `json:"effective_from"`
+	// Price is in minor units of Currency.
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// StatusChange is a lifecycle transition of a subscription as stored by the repository:
+// the new status and end date, and the changes to the pauses of the subscription.
+type StatusChange struct {
+	Status  string
+	EndDate *string
+	// ClosePause ends the open pause with this month; a pause that would end before it starts is removed.
+	ClosePause *string
+	// OpenPause starts a new open pause in this month.
+	OpenPause *string
+	// AddPause records a closed pause.
+	AddPause *Pause
 }
 
 type SubReq struct {
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	// ServiceID selects the service in the catalog; without it the service is found by ServiceName,
+	// and added to the catalog if there is none with the same slug.
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName string     `json:"service_name"`
+	Price       Decimal    `json:"price" swaggertype:"string" example:"149.99"`
+	Currency    string     `json:"currency,omitempty"`
+	// BillingInterval is week, month, quarter or year, month if empty; IntervalCount is 1 if zero.
+	BillingInterval string    `json:"billing_interval,omitempty" enums:"week,month,quarter,year"`
+	IntervalCount   int       `json:"interval_count,omitempty"`
+	UserID          uuid.UUID `json:"user_id"`
+	// StartDate and EndDate are ISO 8601 dates or timestamps, or MM-YYYY months standing for
+	// their first day as a start date and for their last day as an end date.
+	StartDate string  `json:"start_date" example:"2025-01-15"`
+	EndDate   *string `json:"end_date,omitempty" example:"2025-12-31"`
+	// TrialEndDate is the last day of a free trial starting on StartDate, an ISO 8601 date or timestamp.
+	TrialEndDate *string `json:"trial_end_date,omitempty" example:"2025-01-29"`
+	// Category is taken from the catalog service if empty.
+	Category string   `json:"category,omitempty" example:"streaming"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
 type GetSummaryReq struct {
@@ -27,6 +125,50 @@ type GetSummaryReq struct {
 	From        time.Time  `json:"from,omitempty"`
 	To          time.Time  `json:"to,omitempty"`
 	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	// Currency is the ISO 4217 code of the currency the cost is calculated in, BaseCurrency if empty.
+	Currency string `json:"currency,omitempty"`
+	// Amortize spreads every charge evenly over the months it pays for instead of counting it
+	// in the month it is charged, e.g. a yearly charge over its 12 months.
+	Amortize bool `json:"amortize,omitempty"`
+}
+
+// Supported GroupBy values of GetBreakdownReq and GetGroupedSummaryReq.
+// With GroupByTag a subscription belongs to the group of each of its tags.
+const (
+	GroupByServiceName = "service_name"
+	GroupByUserID      = "user_id"
+	GroupByCategory    = "category"
+	GroupByTag         = "tag"
+)
+
+type GetBreakdownReq struct {
+	GetSummaryReq
+	GroupBy string `json:"group_by,omitempty"`
+}
+
+type GetGroupedSummaryReq struct {
+	GetSummaryReq
+	GroupBy string `json:"group_by" enums:"service_name,user_id,category,tag"`
+}
+
+// GroupedSummary is the total cost of subscriptions in a period and its part in every group.
+// Subscriptions without a category or tag are in the group with an empty key. With grouping by tag
+// a subscription counts towards each of its tags, so the groups may add up to more than Total.
+type GroupedSummary struct {
+	Total    Decimal     `json:"total" swaggertype:"string" example:"149.99"`
+	Currency string      `json:"currency"`
+	Groups   []GroupCost `json:"groups"`
+}
+
+type MonthlyCost struct {
+	Month  string      `json:"month"`
+	Total  Decimal     `json:"total" swaggertype:"string" example:"149.99"`
+	Groups []GroupCost `json:"groups,omitempty"`
+}
+
+type GroupCost struct {
+	Key   string  `json:"key"`
+	Total Decimal `json:"total" swaggertype:"string" example:"149.99"`
 }
 
 type GetSummary struct {
@@ -36,9 +178,134 @@ type GetSummary struct {
 	ServiceName string     `json:"service_name"`
 }
 
+// SubscriptionFilter narrows down subscription listings. Nil and empty fields are ignored;
+// dates are months in MM-YYYY form, matching any day of the month, and all ranges are inclusive. Prices are in minor units
+// and are compared regardless of the currency unless Currency is set as well. Subscriptions must have all of the Tags.
 type SubscriptionFilter struct {
-	UserID      *uuid.UUID `json:"user_id"`
-	ServiceName *string    `json:"service_name"`
+	UserID            *uuid.UUID  `json:"user_id"`
+	UserIDs           []uuid.UUID `json:"user_ids"`
+	ServiceName       *string     `json:"service_name"`
+	ServiceNamePrefix *string     `json:"service_name_prefix"`
+	Currency          *string     `json:"currency"`
+	MinPrice          *int64      `json:"min_price"`
+	MaxPrice          *int64      `json:"max_price"`
+	ActiveOn          *string     `json:"active_on"`
+	StartFrom         *string     `json:"start_from"`
+	StartTo           *string     `json:"start_to"`
+	EndFrom           *string     `json:"end_from"`
+	EndTo             *string     `json:"end_to"`
+	OpenEnded         bool        `json:"open_ended"`
+	Category          *string     `json:"category"`
+	Tags              []string    `json:"tags"`
+}
+
+// Supported SortBy values of ListParams.
+const (
+	SortByPrice       = "price"
+	SortByStartDate   = "start_date"
+	SortByServiceName = "service_name"
+)
+
+type ListParams struct {
+	Limit  int
+	SortBy string
+	Desc   bool
+	Cursor *Cursor
+}
+
+// Cursor points to the last subscription of a page. The next page starts right after
+// the subscription with this sort value and ID.
+type Cursor struct {
+	SortBy string    `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	Value  string    `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// Encode returns the opaque string representation of the cursor.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor: missing id")
+	}
+	return &c, nil
+}
+
+type SubsPage struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key header.
+// StatusCode is zero while the first request with the key is still being processed.
+// Headers holds the response headers replayed along with the body, such as ETag.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Response    []byte
+}
+
+// Batch modes: an atomic batch is applied entirely or not at all,
+// a best-effort batch applies every item that succeeds.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// SubUpdateReq is an item of a batch update: the new subscription data together with
+// the ID of the subscription and the version it is expected to have, as in its ETag.
+type SubUpdateReq struct {
+	ID      uuid.UUID `json:"id"`
+	Version int       `json:"version"`
+	SubReq
+}
+
+// BatchItemResult is the outcome of a single item of a batch, identified by its position in the request.
+// Status is the HTTP status the item would have received as a separate request.
+type BatchItemResult struct {
+	Index   int        `json:"index"`
+	ID      *uuid.UUID `json:"id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Status  int        `json:"status"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// BatchReport lists the result of every item of a batch in request order.
+type BatchReport struct {
+	Mode      string            `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Items     []BatchItemResult `json:"items"`
+}
+
+// ImportError describes a row of an imported file that was rejected, by the line it starts on.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport is the outcome of an import. Errors lists the rejected rows in file order;
+// it is truncated when there are too many of them, while Rejected still counts every row.
+type ImportReport struct {
+	Imported  int64         `json:"imported"`
+	Rejected  int           `json:"rejected"`
+	Errors    []ImportError `json:"errors"`
+	Truncated bool          `json:"truncated,omitempty"`
 }
 
 type Response struct {
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// exponents lists the ISO 4217 currencies whose amounts do not have two decimal places,
+// by the number of decimal places they have.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Exponent returns the number of decimal places of amounts in the currency, that is the power of ten
+// its minor unit is of the major one: 2 for kopecks and cents, 0 for currencies without a minor unit.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// decimalPattern matches non-negative decimal numbers without an exponent.
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Decimal is an amount of money written as a decimal number, such as "149.99".
+// It is read from a JSON string or, for clients that send whole amounts, from a JSON number.
+type Decimal string
+
+// UnmarshalJSON accepts both a JSON string and a JSON number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("amount must be a decimal string")
+	}
+	*d = Decimal(n)
+	return nil
+}
+
+// ParseAmount converts a decimal amount in the currency into minor units, e.g. "149.99" RUB into 14999.
+// Amounts with more significant decimal places than the currency has are rejected rather than rounded.
+func ParseAmount(amount Decimal, currency string) (int64, error) {
+	s := string(amount)
+	if !decimalPattern.MatchString(s) {
+		return 0, errors.New("not a decimal number")
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	frac = strings.TrimRight(frac, "0")
+	exp := Exponent(currency)
+	if len(frac) > exp {
+		return 0, fmt.Errorf("more than %d decimal places in %s", exp, currency)
+	}
+	units, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return 0, errors.New("too large")
+	}
+	return units, nil
+}
+
+// FormatAmount converts an amount in minor units of the currency into a decimal with all
+// the decimal places of the currency, e.g. 14999 RUB into "149.99" and 500 JPY into "500".
+func FormatAmount(units int64, currency string) Decimal {
+	exp := Exponent(currency)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+	return Decimal(new(big.Rat).SetFrac(big.NewInt(units), scale).FloatString(exp))
+}
+
+// MarshalJSON writes the price of the subscription as a decimal in its currency.
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type subscription Subscription
+	return json.Marshal(struct {
+		subscription
+		Price Decimal `json:"price"`
+	}{subscription(s), FormatAmount(s.Price, s.Currency)})
+}
+
+// UnmarshalJSON reads a subscription written by MarshalJSON.
+func (s *Subscription) UnmarshalJSON(data []byte) error {
+	type subscription Subscription
+	aux := struct {
+		*subscription
+		Price Decimal `json:"price"`
+	}{subscription: (*subscription)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Price == "" {
+		return nil
+	}
+	price, err := ParseAmount(aux.Price, s.Currency)
+	if err != nil {
+		return err
+	}
+	s.Price = price
+	return nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		amount   Decimal
+		currency string
+		units    int64
+		err      string
+	}{
+		{"149.99", "RUB", 14999, ""},
+		{"150", "USD", 15000, ""},
+		{"150.5", "EUR", 15050, ""},
+		{"0.10", "RUB", 10, ""},
+		{"500", "JPY", 500, ""},
+		{"500.00", "JPY", 500, ""},
+		{"1.234", "KWD", 1234, ""},
+		{"1.2345", "CLF", 12345, ""},
+		{"1.999", "RUB", 0, "more than 2 decimal places in RUB"},
+		{"500.5", "JPY", 0, "more than 0 decimal places in JPY"},
+		{"-1", "RUB", 0, "not a decimal number"},
+		{"1e3", "RUB", 0, "not a decimal number"},
+		{"", "RUB", 0, "not a decimal number"},
+		{"99999999999999999999", "RUB", 0, "too large"},
+	}
+	for _, tt := range tests {
+		units, err := ParseAmount(tt.amount, tt.currency)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, string(tt.amount))
+			continue
+		}
+		assert.NoError(t, err, string(tt.amount))
+		assert.Equal(t, tt.units, units, string(tt.amount))
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, Decimal("149.99"), FormatAmount(14999, "RUB"))
+	assert.Equal(t, Decimal("0.05"), FormatAmount(5, "USD"))
+	assert.Equal(t, Decimal("500"), FormatAmount(500, "JPY"))
+	assert.Equal(t, Decimal("1.234"), FormatAmount(1234, "KWD"))
+	assert.Equal(t, Decimal("0.00"), FormatAmount(0, "RUB"))
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	var req SubReq
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": "149.99"}`), &req))
+	assert.Equal(t, Decimal("149.99"), req.Price)
+
+	// Whole amounts sent as numbers are kept as written, without going through a float.
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 12345678901234567}`), &req))
+	assert.Equal(t, Decimal("12345678901234567"), req.Price)
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"price": true}`), &req), "amount must be a decimal string")
+}
+
+func TestSubscriptionJSON(t *testing.T) {
+	sub := Subscription{ServiceName: "Netflix", Price: 14999, Currency: "USD", StartDate: "01-2025"}
+
+	data, err := json.Marshal(sub)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"price":"149.99"`)
+	assert.Contains(t, string(data), `"currency":"USD"`)
+
+	var decoded Subscription
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, sub, decoded)
+}
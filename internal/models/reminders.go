@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Kinds of reminders: of an upcoming charge of a subscription and of the end of its free trial.
+const (
+	ReminderRenewal  = "renewal"
+	ReminderTrialEnd = "trial_end"
+)
+
+// Types of the events reminders are sent as; their data is the Reminder.
+const (
+	EventRenewalUpcoming = "subscription.renewal_upcoming"
+	EventTrialEnding     = "subscription.trial_ending"
+)
+
+// Reminder tells a user in advance that a subscription is about to be charged on DueDate,
+// or that its free trial ends on DueDate. Every subscription has at most one reminder of a kind for a date.
+type Reminder struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Kind           string    `json:"kind" enums:"renewal,trial_end"`
+	// DueDate is in YYYY-MM-DD form.
+	DueDate     string `json:"due_date" example:"2025-02-15"`
+	ServiceName string `json:"service_name"`
+	// Price is the price charged on DueDate, or after the trial, in minor units of Currency;
+	// it is written to JSON as a decimal string, see MarshalJSON.
+	Price     int64     `json:"price" swaggertype:"string" example:"299.00"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarshalJSON writes the price of the reminder as a decimal in its currency.
+func (r Reminder) MarshalJSON() ([]byte, error) {
+	type reminder Reminder
+	return json.Marshal(struct {
+		reminder
+		Price Decimal `json:"price"`
+	}{reminder: reminder(r), Price: FormatAmount(r.Price, r.Currency)})
+}
+
+// EventType returns the type of the event the reminder is sent as.
+func (r Reminder) EventType() string {
+	if r.Kind == ReminderTrialEnd {
+		return EventTrialEnding
+	}
+	return EventRenewalUpcoming
+}
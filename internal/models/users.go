@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+)
+
+// User is a person subscriptions belong to. Users referred to by subscriptions before they
+// were registered have no display name or email yet.
+type User struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email,omitempty"`
+	// Currency is the preferred currency of the user, in which the overview is calculated;
+	// Timezone is an IANA time zone name that determines the current day of the user.
+	Currency string `json:"currency"`
+	Timezone string `json:"timezone"`
+	// MonthlyBudget is in minor units of Currency, 0 if the user has no budget;
+	// it is written to JSON as a decimal string, see MarshalJSON.
+	MonthlyBudget int64 `json:"monthly_budget,omitempty" swaggertype:"string" example:"1500.00"`
+}
+
+// MarshalJSON writes the monthly budget of the user as a decimal in its currency.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	aux := struct {
+		user
+		MonthlyBudget *Decimal `json:"monthly_budget,omitempty"`
+	}{user: user(u)}
+	if u.MonthlyBudget != 0 {
+		budget := FormatAmount(u.MonthlyBudget, u.Currency)
+		aux.MonthlyBudget = &budget
+	}
+	return json.Marshal(aux)
+}
+
+// UserReq is the body of user creation and update requests. The monthly budget is a decimal string
+// in the preferred currency of the user.
+type UserReq struct {
+	DisplayName   string   `json:"display_name"`
+	Email         string   `json:"email,omitempty"`
+	Currency      string   `json:"currency,omitempty"`
+	Timezone      string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	MonthlyBudget *Decimal `json:"monthly_budget,omitempty" swaggertype:"string" example:"1500.00"`
+}
+
+// UserOverview summarizes the spending of a user in their preferred currency as of the current day
+// in their time zone. MonthlyCost is the cost of the current month and YearToDate the cost of every
+// month of the current year up to and including the current one.
+type UserOverview struct {
+	UserID              uuid.UUID `json:"user_id"`
+	Currency            string    `json:"currency"`
+	ActiveSubscriptions int       `json:"active_subscriptions"`
+	MonthlyCost         Decimal   `json:"monthly_cost" swaggertype:"string" example:"1299.00"`
+	MonthlyBudget       *Decimal  `json:"monthly_budget,omitempty" swaggertype:"string" example:"1500.00"`
+	YearToDate          Decimal   `json:"year_to_date" swaggertype:"string" example:"12990.00"`
+	NextRenewals        []Renewal `json:"next_renewals"`
+}
+
+// Renewal is an upcoming charge of a subscription at its current price.
+type Renewal struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	Date           string    `json:"date" example:"2025-02-15"`
+	Price          Decimal   `json:"price" swaggertype:"string" example:"299.00"`
+	Currency       string    `json:"currency"`
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"go.uber.org/zap"
+)
+
+// Log writes every event to the log instead of delivering it, e.g. in development.
+type Log struct {
+	log *zap.Logger
+}
+
+// NewLog creates a Log writing to log.
+func NewLog(log *zap.Logger) *Log {
+	return &Log{log: log}
+}
+
+// Notify logs the event at info level.
+func (l *Log) Notify(ctx context.Context, event *models.Event) error {
+	l.log.Info("Event", zap.String("id", event.ID.String()), zap.String("type", event.Type),
+		zap.Time("created_at", event.CreatedAt), zap.Any("data", event.Data))
+	return nil
+}
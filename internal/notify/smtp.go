@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"Effective_Mobile/internal/models"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// DefaultSMTPTimeout limits the delivery of an email when no timeout is configured.
+const DefaultSMTPTimeout = 10 * time.Second
+
+// SMTP emails every event to a fixed list of recipients through an SMTP server, e.g. a local relay
+// or a mail catcher in development. The body of the email is the event as JSON.
+type SMTP struct {
+	addr     string
+	host     string
+	from     string
+	to       []string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+// NewSMTP creates an SMTP notifier sending from 'from' to the recipients through the server at addr (host:port).
+// The server is authenticated to with PLAIN if username is not empty, which requires TLS unless the server
+// is local; STARTTLS is used whenever the server offers it. Deliveries time out after timeout,
+// DefaultSMTPTimeout if not positive.
+func NewSMTP(addr string, username string, password string, from string, to []string, timeout time.Duration) *SMTP {
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return &SMTP{addr: addr, host: host, from: from, to: to, username: username, password: password, timeout: timeout}
+}
+
+// Notify emails the event. Its type and ID are also sent in the X-Event-Type and X-Event-ID headers.
+func (s *SMTP) Notify(ctx context.Context, event *models.Event) error {
+	body, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server rejected data: %w", err)
+	}
+	if _, err := w.Write(s.message(event, body)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected email: %w", err)
+	}
+	return client.Quit()
+}
+
+// message returns the email of the event with the body.
+func (s *SMTP) message(event *models.Event, body []byte) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", event.Type)
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.CreatedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "X-Event-Type: %s\r\n", event.Type)
+	fmt.Fprintf(&msg, "X-Event-ID: %s\r\n", event.ID)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: application/json; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
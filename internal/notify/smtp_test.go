@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"Effective_Mobile/internal/models"
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub is an SMTP server accepting a single session and recording the email received in it.
+type smtpStub struct {
+	listener   net.Listener
+	rejectRcpt bool
+	from       string
+	to         []string
+	data       string
+	done       chan struct{}
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	stub := &smtpStub{listener: listener, done: make(chan struct{})}
+	t.Cleanup(func() { listener.Close() })
+	return stub
+}
+
+func (s *smtpStub) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP stub")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			s.from = line
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			if s.rejectRcpt {
+				text.PrintfLine("550 No such user")
+				continue
+			}
+			s.to = append(s.to, line)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	event := &models.Event{ID: uuid.New(), Type: models.EventRenewalUpcoming, CreatedAt: time.Date(2025, time.March, 8, 12, 0, 0, 0, time.UTC),
+		Data: map[string]string{"due_date": "2025-03-10"}}
+
+	// Test case 1: The event is emailed as JSON to every recipient
+	stub := newSMTPStub(t)
+	go stub.serve()
+	notifier := NewSMTP(stub.listener.Addr().String(), "", "", "subscriptions@localhost", []string{"ops@localhost", "billing@localhost"}, time.Second)
+	require.NoError(t, notifier.Notify(context.Background(), event))
+	<-stub.done
+	assert.Equal(t, "MAIL FROM:<subscriptions@localhost>", stub.from)
+	assert.Equal(t, []string{"RCPT TO:<ops@localhost>", "RCPT TO:<billing@localhost>"}, stub.to)
+
+	header, err := textproto.NewReader(bufio.NewReader(strings.NewReader(stub.data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, "ops@localhost, billing@localhost", header.Get("To"))
+	assert.Equal(t, "subscription.renewal_upcoming", header.Get("Subject"))
+	assert.Equal(t, event.ID.String(), header.Get("X-Event-ID"))
+	assert.Contains(t, stub.data, `"due_date": "2025-03-10"`)
+
+	// Test case 2: A rejected recipient is an error
+	stub = newSMTPStub(t)
+	stub.rejectRcpt = true
+	go stub.serve()
+	notifier = NewSMTP(stub.listener.Addr().String(), "", "", "subscriptions@localhost", []string{"nobody@localhost"}, time.Second)
+	assert.ErrorContains(t, notifier.Notify(context.Background(), event), "SMTP server rejected recipient nobody@localhost")
+}
@@ -0,0 +1,76 @@
+// Package notify delivers events of the service to external systems.
+package notify
+
+import (
+	"Effective_Mobile/internal/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultWebhookTimeout limits a webhook request when no timeout is configured.
+const DefaultWebhookTimeout = 5 * time.Second
+
+// SignatureHeader is the header of signed webhook requests: "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the request body keyed with the secret.
+const SignatureHeader = "X-Signature"
+
+// Webhook posts every event as JSON to a URL. The request succeeds if the receiver answers with a 2xx status.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook creates a Webhook that posts to url. Requests are signed if secret is not empty
+// and time out after timeout, DefaultWebhookTimeout if not positive.
+func NewWebhook(url string, secret string, timeout time.Duration) *Webhook {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &Webhook{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+// Notify posts the event. The type and ID of the event are also sent in the X-Event-Type and X-Event-ID headers.
+func (w *Webhook) Notify(ctx context.Context, event *models.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-ID", event.ID.String())
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain the body so that the connection can be reused.
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the value of SignatureHeader for the body.
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
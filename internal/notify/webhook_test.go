@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	event := &models.Event{ID: uuid.New(), Type: models.EventBudgetExceeded, CreatedAt: time.Date(2025, time.April, 1, 10, 0, 0, 0, time.UTC),
+		Data: map[string]string{"category": "music"}}
+
+	// Test case 1: The event is posted as JSON and signed with the secret
+	webhook := NewWebhook(server.URL, "secret", 0)
+	require.NoError(t, webhook.Notify(context.Background(), event))
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, models.EventBudgetExceeded, received.Header.Get("X-Event-Type"))
+	assert.Equal(t, event.ID.String(), received.Header.Get("X-Event-ID"))
+	assert.Equal(t, Sign([]byte("secret"), body), received.Header.Get(SignatureHeader))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, "budget.exceeded", decoded["type"])
+	assert.Equal(t, map[string]any{"category": "music"}, decoded["data"])
+
+	// Test case 2: Requests are not signed without a secret
+	require.NoError(t, NewWebhook(server.URL, "", 0).Notify(context.Background(), event))
+	assert.Empty(t, received.Header.Get(SignatureHeader))
+
+	// Test case 3: A status other than 2xx is an error
+	status = http.StatusInternalServerError
+	assert.EqualError(t, webhook.Notify(context.Background(), event), "webhook responded with status 500")
+}
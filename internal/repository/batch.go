@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BatchCreateSubs inserts the subscriptions in a single transaction.
+// In atomic mode the first failing subscription rolls the whole batch back and the remaining ones
+// are not attempted; otherwise every failing subscription is skipped and the others are stored.
+// Returns the error of every subscription (nil if it was created, or in atomic mode, would have been)
+// and an error if the transaction itself fails.
+func (r *Repository) BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	r.log.Debug("Creating subscriptions in batch", zap.Int("count", len(subs)), zap.Bool("atomic", atomic))
+	return r.runBatch(ctx, len(subs), atomic, func(ctx context.Context, tx *sql.Tx, i int) error {
+		return r.createSubs(ctx, tx, subs[i])
+	})
+}
+
+// BatchUpdateSubs updates the subscriptions in a single transaction.
+// The ID and Version of every subscription identify the row and the version it is expected to have;
+// on success Version is set to the new version. The modes and results are those of BatchCreateSubs;
+// in addition the previous owner of every updated subscription is returned, in order.
+func (r *Repository) BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]uuid.UUID, []error, error) {
+	r.log.Debug("Updating subscriptions in batch", zap.Int("count", len(subs)), zap.Bool("atomic", atomic))
+	owners := make([]uuid.UUID, len(subs))
+	errs, err := r.runBatch(ctx, len(subs), atomic, func(ctx context.Context, tx *sql.Tx, i int) error {
+		var err error
+		owners[i], err = r.updateSubs(ctx, tx, subs[i].ID, subs[i].Version, subs[i])
+		return err
+	})
+	return owners, errs, err
+}
+
+// BatchDeleteSubs deletes the subscriptions with the given IDs in a single transaction.
+// The modes and results are those of BatchCreateSubs.
+func (r *Repository) BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error) {
+	r.log.Debug("Deleting subscriptions in batch", zap.Int("count", len(ids)), zap.Bool("atomic", atomic))
+	return r.runBatch(ctx, len(ids), atomic, func(ctx context.Context, tx *sql.Tx, i int) error {
+		return r.deleteSubs(ctx, tx, ids[i])
+	})
+}
+
+// runBatch executes the statement of every item of a batch inside one transaction.
+// Each statement is limited by the query timeout on its own, so the duration of the whole batch
+// grows with its size. In best-effort mode every item runs under a savepoint: a failing item is
+// rolled back to it, which keeps the transaction usable for the items that follow.
+func (r *Repository) runBatch(ctx context.Context, n int, atomic bool, item func(ctx context.Context, tx *sql.Tx, i int) error) ([]error, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Error("Error starting batch transaction", zap.Error(err))
+		return nil, fmt.Errorf("failed to start transaction: %w", mapError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		if atomic {
+			if errs[i] = r.runBatchItem(ctx, tx, i, item); errs[i] != nil {
+				r.log.Debug("Batch item failed, rolling back", zap.Int("index", i), zap.Error(errs[i]))
+				return errs, nil
+			}
+			continue
+		}
+
+		if err := r.execInTx(ctx, tx, "SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+		if errs[i] = r.runBatchItem(ctx, tx, i, item); errs[i] != nil {
+			r.log.Debug("Batch item failed, skipping", zap.Int("index", i), zap.Error(errs[i]))
+			if err := r.execInTx(ctx, tx, "ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := r.execInTx(ctx, tx, "RELEASE SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Error committing batch transaction", zap.Error(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", mapError(err))
+	}
+	return errs, nil
+}
+
+// runBatchItem executes the statement of a single batch item within the query timeout.
+func (r *Repository) runBatchItem(ctx context.Context, tx *sql.Tx, i int, item func(ctx context.Context, tx *sql.Tx, i int) error) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return item(ctx, tx, i)
+}
+
+// execInTx executes a transaction control statement such as a savepoint within the query timeout.
+func (r *Repository) execInTx(ctx context.Context, tx *sql.Tx, query string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		r.log.Error("Error executing batch statement", zap.String("query", query), zap.Error(err))
+		return fmt.Errorf("failed to execute %q: %w", query, mapError(err))
+	}
+	return nil
+}
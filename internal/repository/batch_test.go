@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchCreateSubs(t *testing.T) {
+	subs := []*models.Subscription{
+		{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Service A", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-01"},
+		{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Service B", Price: 200, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-02-01"},
+	}
+	expectInsert := func(sub *models.Subscription) *sqlmock.ExpectedQuery {
+		expectServiceName(sub)
+		return sqlMock.ExpectQuery(createSubsQuery).WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.Category, pq.StringArray(sub.Tags), sub.TrialEndDate)
+	}
+
+	// Test atomic batch is committed
+	sqlMock.ExpectBegin()
+	expectInsert(subs[0]).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	expectInsert(subs[1]).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	sqlMock.ExpectCommit()
+
+	errs, err := repo.BatchCreateSubs(context.Background(), subs, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, 1, subs[1].Version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test atomic batch is rolled back on the first failing item
+	sqlMock.ExpectBegin()
+	expectInsert(subs[0]).WillReturnError(&pq.Error{Code: "23505"})
+	sqlMock.ExpectRollback()
+
+	errs, err = repo.BatchCreateSubs(context.Background(), subs, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], service.ErrConflict)
+	assert.Nil(t, errs[1])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test best-effort batch skips the failing item
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectInsert(subs[0]).WillReturnError(&pq.Error{Code: "23505"})
+	sqlMock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectInsert(subs[1]).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	sqlMock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	errs, err = repo.BatchCreateSubs(context.Background(), subs, false)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], service.ErrConflict)
+	assert.Nil(t, errs[1])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test commit error
+	sqlMock.ExpectBegin()
+	expectInsert(subs[0]).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	expectInsert(subs[1]).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	sqlMock.ExpectCommit().WillReturnError(errors.New("db error"))
+
+	errs, err = repo.BatchCreateSubs(context.Background(), subs, true)
+	assert.Error(t, err)
+	assert.Nil(t, errs)
+	assert.Contains(t, err.Error(), "failed to commit transaction")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestBatchUpdateSubs(t *testing.T) {
+	sub := &models.Subscription{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Service A", Price: 300, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-03-01", Version: 2}
+
+	sqlMock.ExpectBegin()
+	expectServiceName(sub)
+	sqlMock.ExpectQuery(updateSubsQuery).WithArgs(sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.StartDate, sub.EndDate, sub.ID, 2, sub.ServiceID, sub.Category, pq.StringArray(sub.Tags), sub.TrialEndDate, sub.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(nil, nil, 300, "RUB", 3, "active", sub.UserID))
+	sqlMock.ExpectRollback()
+
+	_, errs, err := repo.BatchUpdateSubs(context.Background(), []*models.Subscription{sub}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, errs[0], service.ErrPreconditionFailed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestBatchDeleteSubs(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(ids[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(ids[1]).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	errs, err := repo.BatchDeleteSubs(context.Background(), ids, false)
+	assert.NoError(t, err)
+	assert.Nil(t, errs[0])
+	assert.ErrorIs(t, errs[1], service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
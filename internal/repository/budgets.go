@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// SetBudget stores the budget of the category of the user, replacing the budget previously set for it.
+// Returns service.ErrNotFound if the user does not exist.
+func (r *Repository) SetBudget(ctx context.Context, budget *models.Budget) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Setting budget", zap.String("user_id", budget.UserID.String()), zap.String("category", budget.Category))
+
+	query := `
+		INSERT INTO budgets (user_id, category, amount, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, category) DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency
+	`
+	if _, err := r.db.ExecContext(ctx, query, budget.UserID, budget.Category, budget.Amount, budget.Currency); err != nil {
+		err = mapError(err)
+		// The foreign key of the user is the only constraint a valid budget can violate.
+		if errors.Is(err, service.ErrConstraintViolation) {
+			r.log.Debug("User not found", zap.String("id", budget.UserID.String()))
+			return fmt.Errorf("user %w", service.ErrNotFound)
+		}
+		r.log.Error("Error setting budget", zap.Error(err))
+		return fmt.Errorf("failed to set budget: %w", err)
+	}
+	return nil
+}
+
+// DeleteBudget removes the budget of the category of the user.
+// Returns service.ErrNotFound if no budget is set for it.
+func (r *Repository) DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Deleting budget", zap.String("user_id", userID.String()), zap.String("category", category))
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE user_id = $1 AND category = $2`, userID, category)
+	if err != nil {
+		r.log.Error("Error deleting budget", zap.Error(err))
+		return fmt.Errorf("failed to delete budget: %w", mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		r.log.Debug("Budget not found", zap.String("user_id", userID.String()), zap.String("category", category))
+		return fmt.Errorf("budget %w", service.ErrNotFound)
+	}
+	return nil
+}
+
+// ListBudgets returns the category budgets of the user ordered by category.
+func (r *Repository) ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing budgets", zap.String("user_id", userID.String()))
+
+	query := `SELECT user_id, category, amount, currency FROM budgets WHERE user_id = $1 ORDER BY category`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.log.Error("Error listing budgets", zap.Error(err))
+		return nil, fmt.Errorf("failed to query budgets: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	budgets := []models.Budget{}
+	for rows.Next() {
+		var budget models.Budget
+		if err := rows.Scan(&budget.UserID, &budget.Category, &budget.Amount, &budget.Currency); err != nil {
+			r.log.Error("failed to scan budget", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over budget rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over budget rows: %w", err)
+	}
+	return budgets, nil
+}
+
+// CreateAlert records the budget alert unless the budget has already raised one in the month.
+// Reports whether the alert was recorded; if so, alert.CreatedAt is set.
+func (r *Repository) CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Creating budget alert", zap.String("user_id", alert.UserID.String()), zap.String("category", alert.Category), zap.String("month", alert.Month))
+
+	query := `
+		INSERT INTO budget_alerts (id, user_id, category, month, budget, spent, currency)
+		VALUES ($1, $2, $3, to_date($4, 'MM-YYYY'), $5, $6, $7)
+		ON CONFLICT (user_id, category, month) DO NOTHING
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(ctx, query, alert.ID, alert.UserID, alert.Category, alert.Month, alert.Budget, alert.Spent, alert.Currency).
+		Scan(&alert.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.log.Error("Error creating budget alert", zap.Error(err))
+		return false, fmt.Errorf("failed to create budget alert: %w", mapError(err))
+	}
+	return true, nil
+}
+
+// ListAlerts returns the budget alerts matching the filter, the most recent first.
+func (r *Repository) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing budget alerts", zap.Any("filter", filter))
+
+	query := `
+		SELECT id, user_id, category, to_char(month, 'MM-YYYY'), budget, spent, currency, created_at
+		FROM budget_alerts
+		WHERE
+			($1::uuid IS NULL OR user_id = $1) AND
+			($2::text = '' OR month = to_date($2, 'MM-YYYY'))
+		ORDER BY created_at DESC, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, filter.Month)
+	if err != nil {
+		r.log.Error("Error listing budget alerts", zap.Error(err))
+		return nil, fmt.Errorf("failed to query budget alerts: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	alerts := []models.BudgetAlert{}
+	for rows.Next() {
+		var alert models.BudgetAlert
+		if err := rows.Scan(&alert.ID, &alert.UserID, &alert.Category, &alert.Month, &alert.Budget, &alert.Spent, &alert.Currency, &alert.CreatedAt); err != nil {
+			r.log.Error("failed to scan budget alert", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over budget alert rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over budget alert rows: %w", err)
+	}
+	return alerts, nil
+}
+
+// ClaimAlerts reserves up to limit undelivered budget alerts for the lease, the oldest first, and returns them.
+// Alerts are claimed like reminders, see ClaimReminders: those claimed by another replica whose lease has not
+// expired and those already attempted maxAttempts times are skipped.
+func (r *Repository) ClaimAlerts(ctx context.Context, limit int, lease time.Duration, maxAttempts int) ([]models.BudgetAlert, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Claiming budget alerts", zap.Int("limit", limit))
+
+	query := `
+		UPDATE budget_alerts
+		SET attempts = attempts + 1, claimed_until = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM budget_alerts
+			WHERE
+				sent_at IS NULL AND
+				attempts < $3 AND
+				(claimed_until IS NULL OR claimed_until < now())
+			ORDER BY created_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, category, to_char(month, 'MM-YYYY'), budget, spent, currency, created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds(), maxAttempts)
+	if err != nil {
+		r.log.Error("Error claiming budget alerts", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim budget alerts: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	alerts := []models.BudgetAlert{}
+	for rows.Next() {
+		var alert models.BudgetAlert
+		if err := rows.Scan(&alert.ID, &alert.UserID, &alert.Category, &alert.Month, &alert.Budget, &alert.Spent, &alert.Currency, &alert.CreatedAt); err != nil {
+			r.log.Error("failed to scan budget alert", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over budget alert rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over budget alert rows: %w", err)
+	}
+	return alerts, nil
+}
+
+// MarkAlertSent records that the budget alert has been delivered, so that it is not claimed again.
+func (r *Repository) MarkAlertSent(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE budget_alerts SET sent_at = now(), claimed_until = NULL WHERE id = $1`, id); err != nil {
+		r.log.Error("Error marking budget alert sent", zap.Error(err))
+		return fmt.Errorf("failed to mark budget alert sent: %w", mapError(err))
+	}
+	return nil
+}
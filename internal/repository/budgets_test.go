@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAndDeleteBudget(t *testing.T) {
+	budget := &models.Budget{UserID: uuid.New(), Category: "music", Amount: 30000, Currency: "RUB"}
+	setQuery := "INSERT INTO budgets (user_id, category, amount, currency) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, category) DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency"
+	deleteQuery := "DELETE FROM budgets WHERE user_id = $1 AND category = $2"
+
+	// Test case 1: Budget set
+	sqlMock.ExpectExec(setQuery).WithArgs(budget.UserID, "music", 30000, "RUB").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.SetBudget(context.Background(), budget))
+
+	// Test case 2: Unknown user
+	sqlMock.ExpectExec(setQuery).WithArgs(budget.UserID, "music", 30000, "RUB").WillReturnError(&pq.Error{Code: "23503"})
+	assert.ErrorIs(t, repo.SetBudget(context.Background(), budget), service.ErrNotFound)
+
+	// Test case 3: Budget deleted
+	sqlMock.ExpectExec(deleteQuery).WithArgs(budget.UserID, "music").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.DeleteBudget(context.Background(), budget.UserID, "music"))
+
+	// Test case 4: No budget for the category
+	sqlMock.ExpectExec(deleteQuery).WithArgs(budget.UserID, "video").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.DeleteBudget(context.Background(), budget.UserID, "video"), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreateAndListAlerts(t *testing.T) {
+	alert := &models.BudgetAlert{ID: uuid.New(), UserID: uuid.New(), Category: "music", Month: "05-2025", Budget: 30000, Spent: 39900, Currency: "RUB"}
+	createQuery := "INSERT INTO budget_alerts (id, user_id, category, month, budget, spent, currency) VALUES ($1, $2, $3, to_date($4, 'MM-YYYY'), $5, $6, $7) ON CONFLICT (user_id, category, month) DO NOTHING RETURNING created_at"
+	createdAt := time.Date(2025, time.May, 3, 12, 0, 0, 0, time.UTC)
+
+	// Test case 1: Alert recorded
+	sqlMock.ExpectQuery(createQuery).WithArgs(alert.ID, alert.UserID, "music", "05-2025", 30000, 39900, "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+	created, err := repo.CreateAlert(context.Background(), alert)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, createdAt, alert.CreatedAt)
+
+	// Test case 2: The budget has already raised an alert in the month
+	sqlMock.ExpectQuery(createQuery).WithArgs(alert.ID, alert.UserID, "music", "05-2025", 30000, 39900, "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+	created, err = repo.CreateAlert(context.Background(), alert)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	// Test case 3: Alerts of a user
+	sqlMock.ExpectQuery("SELECT id, user_id, category, to_char(month, 'MM-YYYY'), budget, spent, currency, created_at FROM budget_alerts WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::text = '' OR month = to_date($2, 'MM-YYYY')) ORDER BY created_at DESC, id").
+		WithArgs(alert.UserID, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category", "month", "budget", "spent", "currency", "created_at"}).
+			AddRow(alert.ID, alert.UserID, "music", "05-2025", 30000, 39900, "RUB", createdAt))
+	alerts, err := repo.ListAlerts(context.Background(), models.AlertFilter{UserID: &alert.UserID})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BudgetAlert{*alert}, alerts)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestClaimAlerts(t *testing.T) {
+	alert := models.BudgetAlert{ID: uuid.New(), UserID: uuid.New(), Category: "music", Month: "05-2025", Budget: 30000, Spent: 39900, Currency: "RUB",
+		CreatedAt: time.Date(2025, time.May, 3, 12, 0, 0, 0, time.UTC)}
+
+	// Test case 1: Undelivered alerts are claimed for the lease, skipping rows locked by other replicas
+	sqlMock.ExpectQuery("UPDATE budget_alerts SET attempts = attempts + 1, claimed_until = now() + $2 * interval '1 second' WHERE id IN ( SELECT id FROM budget_alerts WHERE sent_at IS NULL AND attempts < $3 AND (claimed_until IS NULL OR claimed_until < now()) ORDER BY created_at, id LIMIT $1 FOR UPDATE SKIP LOCKED ) RETURNING id, user_id, category, to_char(month, 'MM-YYYY'), budget, spent, currency, created_at").
+		WithArgs(100, float64(300), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "category", "month", "budget", "spent", "currency", "created_at"}).
+			AddRow(alert.ID, alert.UserID, "music", "05-2025", 30000, 39900, "RUB", alert.CreatedAt))
+	claimed, err := repo.ClaimAlerts(context.Background(), 100, 5*time.Minute, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.BudgetAlert{alert}, claimed)
+
+	// Test case 2: A delivered alert is marked as sent
+	sqlMock.ExpectExec("UPDATE budget_alerts SET sent_at = now(), claimed_until = NULL WHERE id = $1").WithArgs(alert.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.MarkAlertSent(context.Background(), alert.ID))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
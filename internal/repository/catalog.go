@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// serviceColumns are the columns of the services table in the order scanned by scanService.
+// A missing default price is stored as NULL and read as 0.
+const serviceColumns = `id, name, slug, category, COALESCE(default_price, 0), currency, website`
+
+// CreateService inserts the service into the catalog.
+// Returns service.ErrConflict if a service with the same slug exists.
+func (r *Repository) CreateService(ctx context.Context, svc *models.Service) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Creating service", zap.String("slug", svc.Slug))
+
+	query := `
+		INSERT INTO services (id, name, slug, category, default_price, currency, website)
+		VALUES ($1, $2, $3, $4, NULLIF($5::bigint, 0), $6, $7)
+	`
+	if _, err := r.db.ExecContext(ctx, query, svc.ID, svc.Name, svc.Slug, svc.Category, svc.DefaultPrice, svc.Currency, svc.Website); err != nil {
+		r.log.Error("Error creating service", zap.Error(err))
+		return fmt.Errorf("failed to create service: %w", mapError(err))
+	}
+	return nil
+}
+
+// UpdateService replaces the catalog entry with the ID of svc. The subscriptions of the service
+// get its new name in the same statement, and their versions are incremented if it changed.
+// Returns service.ErrNotFound if the service does not exist, or service.ErrConflict
+// if another service has the new slug.
+func (r *Repository) UpdateService(ctx context.Context, svc *models.Service) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Updating service", zap.String("id", svc.ID.String()), zap.String("slug", svc.Slug))
+
+	query := `
+		WITH updated AS (
+			UPDATE services
+			SET
+				name = $2,
+				slug = $3,
+				category = $4,
+				default_price = NULLIF($5::bigint, 0),
+				currency = $6,
+				website = $7
+			WHERE id = $1
+			RETURNING id, name
+		), renamed AS (
+			UPDATE subscriptions
+			SET service_name = updated.name, version = version + 1
+			FROM updated
+			WHERE subscriptions.service_id = updated.id AND subscriptions.service_name <> updated.name
+		)
+		SELECT count(*) FROM updated
+	`
+	var updated int
+	err := r.db.QueryRowContext(ctx, query, svc.ID, svc.Name, svc.Slug, svc.Category, svc.DefaultPrice, svc.Currency, svc.Website).Scan(&updated)
+	if err != nil {
+		r.log.Error("Error updating service", zap.Error(err))
+		return fmt.Errorf("failed to update service: %w", mapError(err))
+	}
+	if updated == 0 {
+		r.log.Debug("Service not found", zap.String("id", svc.ID.String()))
+		return fmt.Errorf("service %w", service.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteService removes the service from the catalog.
+// Returns service.ErrNotFound if the service does not exist, or service.ErrConflict
+// if subscriptions still refer to it.
+func (r *Repository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Deleting service", zap.String("id", id.String()))
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		err = mapError(err)
+		// The foreign key of subscriptions is the only constraint a deletion can violate.
+		if errors.Is(err, service.ErrConstraintViolation) {
+			r.log.Debug("Service is in use", zap.String("id", id.String()))
+			return fmt.Errorf("service %s has subscriptions: %w", id, service.ErrConflict)
+		}
+		r.log.Error("Error deleting service", zap.Error(err))
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		r.log.Debug("Service not found", zap.String("id", id.String()))
+		return fmt.Errorf("service %w", service.ErrNotFound)
+	}
+	return nil
+}
+
+// GetService returns the catalog service with the ID, or service.ErrNotFound.
+func (r *Repository) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Getting service", zap.String("id", id.String()))
+
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = $1`
+	svc, err := scanService(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		r.log.Debug("Service not found", zap.String("id", id.String()))
+		return nil, fmt.Errorf("service %w", service.ErrNotFound)
+	}
+	if err != nil {
+		r.log.Error("Error getting service", zap.Error(err))
+		return nil, fmt.Errorf("failed to get service: %w", mapError(err))
+	}
+	return svc, nil
+}
+
+// ListServices returns the catalog services in the category, or all of them if it is empty,
+// ordered by name.
+func (r *Repository) ListServices(ctx context.Context, category string) ([]models.Service, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing services", zap.String("category", category))
+
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		WHERE $1::text = '' OR category = $1
+		ORDER BY name, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, category)
+	if err != nil {
+		r.log.Error("Error listing services", zap.Error(err))
+		return nil, fmt.Errorf("failed to query services: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	services := []models.Service{}
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			r.log.Error("failed to scan service", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan service: %w", err)
+		}
+		services = append(services, *svc)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over service rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over service rows: %w", err)
+	}
+	return services, nil
+}
+
+// scanService scans the serviceColumns of a row.
+func scanService(row interface{ Scan(dest ...any) error }) (*models.Service, error) {
+	var svc models.Service
+	if err := row.Scan(&svc.ID, &svc.Name, &svc.Slug, &svc.Category, &svc.DefaultPrice, &svc.Currency, &svc.Website); err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// resolveService links the subscription to its service in the catalog using q, which is either
+// the database or a transaction: to the service with sub.ServiceID if it is set, otherwise to the one
+// whose slug is that of sub.ServiceName, which is added to the catalog if there is none yet.
+// sub.ServiceID and sub.ServiceName are set to the ID and name of the service in the catalog,
+// and an empty sub.Category to the category of the service.
+// An unknown sub.ServiceID is reported as a service.ValidationError.
+func (r *Repository) resolveService(ctx context.Context, q querier, sub *models.Subscription) error {
+	var category string
+	if sub.ServiceID != uuid.Nil {
+		err := q.QueryRowContext(ctx, `SELECT name, category FROM services WHERE id = $1`, sub.ServiceID).Scan(&sub.ServiceName, &category)
+		if errors.Is(err, sql.ErrNoRows) {
+			return &service.ValidationError{Reason: fmt.Sprintf("unknown service %s", sub.ServiceID)}
+		}
+		if err != nil {
+			r.log.Error("Error getting service", zap.Error(err))
+			return fmt.Errorf("failed to get service: %w", mapError(err))
+		}
+	} else {
+		// The no-op update makes RETURNING yield the existing service on a conflict.
+		query := `
+			INSERT INTO services (id, name, slug, currency)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+			RETURNING id, name, category
+		`
+		err := q.QueryRowContext(ctx, query, uuid.New(), sub.ServiceName, models.Slug(sub.ServiceName), sub.Currency).
+			Scan(&sub.ServiceID, &sub.ServiceName, &category)
+		if err != nil {
+			r.log.Error("Error resolving service", zap.Error(err))
+			return fmt.Errorf("failed to resolve service: %w", mapError(err))
+		}
+	}
+	if sub.Category == "" {
+		sub.Category = category
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var serviceRows = []string{"id", "name", "slug", "category", "default_price", "currency", "website"}
+
+func TestCreateService(t *testing.T) {
+	svc := &models.Service{ID: uuid.New(), Name: "Yandex Plus", Slug: "yandex-plus", Category: "music", Currency: "RUB"}
+	query := "INSERT INTO services (id, name, slug, category, default_price, currency, website) VALUES ($1, $2, $3, $4, NULLIF($5::bigint, 0), $6, $7)"
+
+	// Test case 1: Service created
+	sqlMock.ExpectExec(query).WithArgs(svc.ID, "Yandex Plus", "yandex-plus", "music", 0, "RUB", "").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.CreateService(context.Background(), svc))
+
+	// Test case 2: Slug taken by another service
+	sqlMock.ExpectExec(query).WithArgs(svc.ID, "Yandex Plus", "yandex-plus", "music", 0, "RUB", "").WillReturnError(&pq.Error{Code: "23505"})
+	assert.ErrorIs(t, repo.CreateService(context.Background(), svc), service.ErrConflict)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUpdateService(t *testing.T) {
+	svc := &models.Service{ID: uuid.New(), Name: "Netflix", Slug: "netflix", DefaultPrice: 99900, Currency: "RUB", Website: "https://netflix.com"}
+	query := "WITH updated AS ( UPDATE services SET name = $2, slug = $3, category = $4, default_price = NULLIF($5::bigint, 0), currency = $6, website = $7 WHERE id = $1 RETURNING id, name ), renamed AS ( UPDATE subscriptions SET service_name = updated.name, version = version + 1 FROM updated WHERE subscriptions.service_id = updated.id AND subscriptions.service_name <> updated.name ) SELECT count(*) FROM updated"
+	args := []driver.Value{svc.ID, "Netflix", "netflix", "", 99900, "RUB", "https://netflix.com"}
+
+	// Test case 1: Service updated
+	sqlMock.ExpectQuery(query).WithArgs(args...).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	assert.NoError(t, repo.UpdateService(context.Background(), svc))
+
+	// Test case 2: Service not found
+	sqlMock.ExpectQuery(query).WithArgs(args...).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	assert.ErrorIs(t, repo.UpdateService(context.Background(), svc), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDeleteService(t *testing.T) {
+	id := uuid.New()
+	query := "DELETE FROM services WHERE id = $1"
+
+	// Test case 1: Service deleted
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.DeleteService(context.Background(), id))
+
+	// Test case 2: Service still has subscriptions
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnError(&pq.Error{Code: "23503"})
+	assert.ErrorIs(t, repo.DeleteService(context.Background(), id), service.ErrConflict)
+
+	// Test case 3: Service not found
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.DeleteService(context.Background(), id), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetAndListServices(t *testing.T) {
+	svc := models.Service{ID: uuid.New(), Name: "Spotify", Slug: "spotify", Category: "music", DefaultPrice: 29900, Currency: "RUB"}
+
+	// Test case 1: Service found
+	sqlMock.ExpectQuery("SELECT id, name, slug, category, COALESCE(default_price, 0), currency, website FROM services WHERE id = $1").WithArgs(svc.ID).
+		WillReturnRows(sqlmock.NewRows(serviceRows).AddRow(svc.ID, svc.Name, svc.Slug, svc.Category, svc.DefaultPrice, svc.Currency, svc.Website))
+	found, err := repo.GetService(context.Background(), svc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, svc, *found)
+
+	// Test case 2: Service not found
+	sqlMock.ExpectQuery("SELECT id, name, slug, category, COALESCE(default_price, 0), currency, website FROM services WHERE id = $1").WithArgs(svc.ID).WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetService(context.Background(), svc.ID)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+
+	// Test case 3: Services of a category
+	sqlMock.ExpectQuery("SELECT id, name, slug, category, COALESCE(default_price, 0), currency, website FROM services WHERE $1::text = '' OR category = $1 ORDER BY name, id").WithArgs("music").
+		WillReturnRows(sqlmock.NewRows(serviceRows).AddRow(svc.ID, svc.Name, svc.Slug, svc.Category, svc.DefaultPrice, svc.Currency, svc.Website))
+	services, err := repo.ListServices(context.Background(), "music")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Service{svc}, services)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestResolveService(t *testing.T) {
+	existing := uuid.New()
+
+	// Test case 1: A service name is matched to the catalog service with the same slug, whose category is taken
+	sub := &models.Subscription{ServiceName: "yandex  plus", Currency: "RUB"}
+	sqlMock.ExpectQuery(upsertServiceQuery).WithArgs(sqlmock.AnyArg(), "yandex  plus", "yandex-plus", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category"}).AddRow(existing, "Yandex Plus", "music"))
+	assert.NoError(t, repo.resolveService(context.Background(), repo.db, sub))
+	assert.Equal(t, existing, sub.ServiceID)
+	assert.Equal(t, "Yandex Plus", sub.ServiceName)
+	assert.Equal(t, "music", sub.Category)
+
+	// Test case 2: The category of the subscription itself is kept
+	sub = &models.Subscription{ServiceID: existing, Category: "family"}
+	sqlMock.ExpectQuery(serviceNameQuery).WithArgs(existing).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "category"}).AddRow("Yandex Plus", "music"))
+	assert.NoError(t, repo.resolveService(context.Background(), repo.db, sub))
+	assert.Equal(t, "family", sub.Category)
+
+	// Test case 3: An unknown service ID is a validation error
+	sub = &models.Subscription{ServiceID: uuid.New()}
+	sqlMock.ExpectQuery(serviceNameQuery).WithArgs(sub.ServiceID).WillReturnError(sql.ErrNoRows)
+	err := repo.resolveService(context.Background(), repo.db, sub)
+	assert.ErrorIs(t, err, service.ErrValidation)
+
+	// Test case 4: Database error
+	sub = &models.Subscription{ServiceName: "Netflix", Currency: "RUB"}
+	sqlMock.ExpectQuery(upsertServiceQuery).WithArgs(sqlmock.AnyArg(), "Netflix", "netflix", "RUB").WillReturnError(errors.New("db error"))
+	err = repo.resolveService(context.Background(), repo.db, sub)
+	assert.ErrorContains(t, err, "failed to resolve service")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/service"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// mapError translates PostgreSQL errors into the domain errors of the service layer.
+// The original error stays in the chain, so its details are still available for logging.
+// Errors that are not reported by PostgreSQL are returned unchanged.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch {
+	// unique_violation, exclusion_violation
+	case pqErr.Code == "23505" || pqErr.Code == "23P01":
+		return fmt.Errorf("%w: %w", service.ErrConflict, err)
+	// check_violation, not_null_violation, foreign_key_violation and the rest of class 23
+	case pqErr.Code.Class() == "23":
+		return fmt.Errorf("%w: %w", service.ErrConstraintViolation, err)
+	// class 22 (data exception): malformed dates, numeric values out of range, etc.
+	case pqErr.Code.Class() == "22":
+		return fmt.Errorf("%w: %w", service.ErrValidation, err)
+	default:
+		return err
+	}
+}
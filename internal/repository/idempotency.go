@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ReserveIdempotencyKey claims the idempotency key for a new request.
+// It returns nil if the key was free (or its previous record has expired) and is now reserved
+// for the caller, or the existing record if the key is already taken.
+// The record expires after ttl, after which the key can be reused. Until its response is saved,
+// the reservation only holds for the lease: a request whose process stopped before saving it
+// leaves a reservation that the next request with the key takes over once the lease has expired.
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, key string, requestHash string, ttl time.Duration, lease time.Duration) (*models.IdempotencyRecord, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Reserving idempotency key", zap.String("key", key))
+	// Insert the key, or take over an expired record or an abandoned reservation with the same key.
+	// RETURNING yields no rows when an active record already holds the key.
+	reserveQuery := `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at, claimed_until)
+		VALUES ($1, $2, now() + make_interval(secs => $3), now() + make_interval(secs => $4))
+		ON CONFLICT (key) DO UPDATE
+		SET 
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			headers = NULL,
+			response = NULL,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at,
+			claimed_until = EXCLUDED.claimed_until
+		WHERE idempotency_keys.expires_at <= now()
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.claimed_until <= now())
+		RETURNING key
+	`
+	recordQuery := `
+		SELECT key, request_hash, COALESCE(status_code, 0), headers, response
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	// The holder of the key may release it between the two statements; the key is then free
+	// again and the reservation is retried once.
+	for attempt := 1; ; attempt++ {
+		var reserved string
+		err := r.db.QueryRowContext(ctx, reserveQuery, key, requestHash, ttl.Seconds(), lease.Seconds()).Scan(&reserved)
+		if err == nil {
+			r.log.Debug("Idempotency key reserved", zap.String("key", key))
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.log.Error("Error reserving idempotency key", zap.Error(err))
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", mapError(err))
+		}
+
+		// The key is held by another request: return its record.
+		var record models.IdempotencyRecord
+		var headers []byte
+		err = r.db.QueryRowContext(ctx, recordQuery, key).Scan(
+			&record.Key,
+			&record.RequestHash,
+			&record.StatusCode,
+			&headers,
+			&record.Response,
+		)
+		if errors.Is(err, sql.ErrNoRows) && attempt < 2 {
+			r.log.Debug("Idempotency key released concurrently, retrying", zap.String("key", key))
+			continue
+		}
+		if err != nil {
+			r.log.Error("Error getting idempotency key", zap.Error(err))
+			return nil, fmt.Errorf("failed to get idempotency key: %w", mapError(err))
+		}
+		if headers != nil {
+			if err := json.Unmarshal(headers, &record.Headers); err != nil {
+				r.log.Error("Error decoding idempotent response headers", zap.Error(err))
+				return nil, fmt.Errorf("failed to decode idempotent response headers: %w", err)
+			}
+		}
+		return &record, nil
+	}
+}
+
+// SaveIdempotentResponse stores the response of the request that reserved the key,
+// so that repeated requests with the same key can be answered with it. If a request that took over
+// the reservation after its lease expired has already saved its response, that one is kept.
+func (r *Repository) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response headers: %w", err)
+	}
+	query := `UPDATE idempotency_keys SET status_code = $1, headers = $2, response = $3, claimed_until = NULL WHERE key = $4 AND status_code IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, statusCode, encoded, response, key); err != nil {
+		r.log.Error("Error saving idempotent response", zap.Error(err))
+		return fmt.Errorf("failed to save idempotent response: %w", mapError(err))
+	}
+	r.log.Debug("Idempotent response saved", zap.String("key", key), zap.Int("status", statusCode))
+	return nil
+}
+
+// ReleaseIdempotencyKey removes a reservation whose request did not complete,
+// so that the client can retry with the same key.
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		r.log.Error("Error releasing idempotency key", zap.Error(err))
+		return fmt.Errorf("failed to release idempotency key: %w", mapError(err))
+	}
+	r.log.Debug("Idempotency key released", zap.String("key", key))
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes up to limit records whose idempotency key has expired and returns
+// the number deleted. Expired records are otherwise only replaced by a later request with the same key.
+func (r *Repository) PurgeIdempotencyKeys(ctx context.Context, limit int) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE key IN (SELECT key FROM idempotency_keys WHERE expires_at <= now() LIMIT $1)
+	`
+	result, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		r.log.Error("Error purging idempotency keys", zap.Error(err))
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", mapError(err))
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	r.log.Debug("Idempotency keys purged", zap.Int64("count", purged))
+	return purged, nil
+}
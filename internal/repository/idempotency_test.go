@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const reserveIdempotencyKeyQuery = "INSERT INTO idempotency_keys (key, request_hash, expires_at, claimed_until) VALUES ($1, $2, now() + make_interval(secs => $3), now() + make_interval(secs => $4)) ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = NULL, headers = NULL, response = NULL, created_at = now(), expires_at = EXCLUDED.expires_at, claimed_until = EXCLUDED.claimed_until WHERE idempotency_keys.expires_at <= now() OR (idempotency_keys.status_code IS NULL AND idempotency_keys.claimed_until <= now()) RETURNING key"
+
+const idempotencyRecordQuery = "SELECT key, request_hash, COALESCE(status_code, 0), headers, response FROM idempotency_keys WHERE key = $1"
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	// Test case 1: Free key, or one whose record has expired or whose reservation was abandoned, is reserved
+	sqlMock.ExpectQuery(reserveIdempotencyKeyQuery).WithArgs("key", "hash", float64(3600), float64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("key"))
+
+	record, err := repo.ReserveIdempotencyKey(context.Background(), "key", "hash", time.Hour, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 2: Taken key returns the stored record
+	sqlMock.ExpectQuery(reserveIdempotencyKeyQuery).WithArgs("key", "hash", float64(3600), float64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	sqlMock.ExpectQuery(idempotencyRecordQuery).WithArgs("key").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "headers", "response"}).AddRow("key", "hash", 200, []byte(`{"ETag":"\"1\""}`), []byte(`{}`)))
+
+	record, err = repo.ReserveIdempotencyKey(context.Background(), "key", "hash", time.Hour, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", record.RequestHash)
+	assert.Equal(t, 200, record.StatusCode)
+	assert.Equal(t, map[string]string{"ETag": `"1"`}, record.Headers)
+	assert.Equal(t, []byte(`{}`), record.Response)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 3: Key released between the two statements is reserved on the retry
+	sqlMock.ExpectQuery(reserveIdempotencyKeyQuery).WithArgs("key", "hash", float64(3600), float64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	sqlMock.ExpectQuery(idempotencyRecordQuery).WithArgs("key").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "headers", "response"}))
+	sqlMock.ExpectQuery(reserveIdempotencyKeyQuery).WithArgs("key", "hash", float64(3600), float64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("key"))
+
+	record, err = repo.ReserveIdempotencyKey(context.Background(), "key", "hash", time.Hour, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 4: A key that keeps disappearing is an error after the retry
+	for range 2 {
+		sqlMock.ExpectQuery(reserveIdempotencyKeyQuery).WithArgs("key", "hash", float64(3600), float64(300)).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}))
+		sqlMock.ExpectQuery(idempotencyRecordQuery).WithArgs("key").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "headers", "response"}))
+	}
+
+	_, err = repo.ReserveIdempotencyKey(context.Background(), "key", "hash", time.Hour, 5*time.Minute)
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSaveIdempotentResponse(t *testing.T) {
+	sqlMock.ExpectExec("UPDATE idempotency_keys SET status_code = $1, headers = $2, response = $3, claimed_until = NULL WHERE key = $4 AND status_code IS NULL").
+		WithArgs(201, []byte(`{"ETag":"\"1\""}`), []byte(`{}`), "key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.SaveIdempotentResponse(context.Background(), "key", 201, map[string]string{"ETag": `"1"`}, []byte(`{}`))
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	sqlMock.ExpectExec("DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL").WithArgs("key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.ReleaseIdempotencyKey(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPurgeIdempotencyKeys(t *testing.T) {
+	sqlMock.ExpectExec("DELETE FROM idempotency_keys WHERE key IN (SELECT key FROM idempotency_keys WHERE expires_at <= now() LIMIT $1)").WithArgs(1000).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := repo.PurgeIdempotencyKeys(context.Background(), 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"io"
+	"time"
+)
+
+// dateLayout is the YYYY-MM-DD form in which subscription dates arrive.
+const dateLayout = "2006-01-02"
+
+// CopySubs inserts the subscriptions returned by next with the COPY protocol, which is
+// much faster than separate INSERT statements for large imports. next returns io.EOF after
+// the last subscription; any other error aborts the copy. All subscriptions are inserted in
+// a single transaction, so either all of them are stored or none. The price of every subscription
+// is recorded as the first entry of its price history, and users not registered yet are registered.
+// Subscriptions are linked to their catalog services as described in resolveService. The connection
+// of the transaction is busy with the copy, so the services are resolved outside of it, and those
+// added to the catalog remain there even if the copy fails.
+// The query timeout does not apply: the copy lasts as long as the source keeps producing rows.
+// Returns the number of inserted subscriptions.
+func (r *Repository) CopySubs(ctx context.Context, next func() (*models.Subscription, error)) (int64, error) {
+	r.log.Debug("Copying subscriptions")
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Error("Error starting copy transaction", zap.Error(err))
+		return 0, fmt.Errorf("failed to start transaction: %w", mapError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("subscriptions", "id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "service_id", "category", "tags", "trial_end_date"))
+	if err != nil {
+		r.log.Error("Error starting copy", zap.Error(err))
+		return 0, fmt.Errorf("failed to start copy: %w", mapError(err))
+	}
+	defer stmt.Close()
+
+	var count int64
+	// COPY cannot insert into two tables at once, so the history is written after the copy.
+	var ids pq.StringArray
+	// Catalog services already resolved, by slug.
+	resolved := make(map[string]models.Service)
+	for {
+		subs, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if err := r.copyService(ctx, subs, resolved); err != nil {
+			return 0, err
+		}
+		startDate, endDate, trialEnd, err := copyDates(subs)
+		if err != nil {
+			return 0, err
+		}
+		// COPY stores a NULL instead of the default of the column, so missing tags are an empty array.
+		tags := pq.StringArray(subs.Tags)
+		if tags == nil {
+			tags = pq.StringArray{}
+		}
+		if _, err := stmt.ExecContext(ctx, subs.ID, subs.ServiceName, subs.Price, subs.Currency, subs.BillingInterval, subs.IntervalCount, subs.UserID, startDate, endDate, subs.ServiceID, subs.Category, tags, trialEnd); err != nil {
+			r.log.Error("Error copying subscription", zap.Error(err))
+			return 0, fmt.Errorf("failed to copy subscription: %w", mapError(err))
+		}
+		ids = append(ids, subs.ID.String())
+		count++
+	}
+
+	// The data is sent to the server by the final Exec without arguments.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		r.log.Error("Error finishing copy", zap.Error(err))
+		return 0, fmt.Errorf("failed to copy subscriptions: %w", mapError(err))
+	}
+	if err := stmt.Close(); err != nil {
+		r.log.Error("Error closing copy", zap.Error(err))
+		return 0, fmt.Errorf("failed to copy subscriptions: %w", mapError(err))
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price, currency)
+		SELECT id, date_trunc('month', start_date)::date, price, currency FROM subscriptions WHERE id = ANY($1::uuid[])
+	`, ids); err != nil {
+		r.log.Error("Error recording copied prices", zap.Error(err))
+		return 0, fmt.Errorf("failed to record prices: %w", mapError(err))
+	}
+	// The foreign key of the users is checked on commit, so they can be registered after the copy.
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO users (id)
+		SELECT DISTINCT user_id FROM subscriptions WHERE id = ANY($1::uuid[])
+		ON CONFLICT (id) DO NOTHING
+	`, ids); err != nil {
+		r.log.Error("Error registering copied users", zap.Error(err))
+		return 0, fmt.Errorf("failed to register users: %w", mapError(err))
+	}
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Error committing copy", zap.Error(err))
+		return 0, fmt.Errorf("failed to commit copy: %w", mapError(err))
+	}
+
+	r.log.Debug("Subscriptions copied", zap.Int64("count", count))
+	return count, nil
+}
+
+// copyService links a copied subscription to its catalog service, resolving every service name
+// with the database only once. resolved holds the ID, name and catalog category of each service
+// already resolved, by slug; the category a subscription sets itself is not taken for the service.
+func (r *Repository) copyService(ctx context.Context, subs *models.Subscription, resolved map[string]models.Service) error {
+	if subs.ServiceID != uuid.Nil {
+		return r.resolveService(ctx, r.db, subs)
+	}
+	slug := models.Slug(subs.ServiceName)
+	known, ok := resolved[slug]
+	if !ok {
+		// The service is resolved for a subscription without a category, which gets that of the catalog.
+		probe := models.Subscription{ServiceName: subs.ServiceName, Currency: subs.Currency}
+		if err := r.resolveService(ctx, r.db, &probe); err != nil {
+			return err
+		}
+		known = models.Service{ID: probe.ServiceID, Name: probe.ServiceName, Slug: slug, Category: probe.Category}
+		resolved[slug] = known
+	}
+	subs.ServiceID, subs.ServiceName = known.ID, known.Name
+	if subs.Category == "" {
+		subs.Category = known.Category
+	}
+	return nil
+}
+
+// copyDates parses the YYYY-MM-DD start, end and trial end dates of a subscription.
+// COPY does not cast its values, so the conversion is done here.
+func copyDates(subs *models.Subscription) (time.Time, *time.Time, *time.Time, error) {
+	startDate, err := time.Parse(dateLayout, subs.StartDate)
+	if err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("invalid start date %q: %w", subs.StartDate, err)
+	}
+	endDate, err := copyOptionalDate("end", subs.EndDate)
+	if err != nil {
+		return time.Time{}, nil, nil, err
+	}
+	trialEnd, err := copyOptionalDate("trial end", subs.TrialEndDate)
+	if err != nil {
+		return time.Time{}, nil, nil, err
+	}
+	return startDate, endDate, trialEnd, nil
+}
+
+// copyOptionalDate parses an optional YYYY-MM-DD date, nil if it is not set.
+func copyOptionalDate(name string, date *string) (*time.Time, error) {
+	if date == nil {
+		return nil, nil
+	}
+	parsed, err := time.Parse(dateLayout, *date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s date %q: %w", name, *date, err)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var copySubsQuery = pq.CopyIn("subscriptions", "id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "service_id", "category", "tags", "trial_end_date")
+
+// subsSource returns the subscriptions one by one and then err.
+func subsSource(subs []*models.Subscription, err error) func() (*models.Subscription, error) {
+	return func() (*models.Subscription, error) {
+		if len(subs) == 0 {
+			return nil, err
+		}
+		sub := subs[0]
+		subs = subs[1:]
+		return sub, nil
+	}
+}
+
+func TestCopySubs(t *testing.T) {
+	endDate := "2025-12-31"
+	trialEnd := "2025-02-14"
+	serviceA := uuid.New()
+	subs := []*models.Subscription{
+		{ID: uuid.New(), ServiceName: "Service A", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-15"},
+		{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Service B", Price: 200, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-02-01", EndDate: &endDate,
+			Category: "music", Tags: []string{"family", "work"}, TrialEndDate: &trialEnd},
+	}
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+
+	// Test case 1: Subscriptions are copied in a transaction with dates converted, services resolved and their prices recorded;
+	// subscriptions without tags get an empty array
+	sqlMock.ExpectBegin()
+	prepare := sqlMock.ExpectPrepare(copySubsQuery)
+	sqlMock.ExpectQuery(upsertServiceQuery).WithArgs(sqlmock.AnyArg(), "Service A", "service-a", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category"}).AddRow(serviceA, "Service A", "video"))
+	prepare.ExpectExec().WithArgs(subs[0].ID, "Service A", 100, "RUB", "month", 1, subs[0].UserID, date(time.January, 15), nil, serviceA, "video", pq.StringArray{}, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectServiceName(subs[1])
+	prepare.ExpectExec().WithArgs(subs[1].ID, "Service B", 200, "RUB", "month", 1, subs[1].UserID, date(time.February, 1), date(time.December, 31), subs[1].ServiceID, "music", pq.StringArray{"family", "work"}, date(time.February, 14)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectExec("INSERT INTO subscription_prices (subscription_id, effective_from, price, currency) SELECT id, date_trunc('month', start_date)::date, price, currency FROM subscriptions WHERE id = ANY($1::uuid[])").
+		WithArgs(pq.StringArray{subs[0].ID.String(), subs[1].ID.String()}).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectExec("INSERT INTO users (id) SELECT DISTINCT user_id FROM subscriptions WHERE id = ANY($1::uuid[]) ON CONFLICT (id) DO NOTHING").
+		WithArgs(pq.StringArray{subs[0].ID.String(), subs[1].ID.String()}).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	count, err := repo.CopySubs(context.Background(), subsSource(subs, io.EOF))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 2: Source error rolls the copy back
+	sourceErr := errors.New("read error")
+	sqlMock.ExpectBegin()
+	prepare = sqlMock.ExpectPrepare(copySubsQuery)
+	expectServiceName(subs[0])
+	prepare.ExpectExec().WithArgs(subs[0].ID, "Service A", 100, "RUB", "month", 1, subs[0].UserID, date(time.January, 15), nil, serviceA, "video", pq.StringArray{}, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.CopySubs(context.Background(), subsSource(subs[:1], sourceErr))
+	assert.ErrorIs(t, err, sourceErr)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 3: The service is resolved once; a later row without a category gets that of the catalog,
+	// not the one set by an earlier row for the same service
+	sameService := []*models.Subscription{
+		{ID: uuid.New(), ServiceName: "Service A", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-15", Category: "music"},
+		{ID: uuid.New(), ServiceName: "service a", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-15"},
+	}
+	sqlMock.ExpectBegin()
+	prepare = sqlMock.ExpectPrepare(copySubsQuery)
+	sqlMock.ExpectQuery(upsertServiceQuery).WithArgs(sqlmock.AnyArg(), "Service A", "service-a", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category"}).AddRow(serviceA, "Service A", "video"))
+	prepare.ExpectExec().WithArgs(sameService[0].ID, "Service A", 100, "RUB", "month", 1, sameService[0].UserID, date(time.January, 15), nil, serviceA, "music", pq.StringArray{}, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(sameService[1].ID, "Service A", 100, "RUB", "month", 1, sameService[1].UserID, date(time.January, 15), nil, serviceA, "video", pq.StringArray{}, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectExec("INSERT INTO subscription_prices (subscription_id, effective_from, price, currency) SELECT id, date_trunc('month', start_date)::date, price, currency FROM subscriptions WHERE id = ANY($1::uuid[])").
+		WithArgs(pq.StringArray{sameService[0].ID.String(), sameService[1].ID.String()}).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectExec("INSERT INTO users (id) SELECT DISTINCT user_id FROM subscriptions WHERE id = ANY($1::uuid[]) ON CONFLICT (id) DO NOTHING").
+		WithArgs(pq.StringArray{sameService[0].ID.String(), sameService[1].ID.String()}).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	count, err = repo.CopySubs(context.Background(), subsSource(sameService, io.EOF))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// ChangeStatus applies a lifecycle transition to the subscription of the given version:
+// it sets the new status and end month, updates the pauses of the subscription and drops its unsent reminders
+// in one transaction.
+// Returns the new version of the subscription, service.ErrNotFound if it does not exist,
+// or service.ErrPreconditionFailed if its version differs.
+func (r *Repository) ChangeStatus(ctx context.Context, id uuid.UUID, version int, change *models.StatusChange) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Changing subscription status", zap.String("id", id.String()), zap.String("status", change.Status))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Error("Error starting status transaction", zap.Error(err))
+		return 0, fmt.Errorf("failed to start transaction: %w", mapError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	// The version check works as in updateSubs: a concurrent change of the subscription,
+	// including another transition, makes this one fail instead of applying to a stale state.
+	query := `
+		WITH updated AS (
+			UPDATE subscriptions
+			SET
+				status = $1,
+				end_date = $2::date,
+				version = version + 1
+			WHERE id = $3 AND version = $4
+			RETURNING version
+		)
+		SELECT (SELECT version FROM updated), EXISTS(SELECT 1 FROM subscriptions WHERE id = $3)
+	`
+	var newVersion sql.NullInt64
+	var exists bool
+	err = tx.QueryRowContext(ctx, query, change.Status, change.EndDate, id, version).Scan(&newVersion, &exists)
+	if err != nil {
+		r.log.Error("Error changing subscription status", zap.Error(err))
+		return 0, fmt.Errorf("failed to change subscription status: %w", mapError(err))
+	}
+	if !newVersion.Valid {
+		if !exists {
+			r.log.Debug("Subscription not found", zap.String("id", id.String()))
+			return 0, fmt.Errorf("subscription %w", service.ErrNotFound)
+		}
+		r.log.Debug("Subscription version mismatch", zap.String("id", id.String()), zap.Int("version", version))
+		return 0, fmt.Errorf("subscription version %d: %w", version, service.ErrPreconditionFailed)
+	}
+	if err := r.dropReminders(ctx, tx, id); err != nil {
+		return 0, err
+	}
+
+	if change.ClosePause != nil {
+		// A pause closed before the month it started in never took effect and is removed.
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM subscription_pauses
+			WHERE subscription_id = $1 AND end_date IS NULL AND start_date > to_date($2, 'MM-YYYY')
+		`, id, *change.ClosePause); err != nil {
+			r.log.Error("Error closing pause", zap.Error(err))
+			return 0, fmt.Errorf("failed to close pause: %w", mapError(err))
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE subscription_pauses SET end_date = to_date($2, 'MM-YYYY')
+			WHERE subscription_id = $1 AND end_date IS NULL
+		`, id, *change.ClosePause); err != nil {
+			r.log.Error("Error closing pause", zap.Error(err))
+			return 0, fmt.Errorf("failed to close pause: %w", mapError(err))
+		}
+	}
+	if change.OpenPause != nil {
+		if err := r.insertPause(ctx, tx, id, models.Pause{StartDate: *change.OpenPause}); err != nil {
+			return 0, err
+		}
+	}
+	if change.AddPause != nil {
+		if err := r.insertPause(ctx, tx, id, *change.AddPause); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Error committing status change", zap.Error(err))
+		return 0, fmt.Errorf("failed to commit status change: %w", mapError(err))
+	}
+	r.log.Debug("Subscription status changed", zap.String("id", id.String()), zap.Int64("version", newVersion.Int64))
+	return int(newVersion.Int64), nil
+}
+
+// insertPause stores a pause of the subscription inside the transaction.
+func (r *Repository) insertPause(ctx context.Context, tx *sql.Tx, id uuid.UUID, pause models.Pause) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO subscription_pauses (subscription_id, start_date, end_date)
+		VALUES ($1, to_date($2, 'MM-YYYY'), to_date($3, 'MM-YYYY'))
+	`, id, pause.StartDate, pause.EndDate)
+	if err != nil {
+		r.log.Error("Error inserting pause", zap.Error(err))
+		return fmt.Errorf("failed to insert pause: %w", mapError(err))
+	}
+	return nil
+}
+
+// ListPauses returns the pauses of the given subscriptions in chronological order, keyed by subscription ID.
+// Subscriptions that have never been paused are absent from the map.
+func (r *Repository) ListPauses(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Pause, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing pauses", zap.Int("subscriptions", len(ids)))
+
+	subIDs := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		subIDs = append(subIDs, id.String())
+	}
+
+	query := `
+		SELECT subscription_id, to_char(start_date, 'MM-YYYY'), to_char(end_date, 'MM-YYYY')
+		FROM subscription_pauses
+		WHERE subscription_id = ANY($1::uuid[])
+		ORDER BY subscription_id, start_date
+	`
+	rows, err := r.db.QueryContext(ctx, query, subIDs)
+	if err != nil {
+		r.log.Error("Error listing pauses", zap.Error(err))
+		return nil, fmt.Errorf("failed to query pauses: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	pauses := make(map[uuid.UUID][]models.Pause)
+	for rows.Next() {
+		var id uuid.UUID
+		var pause models.Pause
+		if err := rows.Scan(&id, &pause.StartDate, &pause.EndDate); err != nil {
+			r.log.Error("failed to scan pause", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan pause: %w", err)
+		}
+		pauses[id] = append(pauses[id], pause)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over pause rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over pause rows: %w", err)
+	}
+	return pauses, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	changeStatusQuery = "WITH updated AS ( UPDATE subscriptions SET status = $1, end_date = $2::date, version = version + 1 WHERE id = $3 AND version = $4 RETURNING version ) SELECT (SELECT version FROM updated), EXISTS(SELECT 1 FROM subscriptions WHERE id = $3)"
+	insertPauseQuery  = "INSERT INTO subscription_pauses (subscription_id, start_date, end_date) VALUES ($1, to_date($2, 'MM-YYYY'), to_date($3, 'MM-YYYY'))"
+)
+
+func TestChangeStatus(t *testing.T) {
+	id := uuid.New()
+	month, endDate := "06-2025", "2025-06-30"
+
+	// Test case 1: Pausing opens a pause and drops the unsent reminders
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(changeStatusQuery).WithArgs(models.StatusPaused, nil, id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "exists"}).AddRow(2, true))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(insertPauseQuery).WithArgs(id, month, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	version, err := repo.ChangeStatus(context.Background(), id, 1, &models.StatusChange{Status: models.StatusPaused, OpenPause: &month})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 2: Canceling a paused subscription closes its pause
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(changeStatusQuery).WithArgs(models.StatusCanceled, endDate, id, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "exists"}).AddRow(3, true))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("DELETE FROM subscription_pauses WHERE subscription_id = $1 AND end_date IS NULL AND start_date > to_date($2, 'MM-YYYY')").
+		WithArgs(id, month).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("UPDATE subscription_pauses SET end_date = to_date($2, 'MM-YYYY') WHERE subscription_id = $1 AND end_date IS NULL").
+		WithArgs(id, month).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	version, err = repo.ChangeStatus(context.Background(), id, 2, &models.StatusChange{Status: models.StatusCanceled, EndDate: &endDate, ClosePause: &month})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 3: Version mismatch
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(changeStatusQuery).WithArgs(models.StatusActive, nil, id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "exists"}).AddRow(nil, true))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.ChangeStatus(context.Background(), id, 1, &models.StatusChange{Status: models.StatusActive})
+	assert.ErrorIs(t, err, service.ErrPreconditionFailed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 4: Subscription not found
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(changeStatusQuery).WithArgs(models.StatusActive, nil, id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "exists"}).AddRow(nil, false))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.ChangeStatus(context.Background(), id, 1, &models.StatusChange{Status: models.StatusActive})
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestListPauses(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	endDate := "03-2025"
+
+	sqlMock.ExpectQuery("SELECT subscription_id, to_char(start_date, 'MM-YYYY'), to_char(end_date, 'MM-YYYY') FROM subscription_pauses WHERE subscription_id = ANY($1::uuid[]) ORDER BY subscription_id, start_date").
+		WithArgs(pq.StringArray{first.String(), second.String()}).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "start_date", "end_date"}).
+			AddRow(first, "02-2025", endDate).
+			AddRow(first, "06-2025", nil))
+
+	pauses, err := repo.ListPauses(context.Background(), []uuid.UUID{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID][]models.Pause{
+		first: {{StartDate: "02-2025", EndDate: &endDate}, {StartDate: "06-2025"}},
+	}, pauses)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
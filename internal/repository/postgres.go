@@ -2,54 +2,124 @@ package repository
 
 import (
 	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
 // Repository provides methods for interacting with the PostgreSQL database.
 // It encapsulates database operations related to subscriptions.
 type Repository struct {
-	db  *sql.DB
-	log *zap.Logger
+	db           *sql.DB
+	log          *zap.Logger
+	queryTimeout time.Duration
 }
 
 // NewRepository creates and returns a new instance of Repository.
 // It takes a Storage (which contains the *sql.DB connection) and a logger as dependencies.
 func (s *Storage) NewRepository() *Repository {
-	return &Repository{db: s.db, log: s.log.Named("Repository")}
+	return &Repository{db: s.db, log: s.log.Named("Repository"), queryTimeout: s.queryTimeout}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx, so statements can run inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// withTimeout derives the context of a single query from the request context.
+// The query is canceled when the request is canceled or when the configured query timeout expires.
+func (r *Repository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
 }
 
 // CreateSubs inserts a new subscription record into the database.
-// It takes a pointer to a models.Subscription struct containing the subscription data.
+// It takes a pointer to a models.Subscription struct containing the subscription data
+// and sets its Version and Status to the initial values assigned by the database.
+// The subscription is linked to its catalog service as described in resolveService,
+// in the same transaction.
 // Returns an error if the insertion fails.
-func (r *Repository) CreateSubs(subs *models.Subscription) error {
+func (r *Repository) CreateSubs(ctx context.Context, subs *models.Subscription) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Error("Error starting create transaction", zap.Error(err))
+		return fmt.Errorf("failed to start transaction: %w", mapError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	if err := r.createSubs(ctx, tx, subs); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Error committing create", zap.Error(err))
+		return fmt.Errorf("failed to commit create: %w", mapError(err))
+	}
+	return nil
+}
+
+// createSubs inserts the subscription using q, which is either the database or a transaction.
+func (r *Repository) createSubs(ctx context.Context, q querier, subs *models.Subscription) error {
 	r.log.Debug("Creating Subscription", zap.String("userId", subs.UserID.String()))
+	if err := r.resolveService(ctx, q, subs); err != nil {
+		return err
+	}
 	// SQL query to insert a new subscription.
 	// Parameters are used to prevent SQL injection.
+	// Dates arrive in YYYY-MM-DD form.
+	// The price is recorded as the first entry of the price history in the same statement,
+	// taking effect in the start month, and a user the subscription is the first one of is registered.
 	query := `
-		INSERT INTO subscriptions 
-			(id, service_name, price, user_id, start_date, end_date)
-		VALUES 
-			($1, $2, $3, $4, $5, $6)
+		WITH registered AS (
+			INSERT INTO users (id) VALUES ($7) ON CONFLICT (id) DO NOTHING
+		), created AS (
+			INSERT INTO subscriptions 
+				(id, service_name, price, currency, billing_interval, interval_count, user_id, start_date, end_date, service_id, category, tags, trial_end_date)
+			VALUES 
+				($1, $2, $3, $4, $5, $6, $7, $8::date, $9::date, $10, $11, COALESCE($12::text[], '{}'), $13::date)
+			RETURNING id, price, currency, start_date, version, status
+		), priced AS (
+			INSERT INTO subscription_prices (subscription_id, effective_from, price, currency)
+			SELECT id, date_trunc('month', start_date)::date, price, currency FROM created
+		)
+		SELECT version, status FROM created
 	`
 
 	// Execute the SQL insert statement.
-	_, err := r.db.Exec(
+	err := q.QueryRowContext(
+		ctx,
 		query,
 		subs.ID,
 		subs.ServiceName,
 		subs.Price,
+		subs.Currency,
+		subs.BillingInterval,
+		subs.IntervalCount,
 		subs.UserID,
 		subs.StartDate,
 		subs.EndDate,
-	)
+		subs.ServiceID,
+		subs.Category,
+		pq.StringArray(subs.Tags),
+		subs.TrialEndDate,
+	).Scan(&subs.Version, &subs.Status)
 
 	if err != nil {
 		r.log.Error("Error creating subscription", zap.Error(err))
-		return fmt.Errorf("failed to create subscription: %w", err)
+		return fmt.Errorf("failed to create subscription: %w", mapError(err))
 	}
 	r.log.Debug("Subscription created", zap.String("userId", subs.UserID.String()))
 	return nil
@@ -57,50 +127,153 @@ func (r *Repository) CreateSubs(subs *models.Subscription) error {
 }
 
 // UpdateSubs updates an existing subscription record in the database.
-// It takes the ID of the subscription to update and a models.Subscription struct
-// containing the new data. Returns an error if the update fails.
-func (r *Repository) UpdateSubs(id uuid.UUID, newSubs *models.Subscription) error {
-	r.log.Debug("Updating subscription", zap.String("id", id.String()))
+// It takes the ID of the subscription to update, the version the caller expects it to have and
+// a models.Subscription struct containing the new data, whose Version is set to the new version
+// and Status to the unchanged status of the subscription. The subscription moves to newSubs.UserID;
+// the user it belonged to before the update is returned.
+// The update only applies if the stored version matches, so concurrent changes are not overwritten.
+// The subscription is linked to its catalog service as described in resolveService, a changed price
+// or currency is appended to the price history of the subscription and its unsent reminders are dropped,
+// in the same transaction.
+// Returns service.ErrNotFound if the subscription does not exist, service.ErrPreconditionFailed
+// if its version differs, or another error if the update fails.
+func (r *Repository) UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) (uuid.UUID, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Error("Error starting update transaction", zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to start transaction: %w", mapError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	owner, err := r.updateSubs(ctx, tx, id, version, newSubs)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.log.Error("Error committing update", zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to commit update: %w", mapError(err))
+	}
+	return owner, nil
+}
+
+// updateSubs updates the subscription of the given version inside the transaction tx
+// and records a changed price in its price history. Returns the previous owner of the subscription.
+func (r *Repository) updateSubs(ctx context.Context, tx *sql.Tx, id uuid.UUID, version int, newSubs *models.Subscription) (uuid.UUID, error) {
+	r.log.Debug("Updating subscription", zap.String("id", id.String()), zap.Int("version", version))
+	if err := r.resolveService(ctx, tx, newSubs); err != nil {
+		return uuid.Nil, err
+	}
 
 	// SQL query to update an existing subscription.
-	// The WHERE clause ensures that only the subscription with the specified ID is updated.
+	// The WHERE clause ensures that only the subscription with the specified ID and version is updated.
+	// The end date of a paused or canceled subscription belongs to its lifecycle and is changed only
+	// by cancel and reactivate, so it must stay as it is unless the subscription is active.
+	// The checks run in a single statement: the outer SELECT sees the row as it was before the update,
+	// so it tells a missing row from a version mismatch or a refused end date, and returns the previous
+	// price, currency and owner.
+	// Dates arrive in YYYY-MM-DD form.
 	query := `
-        UPDATE subscriptions
-        SET 
-            service_name = $1,
-            price = $2,
-            start_date = $3,
-            end_date = $4
-        WHERE id = $5
-    `
+		WITH updated AS (
+			UPDATE subscriptions
+			SET 
+				service_name = $1,
+				service_id = $10,
+				price = $2,
+				currency = $3,
+				billing_interval = $4,
+				interval_count = $5,
+				start_date = $6::date,
+				end_date = $7::date,
+				category = $11,
+				tags = COALESCE($12::text[], '{}'),
+				trial_end_date = $13::date,
+				user_id = $14,
+				version = version + 1
+			WHERE id = $8 AND version = $9
+				AND (status = 'active' OR end_date IS NOT DISTINCT FROM $7::date)
+			RETURNING version, status
+		)
+		SELECT
+			(SELECT version FROM updated), (SELECT status FROM updated),
+			(SELECT price FROM subscriptions WHERE id = $8), (SELECT currency FROM subscriptions WHERE id = $8),
+			(SELECT version FROM subscriptions WHERE id = $8), (SELECT status FROM subscriptions WHERE id = $8),
+			(SELECT user_id FROM subscriptions WHERE id = $8)
+	`
 
 	// Execute the SQL update statement.
-	_, err := r.db.Exec(
+	var newVersion sql.NullInt64
+	var status sql.NullString
+	var oldPrice sql.NullInt64
+	var oldCurrency sql.NullString
+	var oldVersion sql.NullInt64
+	var oldStatus sql.NullString
+	var owner uuid.NullUUID
+	err := tx.QueryRowContext(
+		ctx,
 		query,
 		newSubs.ServiceName,
 		newSubs.Price,
+		newSubs.Currency,
+		newSubs.BillingInterval,
+		newSubs.IntervalCount,
 		newSubs.StartDate,
 		newSubs.EndDate,
 		id,
-	)
+		version,
+		newSubs.ServiceID,
+		newSubs.Category,
+		pq.StringArray(newSubs.Tags),
+		newSubs.TrialEndDate,
+		newSubs.UserID,
+	).Scan(&newVersion, &status, &oldPrice, &oldCurrency, &oldVersion, &oldStatus, &owner)
 
 	if err != nil {
 		r.log.Error("Error updating subscription", zap.Error(err))
-		return fmt.Errorf("failed to update subscription: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to update subscription: %w", mapError(err))
+	}
+	if !newVersion.Valid {
+		if !oldVersion.Valid {
+			r.log.Debug("Subscription not found", zap.String("id", id.String()))
+			return uuid.Nil, fmt.Errorf("subscription %w", service.ErrNotFound)
+		}
+		if int(oldVersion.Int64) != version {
+			r.log.Debug("Subscription version mismatch", zap.String("id", id.String()), zap.Int("version", version))
+			return uuid.Nil, fmt.Errorf("subscription version %d: %w", version, service.ErrPreconditionFailed)
+		}
+		r.log.Debug("End date change refused", zap.String("id", id.String()), zap.String("status", oldStatus.String))
+		return uuid.Nil, fmt.Errorf("%w: cannot change the end date of a subscription that is %s", service.ErrInvalidTransition, oldStatus.String)
 	}
+	newSubs.Version = int(newVersion.Int64)
+	newSubs.Status = status.String
 
-	r.log.Debug("Subscription updated", zap.String("id", id.String()))
-	return nil
+	if err := r.dropReminders(ctx, tx, id); err != nil {
+		return uuid.Nil, err
+	}
+	if newSubs.Price != oldPrice.Int64 || newSubs.Currency != oldCurrency.String {
+		if err := r.recordPrice(ctx, tx, id, newSubs.StartDate, newSubs.Price, newSubs.Currency); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	r.log.Debug("Subscription updated", zap.String("id", id.String()), zap.Int("version", newSubs.Version))
+	return owner.UUID, nil
 }
 
 // SubscriptionExists checks if a subscription with the given ID exists in the database.
 // Returns true if the subscription exists, false otherwise, and an error if the query fails.
-func (r *Repository) SubscriptionExists(id uuid.UUID) (bool, error) {
+func (r *Repository) SubscriptionExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	var exists bool
 	// SQL query to check for the existence of a subscription by ID.
 	query := `SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)`
 	// Execute the query and scan the result into the 'exists' variable.
-	err := r.db.QueryRow(query, id).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("error checking subscription existence: %w", err)
 	}
@@ -109,120 +282,247 @@ func (r *Repository) SubscriptionExists(id uuid.UUID) (bool, error) {
 
 // DeleteSubs deletes a subscription record from the database by its ID.
 // Returns an error if the deletion fails.
-func (r *Repository) DeleteSubs(id uuid.UUID) error {
+func (r *Repository) DeleteSubs(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return r.deleteSubs(ctx, r.db, id)
+}
+
+// deleteSubs deletes the subscription using q, which is either the database or a transaction.
+func (r *Repository) deleteSubs(ctx context.Context, q querier, id uuid.UUID) error {
 	r.log.Debug("Deleting subscription", zap.String("userId", id.String()))
 	// SQL query to delete a subscription.
 	query := `DELETE FROM subscriptions WHERE id = $1`
 	// Execute the SQL delete statement.
-	_, err := r.db.Exec(query, id)
+	result, err := q.ExecContext(ctx, query, id)
 	if err != nil {
 		r.log.Error("Error deleting subscription", zap.Error(err))
-		return fmt.Errorf("failed to delete subscription: %w", err)
+		return fmt.Errorf("failed to delete subscription: %w", mapError(err))
+	}
+	if err := r.checkAffected(result, id); err != nil {
+		return err
 	}
 	r.log.Debug("Subscription deleted", zap.String("userId", id.String()))
 
 	return nil
 }
 
-// ListSubs retrieves a list of subscriptions from the database based on provided filters.
-// It takes a models.SubscriptionFilter struct to apply optional filtering by UserID and ServiceName.
+// sortColumns maps the supported sort fields to their columns and to the SQL expression
+// converting a cursor value back into the column type.
+var sortColumns = map[string]struct {
+	column string
+	cursor string
+}{
+	models.SortByPrice:       {column: "price", cursor: "$3::bigint"},
+	models.SortByStartDate:   {column: "start_date", cursor: "$3::date"},
+	models.SortByServiceName: {column: "service_name", cursor: "$3::text"},
+}
+
+// likeEscaper escapes the LIKE wildcards of user input, so it is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListSubs retrieves a page of subscriptions from the database based on provided filters.
+// It takes a models.SubscriptionFilter struct to apply optional filtering by users, service name,
+// price and date ranges,
+// and models.ListParams describing the sort order, the page size and the cursor to continue after.
+// Rows are ordered by the sort column with the ID as a tie-breaker, so keyset pagination is stable.
 // Returns a slice of models.Subscription and an error if the query or scanning fails.
-func (r *Repository) ListSubs(filter models.SubscriptionFilter) ([]models.Subscription, error) {
-	r.log.Debug("Listing subscriptions")
+func (r *Repository) ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) ([]models.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing subscriptions", zap.String("sort", params.SortBy), zap.Int("limit", params.Limit))
+
+	var subscriptions []models.Subscription
+	err := r.querySubs(ctx, filter, params, func(sub *models.Subscription) error {
+		subscriptions = append(subscriptions, *sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log.Debug("Subscription listed", zap.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
 
-	// SQL query to select subscriptions. The WHERE clause dynamically applies filters.
+// StreamSubs passes the subscriptions matching the filter to fn one at a time, as they are read
+// from the database, so the result set is never held in memory. The filters, sort order and cursor
+// are those of ListSubs; a zero params.Limit returns all matching subscriptions.
+// The query timeout does not apply: the query lasts as long as fn keeps consuming rows,
+// and it is canceled with ctx. Iteration stops at the first error returned by fn.
+func (r *Repository) StreamSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error {
+	r.log.Debug("Streaming subscriptions", zap.String("sort", params.SortBy), zap.Int("limit", params.Limit))
+	return r.querySubs(ctx, filter, params, fn)
+}
+
+// querySubs runs the subscription listing query and passes every row to fn.
+// A zero params.Limit means no limit.
+func (r *Repository) querySubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error {
+	sortBy, ok := sortColumns[params.SortBy]
+	if !ok {
+		return fmt.Errorf("%w: unsupported sort field %q", service.ErrValidation, params.SortBy)
+	}
+	direction, comparison := "ASC", ">"
+	if params.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	// SQL query to select subscriptions. The WHERE clause dynamically applies filters;
+	// every filter is skipped when its parameter is NULL.
 	// $1::uuid IS NULL OR user_id = $1: Filters by user_id if $1 (filter.UserID) is not NULL.
-	// $2::text IS NULL OR service_name = $2: Filters by service_name if $2 (filter.ServiceName) is not NULL.
-	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date
+	// $2::text IS NULL OR ...: Filters by the service whose slug is $2, the slug of filter.ServiceName, if it is set.
+	// $3 and $4 hold the sort value and ID of the cursor; rows after it in the sort order are returned.
+	// $6: any of several user IDs; $7: case-insensitive service name prefix with LIKE wildcards escaped.
+	// $8 and $9: inclusive price range; $10: subscriptions active in the given month.
+	// $11..$14: inclusive start and end month ranges, an upper bound covering the whole month; $15: only subscriptions without an end date;
+	// $16: currency of the subscription; $17: its category; $18: tags it must all have.
+	// The sort column and direction come from the whitelist above and are never taken from user input.
+	// Dates are stored as DATE and returned in YYYY-MM-DD form.
+	query := fmt.Sprintf(`
+		SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD')
 		FROM subscriptions
 		WHERE 
 			($1::uuid IS NULL OR user_id = $1) AND
-			($2::text IS NULL OR service_name = $2)
-	`
+			($2::text IS NULL OR service_id = (SELECT id FROM services WHERE slug = $2)) AND
+			($3::text IS NULL OR (%[1]s, id) %[3]s (%[2]s, $4::uuid)) AND
+			($6::uuid[] IS NULL OR user_id = ANY($6)) AND
+			($7::text IS NULL OR lower(service_name) LIKE lower($7) || '%%') AND
+			($8::bigint IS NULL OR price >= $8) AND
+			($9::bigint IS NULL OR price <= $9) AND
+			($10::text IS NULL OR (start_date < to_date($10, 'MM-YYYY') + interval '1 month' AND (end_date IS NULL OR end_date >= to_date($10, 'MM-YYYY')))) AND
+			($11::text IS NULL OR start_date >= to_date($11, 'MM-YYYY')) AND
+			($12::text IS NULL OR start_date < to_date($12, 'MM-YYYY') + interval '1 month') AND
+			($13::text IS NULL OR end_date >= to_date($13, 'MM-YYYY')) AND
+			($14::text IS NULL OR end_date < to_date($14, 'MM-YYYY') + interval '1 month') AND
+			(NOT $15::boolean OR end_date IS NULL) AND
+			($16::text IS NULL OR currency = $16) AND
+			($17::text IS NULL OR category = $17) AND
+			($18::text[] IS NULL OR tags @> $18)
+		ORDER BY %[1]s %[4]s, id %[4]s
+		LIMIT $5
+	`, sortBy.column, sortBy.cursor, comparison, direction)
 
-	// Execute the query with the filter parameters.
-	rows, err := r.db.Query(query, filter.UserID, filter.ServiceName)
-	if err != nil {
-		r.log.Error("Error listing subscriptions", zap.Error(err))
-		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	var cursorValue *string
+	var cursorID *uuid.UUID
+	if params.Cursor != nil {
+		cursorValue = &params.Cursor.Value
+		cursorID = &params.Cursor.ID
 	}
-	defer rows.Close() // Ensure rows are closed after the function returns.
 
-	var subscriptions []models.Subscription
-	// Iterate over the result set and scan each row into a Subscription struct.
-	for rows.Next() {
-		var subs models.Subscription
+	var userIDs pq.StringArray
+	for _, id := range filter.UserIDs {
+		userIDs = append(userIDs, id.String())
+	}
 
-		// Scan the columns into the struct fields.
-		if err := rows.Scan(
-			&subs.ID,
-			&subs.ServiceName,
-			&subs.Price,
-			&subs.UserID,
-			&subs.StartDate,
-			&subs.EndDate,
-		); err != nil {
-			r.log.Error("failed to scan subscription", zap.Error(err))
-			return nil, fmt.Errorf("failed to scan subscription: %w", err)
-		}
+	var serviceSlug *string
+	if filter.ServiceName != nil {
+		slug := models.Slug(*filter.ServiceName)
+		serviceSlug = &slug
+	}
 
-		subscriptions = append(subscriptions, subs)
+	var prefix *string
+	if filter.ServiceNamePrefix != nil {
+		escaped := likeEscaper.Replace(*filter.ServiceNamePrefix)
+		prefix = &escaped
 	}
-	// Check for any errors that occurred during row iteration.
-	if err = rows.Err(); err != nil {
-		r.log.Error("error iterating over subscription rows", zap.Error(err))
-		return nil, fmt.Errorf("error iterating over subscription rows: %w", err)
+
+	// LIMIT NULL returns all rows.
+	var limit *int
+	if params.Limit > 0 {
+		limit = &params.Limit
 	}
-	r.log.Debug("Subscription listed", zap.Int("count", len(subscriptions)))
-	return subscriptions, nil
+
+	// Execute the query with the filter and pagination parameters.
+	rows, err := r.db.QueryContext(
+		ctx,
+		query,
+		filter.UserID,
+		serviceSlug,
+		cursorValue,
+		cursorID,
+		limit,
+		userIDs,
+		prefix,
+		filter.MinPrice,
+		filter.MaxPrice,
+		filter.ActiveOn,
+		filter.StartFrom,
+		filter.StartTo,
+		filter.EndFrom,
+		filter.EndTo,
+		filter.OpenEnded,
+		filter.Currency,
+		filter.Category,
+		pq.StringArray(filter.Tags),
+	)
+	if err != nil {
+		r.log.Error("Error listing subscriptions", zap.Error(err))
+		return fmt.Errorf("failed to query subscriptions: %w", mapError(err))
+	}
+	defer rows.Close() // Ensure rows are closed after the function returns.
+
+	return r.eachSub(rows, fn)
 }
 
-// GetSummary calculates the total price of subscriptions based on date range and optional filters.
+// ListSubsInPeriod retrieves the subscriptions that are active at some point of the requested period.
 // It takes a models.GetSummary struct with 'From', 'To', 'UserID', and 'ServiceName' fields.
-// Returns the total sum of prices and an error if the query fails.
-func (r *Repository) GetSummary(sum *models.GetSummary) (int, error) {
-	r.log.Debug("Getting summary")
-	// SQL query to calculate the sum of prices. COALESCE handles cases where SUM returns NULL (no matching rows).
-	// The WHERE clause dynamically applies filters for date range, user ID, and service name.
-	// Date comparisons use <= and >= for inclusive ranges.
+// The cost of each subscription within the period is calculated by the service layer.
+// Returns a slice of models.Subscription and an error if the query or scanning fails.
+func (r *Repository) ListSubsInPeriod(ctx context.Context, sum *models.GetSummary) ([]models.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing subscriptions in period")
+	// The WHERE clause dynamically applies filters for date range, user ID, and service, which is matched by its slug.
+	// The MM-YYYY bounds are converted with to_date instead of being compared as strings;
+	// the period includes every day of its last month.
 	// end_date IS NULL: includes subscriptions without an end date.
 	query := `
-        SELECT COALESCE(SUM(price), 0)
+        SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD')
         FROM subscriptions
         WHERE 
-            ($1::text = '' OR start_date <= $1) AND 
-            ($2::text = '' OR end_date >= $2 OR end_date IS NULL) AND
+            ($1::text = '' OR start_date < to_date($1, 'MM-YYYY') + interval '1 month') AND 
+            ($2::text = '' OR end_date >= to_date($2, 'MM-YYYY') OR end_date IS NULL) AND
             ($3::uuid IS NULL OR user_id = $3) AND
-            ($4::text = '' OR service_name = $4)
+            ($4::text = '' OR service_id = (SELECT id FROM services WHERE slug = $4))
     `
 
-	var total int
-	// Execute the query and scan the result (total sum) into the 'total' variable.
-	err := r.db.QueryRow(
+	// $1 is the end of the period and $2 is its beginning: a subscription overlaps the period
+	// when it starts before the period ends and ends after the period starts.
+	rows, err := r.db.QueryContext(
+		ctx,
 		query,
-		sum.To, // Note: The original query had $1 as 'To' and $2 as 'From'. Ensure this is intentional.
+		sum.To,
 		sum.From,
 		sum.UserID,
-		sum.ServiceName,
-	).Scan(&total)
-
+		models.Slug(sum.ServiceName),
+	)
 	if err != nil {
-		r.log.Error("Error getting summary", zap.Error(err))
-		return 0, fmt.Errorf("failed to calculate summary: %w", err)
+		r.log.Error("Error listing subscriptions in period", zap.Error(err))
+		return nil, fmt.Errorf("failed to query subscriptions in period: %w", mapError(err))
 	}
+	defer rows.Close()
 
-	r.log.Debug("Summary retrieved", zap.Int("total", total))
-	return total, nil
+	subscriptions, err := r.scanSubs(rows)
+	if err != nil {
+		return nil, err
+	}
+	r.log.Debug("Subscriptions in period listed", zap.Int("count", len(subscriptions)))
+	return subscriptions, nil
 }
 
 // GetSub retrieves a single subscription record by its ID.
 // Returns a pointer to a models.Subscription struct if found, or an error if not found or a database error occurs.
-func (r *Repository) GetSub(id uuid.UUID) (*models.Subscription, error) {
+func (r *Repository) GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	r.log.Debug("Getting subscription", zap.String("userId", id.String()))
 	// SQL query to select a single subscription by ID.
+	// Dates are stored as DATE and returned in YYYY-MM-DD form.
 	query := `
-        SELECT id, service_name, price, user_id, start_date, end_date
+        SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD')
         FROM subscriptions
         WHERE id = $1 
         LIMIT 1
@@ -230,27 +530,103 @@ func (r *Repository) GetSub(id uuid.UUID) (*models.Subscription, error) {
 
 	var sub models.Subscription
 	// Execute the query and scan the result into the Subscription struct.
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&sub.ID,
+		&sub.ServiceID,
 		&sub.ServiceName,
 		&sub.Price,
+		&sub.Currency,
+		&sub.BillingInterval,
+		&sub.IntervalCount,
 		&sub.UserID,
 		&sub.StartDate,
 		&sub.EndDate,
+		&sub.Version,
+		&sub.Status,
+		&sub.Category,
+		(*pq.StringArray)(&sub.Tags),
+		&sub.TrialEndDate,
 	)
 
 	if err != nil {
 		// Check if no rows were returned (subscription not found).
 		if errors.Is(err, sql.ErrNoRows) {
 			r.log.Debug("Subscription not found", zap.String("userId", id.String()))
-			return nil, fmt.Errorf("subscription not found")
+			return nil, fmt.Errorf("subscription %w", service.ErrNotFound)
 		}
 		// Handle other database errors.
 		r.log.Error("Error getting subscription", zap.Error(err))
-		return nil, fmt.Errorf("database error: %w", err)
+		return nil, fmt.Errorf("database error: %w", mapError(err))
 	}
 	r.log.Debug("Subscription retrieved", zap.String("userId", id.String()))
 	return &sub, nil
 }
 
+// checkAffected reports service.ErrNotFound when a statement targeting a single subscription changed no rows.
+func (r *Repository) checkAffected(result sql.Result, id uuid.UUID) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		r.log.Debug("Subscription not found", zap.String("id", id.String()))
+		return fmt.Errorf("subscription %w", service.ErrNotFound)
+	}
+	return nil
+}
 
+// scanSubs reads all subscriptions from the result set.
+// The rows must contain id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, start_date,
+// end_date, version, status, category, tags and trial_end_date columns in that order.
+func (r *Repository) scanSubs(rows *sql.Rows) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := r.eachSub(rows, func(sub *models.Subscription) error {
+		subscriptions = append(subscriptions, *sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// eachSub scans the subscriptions of the result set one at a time and passes them to fn,
+// stopping at the first error returned by fn. The columns are those of scanSubs.
+func (r *Repository) eachSub(rows *sql.Rows, fn func(sub *models.Subscription) error) error {
+	// Iterate over the result set and scan each row into a Subscription struct.
+	for rows.Next() {
+		var subs models.Subscription
+
+		// Scan the columns into the struct fields.
+		if err := rows.Scan(
+			&subs.ID,
+			&subs.ServiceID,
+			&subs.ServiceName,
+			&subs.Price,
+			&subs.Currency,
+			&subs.BillingInterval,
+			&subs.IntervalCount,
+			&subs.UserID,
+			&subs.StartDate,
+			&subs.EndDate,
+			&subs.Version,
+			&subs.Status,
+			&subs.Category,
+			(*pq.StringArray)(&subs.Tags),
+			&subs.TrialEndDate,
+		); err != nil {
+			r.log.Error("failed to scan subscription", zap.Error(err))
+			return fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		if err := fn(&subs); err != nil {
+			return err
+		}
+	}
+	// Check for any errors that occurred during row iteration.
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over subscription rows", zap.Error(err))
+		return fmt.Errorf("error iterating over subscription rows: %w", err)
+	}
+	return nil
+}
@@ -2,14 +2,19 @@ package repository
 
 import (
 	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -45,58 +50,180 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+const (
+	serviceNameQuery   = "SELECT name, category FROM services WHERE id = $1"
+	upsertServiceQuery = "INSERT INTO services (id, name, slug, currency) VALUES ($1, $2, $3, $4) ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug RETURNING id, name, category"
+)
+
+// expectServiceName expects the catalog lookup of the service the subscription refers to.
+func expectServiceName(sub *models.Subscription) {
+	sqlMock.ExpectQuery(serviceNameQuery).WithArgs(sub.ServiceID).WillReturnRows(sqlmock.NewRows([]string{"name", "category"}).AddRow(sub.ServiceName, sub.Category))
+}
+
+const createSubsQuery = "WITH registered AS ( INSERT INTO users (id) VALUES ($7) ON CONFLICT (id) DO NOTHING ), created AS ( INSERT INTO subscriptions (id, service_name, price, currency, billing_interval, interval_count, user_id, start_date, end_date, service_id, category, tags, trial_end_date) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::date, $9::date, $10, $11, COALESCE($12::text[], '{}'), $13::date) RETURNING id, price, currency, start_date, version, status ), priced AS ( INSERT INTO subscription_prices (subscription_id, effective_from, price, currency) SELECT id, date_trunc('month', start_date)::date, price, currency FROM created ) SELECT version, status FROM created"
+
 func TestCreateSubs(t *testing.T) {
 	sub := &models.Subscription{
-		ID:          uuid.New(),
-		ServiceName: "Test Service",
-		Price:       100,
-		UserID:      uuid.New(),
-		StartDate:   "01-2025",
-		EndDate:     nil,
+		ID:              uuid.New(),
+		ServiceID:       uuid.New(),
+		ServiceName:     "Test Service",
+		Price:           100,
+		Currency:        "RUB",
+		BillingInterval: models.IntervalMonth,
+		IntervalCount:   1,
+		UserID:          uuid.New(),
+		StartDate:       "2025-01-01",
+		EndDate:         nil,
 	}
 
-	sqlMock.ExpectExec("INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5, $6)").WithArgs(
-		sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate,
-	).WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectBegin()
+	expectServiceName(sub)
+	sqlMock.ExpectQuery(createSubsQuery).WithArgs(
+		sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.Category, pq.StringArray(sub.Tags), sub.TrialEndDate,
+	).WillReturnRows(sqlmock.NewRows([]string{"version", "status"}).AddRow(1, "active"))
+	sqlMock.ExpectCommit()
 
-	err := repo.CreateSubs(sub)
+	err := repo.CreateSubs(context.Background(), sub)
 	assert.NoError(t, err)
+	assert.Equal(t, 1, sub.Version)
+	assert.Equal(t, models.StatusActive, sub.Status)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error case
-	sqlMock.ExpectExec("INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5, $6)").WithArgs(
-		sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate,
+	sqlMock.ExpectBegin()
+	expectServiceName(sub)
+	sqlMock.ExpectQuery(createSubsQuery).WithArgs(
+		sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.Category, pq.StringArray(sub.Tags), sub.TrialEndDate,
 	).WillReturnError(errors.New("db error"))
+	sqlMock.ExpectRollback()
 
-	err = repo.CreateSubs(sub)
+	err = repo.CreateSubs(context.Background(), sub)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create subscription")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
+func TestQueryTimeout(t *testing.T) {
+	id := uuid.New()
+	timeoutRepo := &Repository{db: mockDB, log: logger.Named("TestRepository"), queryTimeout: 10 * time.Millisecond}
+
+	// Test query exceeding the configured timeout is canceled
+	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(id).WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := timeoutRepo.DeleteSubs(context.Background(), id)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to delete subscription")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test canceled request context never reaches the database
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = repo.DeleteSubs(ctx, id)
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+const (
+	updateSubsQuery  = "WITH updated AS ( UPDATE subscriptions SET service_name = $1, service_id = $10, price = $2, currency = $3, billing_interval = $4, interval_count = $5, start_date = $6::date, end_date = $7::date, category = $11, tags = COALESCE($12::text[], '{}'), trial_end_date = $13::date, user_id = $14, version = version + 1 WHERE id = $8 AND version = $9 AND (status = 'active' OR end_date IS NOT DISTINCT FROM $7::date) RETURNING version, status ) SELECT (SELECT version FROM updated), (SELECT status FROM updated), (SELECT price FROM subscriptions WHERE id = $8), (SELECT currency FROM subscriptions WHERE id = $8), (SELECT version FROM subscriptions WHERE id = $8), (SELECT status FROM subscriptions WHERE id = $8), (SELECT user_id FROM subscriptions WHERE id = $8)"
+	recordPriceQuery = "WITH superseded AS ( DELETE FROM subscription_prices WHERE subscription_id = $1 AND effective_from > GREATEST(date_trunc('month', $2::date)::date, date_trunc('month', CURRENT_DATE)::date) ) INSERT INTO subscription_prices (subscription_id, effective_from, price, currency) VALUES ($1, GREATEST(date_trunc('month', $2::date)::date, date_trunc('month', CURRENT_DATE)::date), $3, $4) ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price, currency = EXCLUDED.currency"
+)
+
 func TestUpdSubs(t *testing.T) {
 	id := uuid.New()
 	newSubs := &models.Subscription{
-		ServiceName: "Updated Service",
-		Price:       200,
-		StartDate:   "02-2025",
-		EndDate:     nil,
+		ServiceID:       uuid.New(),
+		ServiceName:     "Updated Service",
+		Price:           200,
+		Currency:        "RUB",
+		BillingInterval: models.IntervalMonth,
+		IntervalCount:   1,
+		UserID:          uuid.New(),
+		StartDate:       "2025-02-01",
+		EndDate:         nil,
+	}
+	expectUpdate := func() *sqlmock.ExpectedQuery {
+		return sqlMock.ExpectQuery(updateSubsQuery).WithArgs(
+			newSubs.ServiceName, newSubs.Price, newSubs.Currency, newSubs.BillingInterval, newSubs.IntervalCount, newSubs.StartDate, newSubs.EndDate, id, 3, newSubs.ServiceID, newSubs.Category, pq.StringArray(newSubs.Tags), newSubs.TrialEndDate, newSubs.UserID,
+		)
 	}
 
-	sqlMock.ExpectExec("UPDATE subscriptions SET service_name = $1, price = $2, start_date = $3, end_date = $4 WHERE id = $5").WithArgs(
-		newSubs.ServiceName, newSubs.Price, newSubs.StartDate, newSubs.EndDate, id,
-	).WillReturnResult(sqlmock.NewResult(1, 1))
+	// Test successful update, the changed price is appended to the price history and the unsent reminders are dropped
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(4, "active", 100, "RUB", 3, "active", newSubs.UserID))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(recordPriceQuery).WithArgs(id, newSubs.StartDate, newSubs.Price, newSubs.Currency).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	_, err := repo.UpdateSubs(context.Background(), id, 3, newSubs)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, newSubs.Version)
+	assert.Equal(t, models.StatusActive, newSubs.Status)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test unchanged price leaves the price history as is
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(4, "active", 200, "RUB", 3, "active", newSubs.UserID))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	_, err = repo.UpdateSubs(context.Background(), id, 3, newSubs)
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test version mismatch
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(nil, nil, 100, "RUB", 4, "active", newSubs.UserID))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.UpdateSubs(context.Background(), id, 3, newSubs)
+	assert.ErrorIs(t, err, service.ErrPreconditionFailed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test subscription not found
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(nil, nil, nil, nil, nil, nil, nil))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.UpdateSubs(context.Background(), id, 3, newSubs)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test moving the subscription to another user returns its previous owner
+	oldOwner := uuid.New()
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(4, "active", 200, "RUB", 3, "active", oldOwner))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
 
-	err := repo.UpdateSubs(id, newSubs)
+	owner, err := repo.UpdateSubs(context.Background(), id, 3, newSubs)
 	assert.NoError(t, err)
+	assert.Equal(t, oldOwner, owner)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test end date change of a canceled subscription is refused
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnRows(sqlmock.NewRows([]string{"version", "status", "price", "currency", "current_version", "current_status", "user_id"}).AddRow(nil, nil, 200, "RUB", 3, "canceled", newSubs.UserID))
+	sqlMock.ExpectRollback()
+
+	_, err = repo.UpdateSubs(context.Background(), id, 3, newSubs)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+	assert.ErrorContains(t, err, "cannot change the end date of a subscription that is canceled")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error case
-	sqlMock.ExpectExec("UPDATE subscriptions SET service_name = $1, price = $2, start_date = $3, end_date = $4 WHERE id = $5").WithArgs(
-		newSubs.ServiceName, newSubs.Price, newSubs.StartDate, newSubs.EndDate, id,
-	).WillReturnError(errors.New("db error"))
+	sqlMock.ExpectBegin()
+	expectServiceName(newSubs)
+	expectUpdate().WillReturnError(errors.New("db error"))
+	sqlMock.ExpectRollback()
 
-	err = repo.UpdateSubs(id, newSubs)
+	_, err = repo.UpdateSubs(context.Background(), id, 3, newSubs)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update subscription")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
@@ -109,7 +236,7 @@ func TestSubscriptionExists(t *testing.T) {
 	sqlMock.ExpectQuery("SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)").WithArgs(id).WillReturnRows(
 		sqlmock.NewRows([]string{"exists"}).AddRow(true),
 	)
-	exists, err := repo.SubscriptionExists(id)
+	exists, err := repo.SubscriptionExists(context.Background(), id)
 	assert.NoError(t, err)
 	assert.True(t, exists)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
@@ -118,14 +245,14 @@ func TestSubscriptionExists(t *testing.T) {
 	sqlMock.ExpectQuery("SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)").WithArgs(id).WillReturnRows(
 		sqlmock.NewRows([]string{"exists"}).AddRow(false),
 	)
-	exists, err = repo.SubscriptionExists(id)
+	exists, err = repo.SubscriptionExists(context.Background(), id)
 	assert.NoError(t, err)
 	assert.False(t, exists)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error
 	sqlMock.ExpectQuery("SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)").WithArgs(id).WillReturnError(errors.New("db error"))
-	exists, err = repo.SubscriptionExists(id)
+	exists, err = repo.SubscriptionExists(context.Background(), id)
 	assert.Error(t, err)
 	assert.False(t, exists)
 	assert.Contains(t, err.Error(), "error checking subscription existence")
@@ -137,17 +264,77 @@ func TestDeleteSubs(t *testing.T) {
 
 	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(id).WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := repo.DeleteSubs(id)
+	err := repo.DeleteSubs(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error case
 	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(id).WillReturnError(errors.New("db error"))
 
-	err = repo.DeleteSubs(id)
+	err = repo.DeleteSubs(context.Background(), id)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to delete subscription")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test not found
+	sqlMock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.DeleteSubs(context.Background(), id)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestMapError(t *testing.T) {
+	cases := []struct {
+		code   pq.ErrorCode
+		target error
+	}{
+		{"23505", service.ErrConflict},
+		{"23514", service.ErrConstraintViolation},
+		{"23502", service.ErrConstraintViolation},
+		{"22007", service.ErrValidation},
+	}
+	for _, c := range cases {
+		err := mapError(&pq.Error{Code: c.code, Message: "pq error"})
+		assert.ErrorIs(t, err, c.target, string(c.code))
+		var pqErr *pq.Error
+		assert.ErrorAs(t, err, &pqErr)
+	}
+
+	// Other errors are returned unchanged
+	dbErr := errors.New("db error")
+	assert.Equal(t, dbErr, mapError(dbErr))
+	serverErr := &pq.Error{Code: "57014"}
+	assert.Equal(t, serverErr, mapError(serverErr))
+
+	// Test constraint violation on create
+	sub := &models.Subscription{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Test Service", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-01"}
+	sqlMock.ExpectBegin()
+	expectServiceName(sub)
+	sqlMock.ExpectQuery(createSubsQuery).WithArgs(
+		sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.Category, pq.StringArray(sub.Tags), sub.TrialEndDate,
+	).WillReturnError(&pq.Error{Code: "23514", Message: "new row violates check constraint"})
+	sqlMock.ExpectRollback()
+
+	err := repo.CreateSubs(context.Background(), sub)
+	assert.ErrorIs(t, err, service.ErrConstraintViolation)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+// listSubsQuery returns the listing query for the given sort column, cursor expression and direction.
+func listSubsQuery(column, cursor, comparison, direction string) string {
+	return "SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE " +
+		"($1::uuid IS NULL OR user_id = $1) AND ($2::text IS NULL OR service_id = (SELECT id FROM services WHERE slug = $2)) AND " +
+		"($3::text IS NULL OR (" + column + ", id) " + comparison + " (" + cursor + ", $4::uuid)) AND " +
+		"($6::uuid[] IS NULL OR user_id = ANY($6)) AND " +
+		"($7::text IS NULL OR lower(service_name) LIKE lower($7) || '%') AND " +
+		"($8::bigint IS NULL OR price >= $8) AND ($9::bigint IS NULL OR price <= $9) AND " +
+		"($10::text IS NULL OR (start_date < to_date($10, 'MM-YYYY') + interval '1 month' AND (end_date IS NULL OR end_date >= to_date($10, 'MM-YYYY')))) AND " +
+		"($11::text IS NULL OR start_date >= to_date($11, 'MM-YYYY')) AND ($12::text IS NULL OR start_date < to_date($12, 'MM-YYYY') + interval '1 month') AND " +
+		"($13::text IS NULL OR end_date >= to_date($13, 'MM-YYYY')) AND ($14::text IS NULL OR end_date < to_date($14, 'MM-YYYY') + interval '1 month') AND " +
+		"(NOT $15::boolean OR end_date IS NULL) AND ($16::text IS NULL OR currency = $16) AND " +
+		"($17::text IS NULL OR category = $17) AND ($18::text[] IS NULL OR tags @> $18) " +
+		"ORDER BY " + column + " " + direction + ", id " + direction + " LIMIT $5"
 }
 
 func TestListSubs(t *testing.T) {
@@ -155,105 +342,206 @@ func TestListSubs(t *testing.T) {
 		UserID:      nil,
 		ServiceName: nil,
 	}
+	params := models.ListParams{Limit: 51, SortBy: models.SortByStartDate}
+	query := listSubsQuery("start_date", "$3::date", ">", "ASC")
+	var noCursorValue, noString *string
+	var noCursorID *uuid.UUID
+	var noUserIDs pq.StringArray
+	var noPrice *int64
+	emptyArgs := []driver.Value{filter.UserID, filter.ServiceName, noCursorValue, noCursorID, params.Limit, noUserIDs, noString, noPrice, noPrice, noString, noString, noString, noString, noString, false, noString, noString, pq.StringArray(nil)}
 
 	sub1 := models.Subscription{
-		ID: uuid.New(), ServiceName: "Service A", Price: 100, UserID: uuid.New(), StartDate: "01-2025", EndDate: nil,
+		ID: uuid.New(), ServiceName: "Service A", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-01", EndDate: nil, Version: 1, Status: models.StatusActive,
 	}
 	sub2 := models.Subscription{
-		ID: uuid.New(), ServiceName: "Service B", Price: 200, UserID: uuid.New(), StartDate: "02-2025", EndDate: nil,
+		ID: uuid.New(), ServiceName: "Service B", Price: 200, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-02-01", EndDate: nil, Version: 2, Status: models.StatusActive,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date"}).
-		AddRow(sub1.ID, sub1.ServiceName, sub1.Price, sub1.UserID, sub1.StartDate, sub1.EndDate).
-		AddRow(sub2.ID, sub2.ServiceName, sub2.Price, sub2.UserID, sub2.StartDate, sub2.EndDate)
+	rows := sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).
+		AddRow(sub1.ID, sub1.ServiceID, sub1.ServiceName, sub1.Price, sub1.Currency, sub1.BillingInterval, sub1.IntervalCount, sub1.UserID, sub1.StartDate, sub1.EndDate, sub1.Version, sub1.Status, sub1.Category, nil, sub1.TrialEndDate).
+		AddRow(sub2.ID, sub2.ServiceID, sub2.ServiceName, sub2.Price, sub2.Currency, sub2.BillingInterval, sub2.IntervalCount, sub2.UserID, sub2.StartDate, sub2.EndDate, sub2.Version, sub2.Status, sub2.Category, nil, sub2.TrialEndDate)
 
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::text IS NULL OR service_name = $2)").WithArgs(filter.UserID, filter.ServiceName).WillReturnRows(rows)
+	sqlMock.ExpectQuery(query).WithArgs(emptyArgs...).WillReturnRows(rows)
 
-	subs, err := repo.ListSubs(filter)
+	subs, err := repo.ListSubs(context.Background(), filter, params)
 	assert.NoError(t, err)
 	assert.Len(t, subs, 2)
 	assert.Equal(t, sub1.ID, subs[0].ID)
 	assert.Equal(t, sub2.ID, subs[1].ID)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
+	// Test descending sort continuing after a cursor with every filter set
+	cursor := &models.Cursor{SortBy: models.SortByPrice, Desc: true, Value: "300", ID: uuid.New()}
+	descParams := models.ListParams{Limit: 11, SortBy: models.SortByPrice, Desc: true, Cursor: cursor}
+	userA, userB := uuid.New(), uuid.New()
+	prefix, currency, month, category := "Yandex_100%", "RUB", "03-2025", "streaming"
+	minPrice, maxPrice := int64(10000), int64(50000)
+	fullFilter := models.SubscriptionFilter{
+		UserIDs:           []uuid.UUID{userA, userB},
+		ServiceNamePrefix: &prefix,
+		Currency:          &currency,
+		MinPrice:          &minPrice,
+		MaxPrice:          &maxPrice,
+		ActiveOn:          &month,
+		StartFrom:         &month,
+		StartTo:           &month,
+		EndFrom:           &month,
+		EndTo:             &month,
+		OpenEnded:         true,
+		Category:          &category,
+		Tags:              []string{"family", "work"},
+	}
+	escapedPrefix := `Yandex\_100\%`
+	sqlMock.ExpectQuery(listSubsQuery("price", "$3::bigint", "<", "DESC")).WithArgs(
+		fullFilter.UserID, fullFilter.ServiceName, &cursor.Value, &cursor.ID, descParams.Limit,
+		pq.StringArray{userA.String(), userB.String()}, &escapedPrefix, &minPrice, &maxPrice,
+		&month, &month, &month, &month, &month, true, &currency, &category, pq.StringArray{"family", "work"},
+	).WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).
+		AddRow(sub2.ID, sub2.ServiceID, sub2.ServiceName, sub2.Price, sub2.Currency, sub2.BillingInterval, sub2.IntervalCount, sub2.UserID, sub2.StartDate, sub2.EndDate, sub2.Version, sub2.Status, sub2.Category, nil, sub2.TrialEndDate))
+
+	subs, err = repo.ListSubs(context.Background(), fullFilter, descParams)
+	assert.NoError(t, err)
+	assert.Len(t, subs, 1)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test unsupported sort field
+	subs, err = repo.ListSubs(context.Background(), filter, models.ListParams{Limit: 1, SortBy: "user_id"})
+	assert.Error(t, err)
+	assert.Nil(t, subs)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
 	// Test error case
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::text IS NULL OR service_name = $2)").WithArgs(filter.UserID, filter.ServiceName).WillReturnError(errors.New("db error"))
+	sqlMock.ExpectQuery(query).WithArgs(emptyArgs...).WillReturnError(errors.New("db error"))
 
-	subs, err = repo.ListSubs(filter)
+	subs, err = repo.ListSubs(context.Background(), filter, params)
 	assert.Error(t, err)
 	assert.Nil(t, subs)
 	assert.Contains(t, err.Error(), "failed to query subscriptions")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test scan error
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::text IS NULL OR service_name = $2)").WithArgs(filter.UserID, filter.ServiceName).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date"}).AddRow("invalid-uuid", "Service C", 300, uuid.New(), "03-2025", nil),
+	sqlMock.ExpectQuery(query).WithArgs(emptyArgs...).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).AddRow("invalid-uuid", uuid.New(), "Service C", 300, "RUB", "month", 1, uuid.New(), "2025-03-01", nil, 1, "active", "", nil, nil),
 	)
-	subs, err = repo.ListSubs(filter)
+	subs, err = repo.ListSubs(context.Background(), filter, params)
 	assert.Error(t, err)
 	assert.Nil(t, subs)
 	assert.Contains(t, err.Error(), "failed to scan subscription")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
-func TestGetSummary(t *testing.T) {
+func TestStreamSubs(t *testing.T) {
+	filter := models.SubscriptionFilter{}
+	params := models.ListParams{SortBy: models.SortByServiceName}
+	var noCursorValue, noString *string
+	var noCursorID *uuid.UUID
+	var noUserIDs pq.StringArray
+	var noLimit *int
+	var noPrice *int64
+	args := []driver.Value{filter.UserID, filter.ServiceName, noCursorValue, noCursorID, noLimit, noUserIDs, noString, noPrice, noPrice, noString, noString, noString, noString, noString, false, noString, noString, pq.StringArray(nil)}
+	columns := []string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}
+	sub1 := models.Subscription{ID: uuid.New(), ServiceName: "Service A", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-01", Version: 1}
+	sub2 := models.Subscription{ID: uuid.New(), ServiceName: "Service B", Price: 200, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-02-01", Version: 1}
+
+	// Test case 1: Every row is passed on, without a limit
+	sqlMock.ExpectQuery(listSubsQuery("service_name", "$3::text", ">", "ASC")).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(sub1.ID, sub1.ServiceID, sub1.ServiceName, sub1.Price, sub1.Currency, sub1.BillingInterval, sub1.IntervalCount, sub1.UserID, sub1.StartDate, sub1.EndDate, sub1.Version, sub1.Status, sub1.Category, nil, sub1.TrialEndDate).
+			AddRow(sub2.ID, sub2.ServiceID, sub2.ServiceName, sub2.Price, sub2.Currency, sub2.BillingInterval, sub2.IntervalCount, sub2.UserID, sub2.StartDate, sub2.EndDate, sub2.Version, sub2.Status, sub2.Category, nil, sub2.TrialEndDate))
+
+	var streamed []uuid.UUID
+	err := repo.StreamSubs(context.Background(), filter, params, func(sub *models.Subscription) error {
+		streamed = append(streamed, sub.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{sub1.ID, sub2.ID}, streamed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 2: Error of the callback stops the iteration
+	sqlMock.ExpectQuery(listSubsQuery("service_name", "$3::text", ">", "ASC")).WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(sub1.ID, sub1.ServiceID, sub1.ServiceName, sub1.Price, sub1.Currency, sub1.BillingInterval, sub1.IntervalCount, sub1.UserID, sub1.StartDate, sub1.EndDate, sub1.Version, sub1.Status, sub1.Category, nil, sub1.TrialEndDate).
+			AddRow(sub2.ID, sub2.ServiceID, sub2.ServiceName, sub2.Price, sub2.Currency, sub2.BillingInterval, sub2.IntervalCount, sub2.UserID, sub2.StartDate, sub2.EndDate, sub2.Version, sub2.Status, sub2.Category, nil, sub2.TrialEndDate))
+
+	writeErr := errors.New("client gone")
+	calls := 0
+	err = repo.StreamSubs(context.Background(), filter, params, func(sub *models.Subscription) error {
+		calls++
+		return writeErr
+	})
+	assert.ErrorIs(t, err, writeErr)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestListSubsInPeriod(t *testing.T) {
 	sumReq := &models.GetSummary{
 		From:        "01-2025",
 		To:          "12-2025",
 		UserID:      nil,
 		ServiceName: "",
 	}
+	query := "SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE ($1::text = '' OR start_date < to_date($1, 'MM-YYYY') + interval '1 month') AND ($2::text = '' OR end_date >= to_date($2, 'MM-YYYY') OR end_date IS NULL) AND ($3::uuid IS NULL OR user_id = $3) AND ($4::text = '' OR service_id = (SELECT id FROM services WHERE slug = $4))"
 
-	sqlMock.ExpectQuery("SELECT COALESCE(SUM(price), 0) FROM subscriptions WHERE ($1::text = '' OR start_date <= $1) AND ($2::text = '' OR end_date >= $2 OR end_date IS NULL) AND ($3::uuid IS NULL OR user_id = $3) AND ($4::text = '' OR service_name = $4)").WithArgs(
+	sub := models.Subscription{
+		ID: uuid.New(), ServiceName: "Service A", Price: 400, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-03-01", EndDate: nil, Version: 1, Status: models.StatusActive,
+	}
+	sqlMock.ExpectQuery(query).WithArgs(
 		sumReq.To, sumReq.From, sumReq.UserID, sumReq.ServiceName,
-	).WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(500))
+	).WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).
+		AddRow(sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.Version, sub.Status, sub.Category, nil, sub.TrialEndDate))
 
-	total, err := repo.GetSummary(sumReq)
+	subs, err := repo.ListSubsInPeriod(context.Background(), sumReq)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, total)
+	assert.Len(t, subs, 1)
+	assert.Equal(t, sub, subs[0])
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error case
-	sqlMock.ExpectQuery("SELECT COALESCE(SUM(price), 0) FROM subscriptions WHERE ($1::text = '' OR start_date <= $1) AND ($2::text = '' OR end_date >= $2 OR end_date IS NULL) AND ($3::uuid IS NULL OR user_id = $3) AND ($4::text = '' OR service_name = $4)").WithArgs(
+	sqlMock.ExpectQuery(query).WithArgs(
 		sumReq.To, sumReq.From, sumReq.UserID, sumReq.ServiceName,
 	).WillReturnError(errors.New("db error"))
 
-	total, err = repo.GetSummary(sumReq)
+	subs, err = repo.ListSubsInPeriod(context.Background(), sumReq)
 	assert.Error(t, err)
-	assert.Equal(t, 0, total)
-	assert.Contains(t, err.Error(), "failed to calculate summary")
+	assert.Nil(t, subs)
+	assert.Contains(t, err.Error(), "failed to query subscriptions in period")
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
 func TestGetSub(t *testing.T) {
 	id := uuid.New()
 	sub := models.Subscription{
-		ID: id, ServiceName: "Service X", Price: 100, UserID: uuid.New(), StartDate: "01-2025", EndDate: nil,
+		ID: id, ServiceName: "Service X", Price: 100, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "2025-01-01", EndDate: nil, Version: 1, Status: models.StatusActive,
 	}
 
 	// Test found
-	rows := sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date"}).
-		AddRow(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate)
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnRows(rows)
+	rows := sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).
+		AddRow(sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.Version, sub.Status, sub.Category, nil, sub.TrialEndDate)
+	sqlMock.ExpectQuery("SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnRows(rows)
 
-	foundSub, err := repo.GetSub(id)
+	foundSub, err := repo.GetSub(context.Background(), id)
 	assert.NoError(t, err)
 	assert.Equal(t, sub.ID, foundSub.ID)
+	assert.Equal(t, sub.Version, foundSub.Version)
+	assert.Equal(t, sub.Status, foundSub.Status)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test not found
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnError(sql.ErrNoRows)
+	sqlMock.ExpectQuery("SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnError(sql.ErrNoRows)
 
-	foundSub, err = repo.GetSub(id)
+	foundSub, err = repo.GetSub(context.Background(), id)
 	assert.Error(t, err)
 	assert.Nil(t, foundSub)
 	assert.Contains(t, err.Error(), "subscription not found")
+	assert.ErrorIs(t, err, service.ErrNotFound)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 
 	// Test error
-	sqlMock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnError(errors.New("db error"))
+	sqlMock.ExpectQuery("SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE id = $1 LIMIT 1").WithArgs(id).WillReturnError(errors.New("db error"))
 
-	foundSub, err = repo.GetSub(id)
+	foundSub, err = repo.GetSub(context.Background(), id)
 	assert.Error(t, err)
 	assert.Nil(t, foundSub)
 	assert.Contains(t, err.Error(), "database error")
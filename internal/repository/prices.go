@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// recordPrice appends the price to the price history of the subscription inside the transaction.
+// The price takes effect in the current month, or in the start month of a subscription that has not
+// started yet; entries that would have taken effect later are superseded by it and removed.
+func (r *Repository) recordPrice(ctx context.Context, tx *sql.Tx, id uuid.UUID, startDate string, price int64, currency string) error {
+	query := `
+		WITH superseded AS (
+			DELETE FROM subscription_prices
+			WHERE subscription_id = $1
+				AND effective_from > GREATEST(date_trunc('month', $2::date)::date, date_trunc('month', CURRENT_DATE)::date)
+		)
+		INSERT INTO subscription_prices (subscription_id, effective_from, price, currency)
+		VALUES ($1, GREATEST(date_trunc('month', $2::date)::date, date_trunc('month', CURRENT_DATE)::date), $3, $4)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price, currency = EXCLUDED.currency
+	`
+	if _, err := tx.ExecContext(ctx, query, id, startDate, price, currency); err != nil {
+		r.log.Error("Error recording price", zap.Error(err))
+		return fmt.Errorf("failed to record price: %w", mapError(err))
+	}
+	return nil
+}
+
+// ListPrices returns the price history of the given subscriptions ordered by the month each price
+// takes effect, keyed by subscription ID. Subscriptions without a history are absent from the map.
+func (r *Repository) ListPrices(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.PricePeriod, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing prices", zap.Int("subscriptions", len(ids)))
+
+	subIDs := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		subIDs = append(subIDs, id.String())
+	}
+
+	query := `
+		SELECT subscription_id, to_char(effective_from, 'MM-YYYY'), price, currency
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1::uuid[])
+		ORDER BY subscription_id, effective_from
+	`
+	rows, err := r.db.QueryContext(ctx, query, subIDs)
+	if err != nil {
+		r.log.Error("Error listing prices", zap.Error(err))
+		return nil, fmt.Errorf("failed to query prices: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	prices := make(map[uuid.UUID][]models.PricePeriod)
+	for rows.Next() {
+		var id uuid.UUID
+		var price models.PricePeriod
+		if err := rows.Scan(&id, &price.EffectiveFrom, &price.Price, &price.Currency); err != nil {
+			r.log.Error("failed to scan price", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		prices[id] = append(prices[id], price)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over price rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over price rows: %w", err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListPrices(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+
+	sqlMock.ExpectQuery("SELECT subscription_id, to_char(effective_from, 'MM-YYYY'), price, currency FROM subscription_prices WHERE subscription_id = ANY($1::uuid[]) ORDER BY subscription_id, effective_from").
+		WithArgs(pq.StringArray{first.String(), second.String()}).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "effective_from", "price", "currency"}).
+			AddRow(first, "01-2025", 299, "RUB").
+			AddRow(first, "06-2025", 399, "USD").
+			AddRow(second, "03-2025", 100, "RUB"))
+
+	prices, err := repo.ListPrices(context.Background(), []uuid.UUID{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID][]models.PricePeriod{
+		first:  {{EffectiveFrom: "01-2025", Price: 299, Currency: "RUB"}, {EffectiveFrom: "06-2025", Price: 399, Currency: "USD"}},
+		second: {{EffectiveFrom: "03-2025", Price: 100, Currency: "RUB"}},
+	}, prices)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// SetRate stores the exchange rate of the currency for the month, replacing the rate
+// previously set for the same month. rate.Rate is set to the stored value.
+func (r *Repository) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Setting exchange rate", zap.String("currency", rate.Currency), zap.String("month", rate.Month))
+
+	query := `
+		INSERT INTO exchange_rates (currency, month, rate)
+		VALUES ($1, to_date($2, 'MM-YYYY'), $3::numeric)
+		ON CONFLICT (currency, month) DO UPDATE SET rate = EXCLUDED.rate
+		RETURNING rate::text
+	`
+	if err := r.db.QueryRowContext(ctx, query, rate.Currency, rate.Month, rate.Rate).Scan(&rate.Rate); err != nil {
+		r.log.Error("Error setting exchange rate", zap.Error(err))
+		return fmt.Errorf("failed to set exchange rate: %w", mapError(err))
+	}
+	return nil
+}
+
+// DeleteRate removes the exchange rate of the currency for the month.
+// Returns service.ErrNotFound if no rate is set for that month.
+func (r *Repository) DeleteRate(ctx context.Context, currency string, month string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Deleting exchange rate", zap.String("currency", currency), zap.String("month", month))
+
+	query := `DELETE FROM exchange_rates WHERE currency = $1 AND month = to_date($2, 'MM-YYYY')`
+	result, err := r.db.ExecContext(ctx, query, currency, month)
+	if err != nil {
+		r.log.Error("Error deleting exchange rate", zap.Error(err))
+		return fmt.Errorf("failed to delete exchange rate: %w", mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		r.log.Debug("Exchange rate not found", zap.String("currency", currency), zap.String("month", month))
+		return fmt.Errorf("exchange rate of %s for %s %w", currency, month, service.ErrNotFound)
+	}
+	return nil
+}
+
+// ListRates returns the exchange rates of the given currencies, or of all currencies if none are given,
+// that apply from months not later than until (MM-YYYY, no bound if empty).
+// The rates are ordered by currency and month.
+func (r *Repository) ListRates(ctx context.Context, currencies []string, until string) ([]models.ExchangeRate, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing exchange rates", zap.Strings("currencies", currencies), zap.String("until", until))
+
+	query := `
+		SELECT currency, to_char(month, 'MM-YYYY'), rate::text
+		FROM exchange_rates
+		WHERE
+			($1::text[] IS NULL OR currency = ANY($1)) AND
+			($2::text = '' OR month <= to_date($2, 'MM-YYYY'))
+		ORDER BY currency, month
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.StringArray(currencies), until)
+	if err != nil {
+		r.log.Error("Error listing exchange rates", zap.Error(err))
+		return nil, fmt.Errorf("failed to query exchange rates: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Month, &rate.Rate); err != nil {
+			r.log.Error("failed to scan exchange rate", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over exchange rate rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over exchange rate rows: %w", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRate(t *testing.T) {
+	rate := &models.ExchangeRate{Currency: "USD", Month: "01-2025", Rate: "92.350"}
+	sqlMock.ExpectQuery("INSERT INTO exchange_rates (currency, month, rate) VALUES ($1, to_date($2, 'MM-YYYY'), $3::numeric) ON CONFLICT (currency, month) DO UPDATE SET rate = EXCLUDED.rate RETURNING rate::text").
+		WithArgs("USD", "01-2025", "92.350").
+		WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow("92.350"))
+
+	err := repo.SetRate(context.Background(), rate)
+	assert.NoError(t, err)
+	assert.Equal(t, "92.350", rate.Rate)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDeleteRate(t *testing.T) {
+	query := "DELETE FROM exchange_rates WHERE currency = $1 AND month = to_date($2, 'MM-YYYY')"
+
+	// Test case 1: Rate deleted
+	sqlMock.ExpectExec(query).WithArgs("USD", "01-2025").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.DeleteRate(context.Background(), "USD", "01-2025"))
+
+	// Test case 2: No rate for the month
+	sqlMock.ExpectExec(query).WithArgs("USD", "02-2025").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.DeleteRate(context.Background(), "USD", "02-2025"), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestListRates(t *testing.T) {
+	query := "SELECT currency, to_char(month, 'MM-YYYY'), rate::text FROM exchange_rates WHERE ($1::text[] IS NULL OR currency = ANY($1)) AND ($2::text = '' OR month <= to_date($2, 'MM-YYYY')) ORDER BY currency, month"
+	columns := []string{"currency", "month", "rate"}
+
+	// Test case 1: Rates of the given currencies up to a month
+	sqlMock.ExpectQuery(query).WithArgs(pq.StringArray{"EUR", "USD"}, "06-2025").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("EUR", "01-2025", "100.5").
+			AddRow("USD", "01-2025", "92.35").
+			AddRow("USD", "04-2025", "90"))
+
+	rates, err := repo.ListRates(context.Background(), []string{"EUR", "USD"}, "06-2025")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ExchangeRate{
+		{Currency: "EUR", Month: "01-2025", Rate: "100.5"},
+		{Currency: "USD", Month: "01-2025", Rate: "92.35"},
+		{Currency: "USD", Month: "04-2025", Rate: "90"},
+	}, rates)
+
+	// Test case 2: No currencies lists every rate
+	sqlMock.ExpectQuery(query).WithArgs(pq.StringArray(nil), "").WillReturnRows(sqlmock.NewRows(columns))
+
+	rates, err = repo.ListRates(context.Background(), nil, "")
+	assert.NoError(t, err)
+	assert.Empty(t, rates)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
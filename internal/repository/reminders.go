@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ListUpcomingSubs returns the active subscriptions that start on or before 'to' and have not ended before 'from':
+// those that may be charged, or whose trial may end, between the two YYYY-MM-DD dates.
+// The subscriptions are returned by ID, up to limit of them whose ID follows 'after', so that they are read
+// page by page: the next page follows the ID of the last subscription of the previous one; uuid.Nil starts
+// from the first.
+func (r *Repository) ListUpcomingSubs(ctx context.Context, from string, to string, after uuid.UUID, limit int) ([]models.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing upcoming subscriptions", zap.String("from", from), zap.String("to", to), zap.String("after", after.String()))
+
+	query := `
+		SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD')
+		FROM subscriptions
+		WHERE
+			status = 'active' AND
+			start_date <= $2::date AND
+			(end_date IS NULL OR end_date >= $1::date) AND
+			id > $3
+		ORDER BY id
+		LIMIT $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, to, after, limit)
+	if err != nil {
+		r.log.Error("Error listing upcoming subscriptions", zap.Error(err))
+		return nil, fmt.Errorf("failed to query upcoming subscriptions: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	return r.scanSubs(rows)
+}
+
+// QueueReminders stores the reminders to be sent, skipping those already queued for the same subscription,
+// kind and date, possibly by another replica. Returns the number of reminders queued.
+func (r *Repository) QueueReminders(ctx context.Context, reminders []models.Reminder) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Queueing reminders", zap.Int("count", len(reminders)))
+
+	var ids, subIDs, userIDs, kinds, dueDates, names, currencies pq.StringArray
+	var prices pq.Int64Array
+	for _, reminder := range reminders {
+		ids = append(ids, reminder.ID.String())
+		subIDs = append(subIDs, reminder.SubscriptionID.String())
+		userIDs = append(userIDs, reminder.UserID.String())
+		kinds = append(kinds, reminder.Kind)
+		dueDates = append(dueDates, reminder.DueDate)
+		names = append(names, reminder.ServiceName)
+		prices = append(prices, reminder.Price)
+		currencies = append(currencies, reminder.Currency)
+	}
+
+	// The reminders are inserted in a single statement from parallel arrays.
+	query := `
+		INSERT INTO reminders (id, subscription_id, user_id, kind, due_date, service_name, price, currency)
+		SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::uuid[], $4::text[], $5::date[], $6::text[], $7::bigint[], $8::text[])
+		ON CONFLICT (subscription_id, kind, due_date) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, ids, subIDs, userIDs, kinds, dueDates, names, prices, currencies)
+	if err != nil {
+		r.log.Error("Error queueing reminders", zap.Error(err))
+		return 0, fmt.Errorf("failed to queue reminders: %w", mapError(err))
+	}
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return queued, nil
+}
+
+// ClaimReminders reserves up to limit unsent reminders due on or after the YYYY-MM-DD date 'from' for the lease,
+// the earliest first, and returns them. Reminders claimed by another replica whose lease has not expired
+// are skipped, as are those that have already been attempted maxAttempts times and those of subscriptions
+// that are no longer active or end before the reminder is due. Rows locked by a concurrent claim are skipped
+// rather than waited for, so replicas claim disjoint reminders.
+func (r *Repository) ClaimReminders(ctx context.Context, from string, limit int, lease time.Duration, maxAttempts int) ([]models.Reminder, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Claiming reminders", zap.String("from", from), zap.Int("limit", limit))
+
+	query := `
+		UPDATE reminders
+		SET attempts = attempts + 1, claimed_until = now() + $3 * interval '1 second'
+		WHERE id IN (
+			SELECT r.id FROM reminders r
+			JOIN subscriptions s ON s.id = r.subscription_id
+			WHERE
+				r.sent_at IS NULL AND
+				r.due_date >= $1::date AND
+				r.attempts < $4 AND
+				(r.claimed_until IS NULL OR r.claimed_until < now()) AND
+				s.status = 'active' AND
+				(s.end_date IS NULL OR s.end_date >= r.due_date)
+			ORDER BY r.due_date, r.id
+			LIMIT $2
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		RETURNING id, subscription_id, user_id, kind, to_char(due_date, 'YYYY-MM-DD'), service_name, price, currency, created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, limit, lease.Seconds(), maxAttempts)
+	if err != nil {
+		r.log.Error("Error claiming reminders", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim reminders: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	reminders := []models.Reminder{}
+	for rows.Next() {
+		var reminder models.Reminder
+		if err := rows.Scan(&reminder.ID, &reminder.SubscriptionID, &reminder.UserID, &reminder.Kind, &reminder.DueDate,
+			&reminder.ServiceName, &reminder.Price, &reminder.Currency, &reminder.CreatedAt); err != nil {
+			r.log.Error("failed to scan reminder", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over reminder rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over reminder rows: %w", err)
+	}
+	return reminders, nil
+}
+
+// MarkReminderSent records that the reminder has been delivered, so that it is not claimed again.
+func (r *Repository) MarkReminderSent(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE reminders SET sent_at = now(), claimed_until = NULL WHERE id = $1`, id); err != nil {
+		r.log.Error("Error marking reminder sent", zap.Error(err))
+		return fmt.Errorf("failed to mark reminder sent: %w", mapError(err))
+	}
+	return nil
+}
+
+// dropReminders removes the unsent reminders of the subscription inside the transaction tx. It is called
+// when the status, dates or price of the subscription change, since the reminders were queued for the
+// subscription as it was; those still due are queued again on the next run of the scheduler.
+func (r *Repository) dropReminders(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reminders WHERE subscription_id = $1 AND sent_at IS NULL`, id); err != nil {
+		r.log.Error("Error dropping reminders", zap.Error(err))
+		return fmt.Errorf("failed to drop reminders: %w", mapError(err))
+	}
+	return nil
+}
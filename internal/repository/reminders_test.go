@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	claimRemindersQuery = "UPDATE reminders SET attempts = attempts + 1, claimed_until = now() + $3 * interval '1 second' WHERE id IN ( SELECT r.id FROM reminders r JOIN subscriptions s ON s.id = r.subscription_id WHERE r.sent_at IS NULL AND r.due_date >= $1::date AND r.attempts < $4 AND (r.claimed_until IS NULL OR r.claimed_until < now()) AND s.status = 'active' AND (s.end_date IS NULL OR s.end_date >= r.due_date) ORDER BY r.due_date, r.id LIMIT $2 FOR UPDATE OF r SKIP LOCKED ) RETURNING id, subscription_id, user_id, kind, to_char(due_date, 'YYYY-MM-DD'), service_name, price, currency, created_at"
+	dropRemindersQuery  = "DELETE FROM reminders WHERE subscription_id = $1 AND sent_at IS NULL"
+)
+
+func TestListUpcomingSubs(t *testing.T) {
+	trialEnd := "2025-03-09"
+	sub := models.Subscription{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Video", Price: 59900, Currency: "RUB", BillingInterval: "month", IntervalCount: 1,
+		UserID: uuid.New(), StartDate: "2025-02-25", Version: 1, Status: "active", TrialEndDate: &trialEnd}
+
+	sqlMock.ExpectQuery("SELECT id, service_id, service_name, price, currency, billing_interval, interval_count, user_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), version, status, category, tags, to_char(trial_end_date, 'YYYY-MM-DD') FROM subscriptions WHERE status = 'active' AND start_date <= $2::date AND (end_date IS NULL OR end_date >= $1::date) AND id > $3 ORDER BY id LIMIT $4").
+		WithArgs("2025-03-08", "2025-03-11", uuid.Nil, 1000).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id", "start_date", "end_date", "version", "status", "category", "tags", "trial_end_date"}).
+			AddRow(sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, nil, sub.Version, sub.Status, "", nil, "2025-03-09"))
+	subs, err := repo.ListUpcomingSubs(context.Background(), "2025-03-08", "2025-03-11", uuid.Nil, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Subscription{sub}, subs)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestQueueAndClaimReminders(t *testing.T) {
+	reminder := models.Reminder{ID: uuid.New(), SubscriptionID: uuid.New(), UserID: uuid.New(), Kind: models.ReminderRenewal, DueDate: "2025-03-10",
+		ServiceName: "Music", Price: 34900, Currency: "RUB", CreatedAt: time.Date(2025, time.March, 8, 12, 0, 0, 0, time.UTC)}
+
+	// Test case 1: Reminders are queued in a single statement, skipping those already queued
+	sqlMock.ExpectExec("INSERT INTO reminders (id, subscription_id, user_id, kind, due_date, service_name, price, currency) SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::uuid[], $4::text[], $5::date[], $6::text[], $7::bigint[], $8::text[]) ON CONFLICT (subscription_id, kind, due_date) DO NOTHING").
+		WithArgs(pq.StringArray{reminder.ID.String()}, pq.StringArray{reminder.SubscriptionID.String()}, pq.StringArray{reminder.UserID.String()},
+			pq.StringArray{"renewal"}, pq.StringArray{"2025-03-10"}, pq.StringArray{"Music"}, pq.Int64Array{34900}, pq.StringArray{"RUB"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	queued, err := repo.QueueReminders(context.Background(), []models.Reminder{reminder})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), queued)
+
+	// Test case 2: Unsent reminders are claimed for the lease, skipping rows locked by other replicas
+	sqlMock.ExpectQuery(claimRemindersQuery).
+		WithArgs("2025-03-08", 100, float64(300), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "user_id", "kind", "due_date", "service_name", "price", "currency", "created_at"}).
+			AddRow(reminder.ID, reminder.SubscriptionID, reminder.UserID, "renewal", "2025-03-10", "Music", 34900, "RUB", reminder.CreatedAt))
+	claimed, err := repo.ClaimReminders(context.Background(), "2025-03-08", 100, 5*time.Minute, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Reminder{reminder}, claimed)
+
+	// Test case 3: A delivered reminder is marked as sent
+	sqlMock.ExpectExec("UPDATE reminders SET sent_at = now(), claimed_until = NULL WHERE id = $1").WithArgs(reminder.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.MarkReminderSent(context.Background(), reminder.ID))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Test case 4: A reminder queued before its subscription was canceled is not claimed
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(changeStatusQuery).WithArgs(models.StatusCanceled, "2025-03-09", reminder.SubscriptionID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "exists"}).AddRow(2, true))
+	sqlMock.ExpectExec(dropRemindersQuery).WithArgs(reminder.SubscriptionID).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	endDate := "2025-03-09"
+	_, err = repo.ChangeStatus(context.Background(), reminder.SubscriptionID, 1, &models.StatusChange{Status: models.StatusCanceled, EndDate: &endDate})
+	assert.NoError(t, err)
+
+	sqlMock.ExpectQuery(claimRemindersQuery).
+		WithArgs("2025-03-08", 100, float64(300), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "user_id", "kind", "due_date", "service_name", "price", "currency", "created_at"}))
+	claimed, err = repo.ClaimReminders(context.Background(), "2025-03-08", 100, 5*time.Minute, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
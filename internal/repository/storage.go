@@ -8,16 +8,19 @@ import (
 	"go.uber.org/zap"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Storage представляет слой доступа к данным PostgreSQL
 type Storage struct {
-	db  *sql.DB
-	log *zap.Logger
+	db           *sql.DB
+	log          *zap.Logger
+	queryTimeout time.Duration
 }
 
-// NewStorage создает новый экземпляр репозитория
-func NewStorage(user string, password string, host string, port string, dbname string, sslmode string, log *zap.Logger) (*Storage, error) {
+// NewStorage создает новый экземпляр репозитория.
+// queryTimeout ограничивает время выполнения каждого запроса (0 — без ограничения).
+func NewStorage(user string, password string, host string, port string, dbname string, sslmode string, queryTimeout time.Duration, log *zap.Logger) (*Storage, error) {
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", user, password, host, port, dbname, sslmode)
 	log = log.With(zap.String("type", "Storage"))
 
@@ -49,8 +52,9 @@ func NewStorage(user string, password string, host string, port string, dbname s
 	}
 	log.Info("Successfully migrated database")
 	return &Storage{
-		db:  db,
-		log: log,
+		db:           db,
+		log:          log,
+		queryTimeout: queryTimeout,
 	}, nil
 }
 
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// userColumns are the columns of the users table in the order scanned by scanUser.
+// A missing email or budget is stored as NULL and read as the zero value.
+const userColumns = `id, display_name, COALESCE(email, ''), currency, timezone, COALESCE(monthly_budget, 0)`
+
+// CreateUser inserts the user.
+// Returns service.ErrConflict if the ID or the email is taken.
+func (r *Repository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Creating user", zap.String("id", user.ID.String()))
+
+	query := `
+		INSERT INTO users (id, display_name, email, currency, timezone, monthly_budget)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6::bigint, 0))
+	`
+	if _, err := r.db.ExecContext(ctx, query, user.ID, user.DisplayName, user.Email, user.Currency, user.Timezone, user.MonthlyBudget); err != nil {
+		r.log.Error("Error creating user", zap.Error(err))
+		return fmt.Errorf("failed to create user: %w", mapError(err))
+	}
+	return nil
+}
+
+// UpdateUser replaces the user with the ID of user.
+// Returns service.ErrNotFound if the user does not exist, or service.ErrConflict if the email is taken.
+func (r *Repository) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Updating user", zap.String("id", user.ID.String()))
+
+	query := `
+		UPDATE users
+		SET
+			display_name = $2,
+			email = NULLIF($3, ''),
+			currency = $4,
+			timezone = $5,
+			monthly_budget = NULLIF($6::bigint, 0)
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, user.ID, user.DisplayName, user.Email, user.Currency, user.Timezone, user.MonthlyBudget)
+	if err != nil {
+		r.log.Error("Error updating user", zap.Error(err))
+		return fmt.Errorf("failed to update user: %w", mapError(err))
+	}
+	return r.checkUserAffected(result, user.ID)
+}
+
+// DeleteUser removes the user.
+// Returns service.ErrNotFound if the user does not exist, or service.ErrConflict
+// if the user still has subscriptions.
+func (r *Repository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Deleting user", zap.String("id", id.String()))
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		err = mapError(err)
+		// The foreign key of subscriptions is the only constraint a deletion can violate.
+		if errors.Is(err, service.ErrConstraintViolation) {
+			r.log.Debug("User has subscriptions", zap.String("id", id.String()))
+			return fmt.Errorf("user %s has subscriptions: %w", id, service.ErrConflict)
+		}
+		r.log.Error("Error deleting user", zap.Error(err))
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return r.checkUserAffected(result, id)
+}
+
+// GetUser returns the user with the ID, or service.ErrNotFound.
+func (r *Repository) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Getting user", zap.String("id", id.String()))
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		r.log.Debug("User not found", zap.String("id", id.String()))
+		return nil, fmt.Errorf("user %w", service.ErrNotFound)
+	}
+	if err != nil {
+		r.log.Error("Error getting user", zap.Error(err))
+		return nil, fmt.Errorf("failed to get user: %w", mapError(err))
+	}
+	return user, nil
+}
+
+// ListUsers returns all users ordered by display name.
+func (r *Repository) ListUsers(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	r.log.Debug("Listing users")
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY display_name, id`)
+	if err != nil {
+		r.log.Error("Error listing users", zap.Error(err))
+		return nil, fmt.Errorf("failed to query users: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			r.log.Error("failed to scan user", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("error iterating over user rows", zap.Error(err))
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+	return users, nil
+}
+
+// scanUser scans the userColumns of a row.
+func scanUser(row interface{ Scan(dest ...any) error }) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(&user.ID, &user.DisplayName, &user.Email, &user.Currency, &user.Timezone, &user.MonthlyBudget); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// checkUserAffected reports service.ErrNotFound when a statement targeting a single user changed no rows.
+func (r *Repository) checkUserAffected(result sql.Result, id uuid.UUID) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		r.log.Debug("User not found", zap.String("id", id.String()))
+		return fmt.Errorf("user %w", service.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var userRows = []string{"id", "display_name", "email", "currency", "timezone", "monthly_budget"}
+
+func TestCreateUser(t *testing.T) {
+	user := &models.User{ID: uuid.New(), DisplayName: "Ivan", Email: "ivan@example.com", Currency: "RUB", Timezone: "Europe/Moscow", MonthlyBudget: 150000}
+	query := "INSERT INTO users (id, display_name, email, currency, timezone, monthly_budget) VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6::bigint, 0))"
+	args := []driver.Value{user.ID, "Ivan", "ivan@example.com", "RUB", "Europe/Moscow", 150000}
+
+	// Test case 1: User created
+	sqlMock.ExpectExec(query).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.CreateUser(context.Background(), user))
+
+	// Test case 2: Email taken by another user
+	sqlMock.ExpectExec(query).WithArgs(args...).WillReturnError(&pq.Error{Code: "23505"})
+	assert.ErrorIs(t, repo.CreateUser(context.Background(), user), service.ErrConflict)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUpdateUser(t *testing.T) {
+	user := &models.User{ID: uuid.New(), DisplayName: "Ivan", Currency: "USD", Timezone: "UTC"}
+	query := "UPDATE users SET display_name = $2, email = NULLIF($3, ''), currency = $4, timezone = $5, monthly_budget = NULLIF($6::bigint, 0) WHERE id = $1"
+	args := []driver.Value{user.ID, "Ivan", "", "USD", "UTC", 0}
+
+	// Test case 1: User updated
+	sqlMock.ExpectExec(query).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.UpdateUser(context.Background(), user))
+
+	// Test case 2: User not found
+	sqlMock.ExpectExec(query).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.UpdateUser(context.Background(), user), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDeleteUser(t *testing.T) {
+	id := uuid.New()
+	query := "DELETE FROM users WHERE id = $1"
+
+	// Test case 1: User deleted
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.DeleteUser(context.Background(), id))
+
+	// Test case 2: User still has subscriptions
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnError(&pq.Error{Code: "23503"})
+	assert.ErrorIs(t, repo.DeleteUser(context.Background(), id), service.ErrConflict)
+
+	// Test case 3: User not found
+	sqlMock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.DeleteUser(context.Background(), id), service.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetAndListUsers(t *testing.T) {
+	user := models.User{ID: uuid.New(), DisplayName: "Ivan", Email: "ivan@example.com", Currency: "RUB", Timezone: "Europe/Moscow", MonthlyBudget: 150000}
+	unnamed := models.User{ID: uuid.New(), Currency: "RUB", Timezone: "UTC"}
+	getQuery := "SELECT id, display_name, COALESCE(email, ''), currency, timezone, COALESCE(monthly_budget, 0) FROM users WHERE id = $1"
+
+	// Test case 1: User found
+	sqlMock.ExpectQuery(getQuery).WithArgs(user.ID).
+		WillReturnRows(sqlmock.NewRows(userRows).AddRow(user.ID, user.DisplayName, user.Email, user.Currency, user.Timezone, user.MonthlyBudget))
+	found, err := repo.GetUser(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user, *found)
+
+	// Test case 2: User not found
+	sqlMock.ExpectQuery(getQuery).WithArgs(user.ID).WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetUser(context.Background(), user.ID)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+
+	// Test case 3: Users registered with their subscriptions have no name
+	sqlMock.ExpectQuery("SELECT id, display_name, COALESCE(email, ''), currency, timezone, COALESCE(monthly_budget, 0) FROM users ORDER BY display_name, id").
+		WillReturnRows(sqlmock.NewRows(userRows).
+			AddRow(unnamed.ID, "", "", "RUB", "UTC", 0).
+			AddRow(user.ID, user.DisplayName, user.Email, user.Currency, user.Timezone, user.MonthlyBudget))
+	users, err := repo.ListUsers(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.User{unnamed, user}, users)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
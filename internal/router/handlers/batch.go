@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+)
+
+// errNotApplied is reported for the items of an atomic batch that was rolled back because of another item.
+const errNotApplied = "not applied: another item of the atomic batch failed"
+
+// BatchCreateSubs handles the creation of several subscriptions in one request.
+// Every item is validated like a single subscription; the report lists the outcome of each item.
+// @Summary Создать несколько подписок
+// @Description Создает подписки из массива в одной транзакции и возвращает результат по каждому элементу.
+// @Description В режиме atomic при ошибке любого элемента не создается ни одна подписка, в режиме best_effort создаются все корректные.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим: atomic (по умолчанию) или best_effort"
+// @Param subscriptions body []models.SubReq true "Данные подписок"
+// @Success 200 {object} models.Response{data=models.BatchReport}
+// @Success 207 {object} models.Response{data=models.BatchReport}
+// @Failure 400 {object} models.Response
+// @Failure 409 {object} models.Response{data=models.BatchReport}
+// @Failure 422 {object} models.Response{data=models.BatchReport}
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/batch [post]
+func (h *SubscriptionHandler) BatchCreateSubs(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling batch create subscriptions")
+	var reqs []models.SubReq
+	report, ok := h.decodeBatch(w, r, log, &reqs, func() int { return len(reqs) })
+	if !ok {
+		return
+	}
+
+	subs := make([]*models.Subscription, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i := range reqs {
+		sub, err := h.validateSubReq(&reqs[i])
+		if err != nil {
+			report.Items[i].Status = http.StatusBadRequest
+			report.Items[i].Error = fmt.Sprintf("Invalid request body: %s", err)
+			continue
+		}
+		sub.ID = uuid.New()
+		report.Items[i].ID = &sub.ID
+		subs = append(subs, sub)
+		indexes = append(indexes, i)
+	}
+
+	h.processBatch(w, r, log, report, indexes, "Failed to create subscription",
+		func(ctx context.Context, atomic bool) ([]error, error) {
+			return h.service.BatchCreateSubs(ctx, subs, atomic)
+		},
+		func(k int, item *models.BatchItemResult) { item.Version = subs[k].Version },
+	)
+}
+
+// BatchUpdateSubs handles the update of several subscriptions in one request.
+// Every item carries the ID and the version of the subscription, which plays the role of If-Match:
+// an item whose subscription has been changed since is rejected with 412.
+// @Summary Обновить несколько подписок
+// @Description Обновляет подписки из массива в одной транзакции и возвращает результат по каждому элементу.
+// @Description Каждый элемент содержит id и version подписки (значение ETag); при несовпадении версии элемент отклоняется с кодом 412.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим: atomic (по умолчанию) или best_effort"
+// @Param subscriptions body []models.SubUpdateReq true "Новые данные подписок"
+// @Success 200 {object} models.Response{data=models.BatchReport}
+// @Success 207 {object} models.Response{data=models.BatchReport}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response{data=models.BatchReport}
+// @Failure 412 {object} models.Response{data=models.BatchReport}
+// @Failure 422 {object} models.Response{data=models.BatchReport}
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/batch [put]
+func (h *SubscriptionHandler) BatchUpdateSubs(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling batch update subscriptions")
+	var reqs []models.SubUpdateReq
+	report, ok := h.decodeBatch(w, r, log, &reqs, func() int { return len(reqs) })
+	if !ok {
+		return
+	}
+
+	subs := make([]*models.Subscription, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i := range reqs {
+		if reqs[i].ID == uuid.Nil {
+			report.Items[i].Status = http.StatusBadRequest
+			report.Items[i].Error = "Missing id"
+			continue
+		}
+		report.Items[i].ID = &reqs[i].ID
+		if reqs[i].Version <= 0 {
+			report.Items[i].Status = http.StatusPreconditionRequired
+			report.Items[i].Error = "version is required"
+			continue
+		}
+		sub, err := h.validateSubReq(&reqs[i].SubReq)
+		if err != nil {
+			report.Items[i].Status = http.StatusBadRequest
+			report.Items[i].Error = fmt.Sprintf("Invalid request body: %s", err)
+			continue
+		}
+		sub.ID, sub.Version = reqs[i].ID, reqs[i].Version
+		subs = append(subs, sub)
+		indexes = append(indexes, i)
+	}
+
+	h.processBatch(w, r, log, report, indexes, "Failed to update subscription",
+		func(ctx context.Context, atomic bool) ([]error, error) {
+			return h.service.BatchUpdateSubs(ctx, subs, atomic)
+		},
+		func(k int, item *models.BatchItemResult) { item.Version = subs[k].Version },
+	)
+}
+
+// BatchDeleteSubs handles the deletion of several subscriptions in one request.
+// @Summary Удалить несколько подписок
+// @Description Удаляет подписки с идентификаторами из массива в одной транзакции и возвращает результат по каждому элементу.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param mode query string false "Режим: atomic (по умолчанию) или best_effort"
+// @Param ids body []string true "ID подписок"
+// @Success 200 {object} models.Response{data=models.BatchReport}
+// @Success 207 {object} models.Response{data=models.BatchReport}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response{data=models.BatchReport}
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/batch [delete]
+func (h *SubscriptionHandler) BatchDeleteSubs(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling batch delete subscriptions")
+	var ids []uuid.UUID
+	report, ok := h.decodeBatch(w, r, log, &ids, func() int { return len(ids) })
+	if !ok {
+		return
+	}
+
+	indexes := make([]int, len(ids))
+	for i := range ids {
+		report.Items[i].ID = &ids[i]
+		indexes[i] = i
+	}
+
+	h.processBatch(w, r, log, report, indexes, "Failed to delete subscription",
+		func(ctx context.Context, atomic bool) ([]error, error) {
+			return h.service.BatchDeleteSubs(ctx, ids, atomic)
+		},
+		func(int, *models.BatchItemResult) {},
+	)
+}
+
+// decodeBatch parses the batch mode and decodes the array of items from the request body into dst.
+// It returns a report with an entry for each of the count() items, or false if the response
+// has already been sent because the request is invalid.
+func (h *SubscriptionHandler) decodeBatch(w http.ResponseWriter, r *http.Request, log *zap.Logger, dst any, count func() int) (*models.BatchReport, bool) {
+	mode, err := parseBatchMode(r.URL.Query())
+	if err != nil {
+		log.Warn("Invalid batch mode", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid query parameters: %s", err), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	n := count()
+	if n == 0 || n > service.MaxBatchSize {
+		log.Warn("Invalid batch size", zap.Int("size", n))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: batch must contain from 1 to %d items", service.MaxBatchSize), http.StatusBadRequest)
+		return nil, false
+	}
+
+	report := &models.BatchReport{Mode: mode, Items: make([]models.BatchItemResult, n)}
+	for i := range report.Items {
+		report.Items[i].Index = i
+	}
+	return report, true
+}
+
+// processBatch runs the valid items of the batch, given by their indexes in the request,
+// and sends the report. Items that failed validation already have a status in the report.
+// An atomic batch with an invalid item is not run at all. The response status is 200 if every
+// item succeeded; otherwise it is 207 for a best-effort batch and the status of the first failed
+// item for an atomic one, whose other items are then reported as not applied.
+func (h *SubscriptionHandler) processBatch(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *zap.Logger,
+	report *models.BatchReport,
+	indexes []int,
+	message string,
+	run func(ctx context.Context, atomic bool) ([]error, error),
+	applied func(k int, item *models.BatchItemResult),
+) {
+	atomic := report.Mode == models.BatchModeAtomic
+	valid := len(indexes) == len(report.Items)
+
+	if len(indexes) > 0 && (valid || !atomic) {
+		errs, err := run(r.Context(), atomic)
+		if err != nil {
+			log.Warn("Batch failed", zap.Error(err))
+			h.sendError(w, err, message)
+			return
+		}
+		for k, i := range indexes {
+			if errs[k] != nil {
+				report.Items[i].Status, report.Items[i].Error = h.errorStatus(errs[k], message)
+				continue
+			}
+			report.Items[i].Status = http.StatusOK
+			applied(k, &report.Items[i])
+		}
+	}
+
+	status := http.StatusOK
+	for i := range report.Items {
+		if report.Items[i].Error != "" && status == http.StatusOK {
+			status = report.Items[i].Status
+		}
+	}
+	if atomic && status != http.StatusOK {
+		// Nothing was committed: items that succeeded or were never run are rolled back.
+		for i := range report.Items {
+			if report.Items[i].Error == "" {
+				report.Items[i].Status = http.StatusFailedDependency
+				report.Items[i].Error = errNotApplied
+				report.Items[i].Version = 0
+			}
+		}
+	}
+
+	for _, item := range report.Items {
+		if item.Error == "" {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	switch {
+	case report.Failed == 0:
+		log.Info("Successfully processed batch", zap.Int("items", len(report.Items)))
+		h.sendResponse(w, report, "Successfully processed batch", http.StatusOK)
+	case atomic:
+		log.Warn("Atomic batch rolled back", zap.Int("failed", report.Failed))
+		h.sendResponse(w, report, "Batch rolled back", status)
+	default:
+		log.Warn("Batch partially processed", zap.Int("failed", report.Failed))
+		h.sendResponse(w, report, "Batch partially processed", http.StatusMultiStatus)
+	}
+}
+
+// parseBatchMode returns the batch mode from the 'mode' query parameter, atomic by default.
+func parseBatchMode(query url.Values) (string, error) {
+	switch mode := query.Get("mode"); mode {
+	case "":
+		return models.BatchModeAtomic, nil
+	case models.BatchModeAtomic, models.BatchModeBestEffort:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported mode %q", mode)
+	}
+}
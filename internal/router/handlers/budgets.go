@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// ListBudgets handles listing the budgets of a user.
+// @Summary Бюджеты пользователя
+// @Description Возвращает месячные бюджеты пользователя: общий бюджет (monthly_budget пользователя) с пустой категорией,
+// @Description если он задан, и бюджеты категорий, упорядоченные по категории.
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.Response{data=[]models.Budget}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id}/budgets [get]
+func (h *SubscriptionHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling list budgets")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	budgets, err := h.service.ListBudgets(r.Context(), id)
+	if err != nil {
+		log.Warn("Failed to list budgets", zap.Error(err))
+		h.sendUserError(w, err, "Failed to list budgets")
+		return
+	}
+	log.Info("Successfully listed budgets", zap.Int("count", len(budgets)))
+	h.sendResponse(w, budgets, "Successfully listed budgets", http.StatusOK)
+}
+
+// SetBudget handles setting the budget of a category of a user.
+// @Summary Установить бюджет категории
+// @Description Устанавливает месячный бюджет на подписки категории, заменяя ранее заданный. Сумма передается десятичной строкой
+// @Description в валюте бюджета (по умолчанию RUB). Общий бюджет пользователя задается полем monthly_budget пользователя.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param category path string true "Категория подписок"
+// @Param budget body models.BudgetReq true "Бюджет"
+// @Success 200 {object} models.Response{data=models.Budget}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id}/budgets/{category} [put]
+func (h *SubscriptionHandler) SetBudget(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling set budget")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	var req models.BudgetReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	budget, err := service.ValidateBudgetReq(r.PathValue("category"), &req)
+	if err != nil {
+		log.Warn("Invalid budget", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+	budget.UserID = id
+
+	if err := h.service.SetBudget(r.Context(), budget); err != nil {
+		log.Warn("Failed to set budget", zap.Error(err))
+		h.sendUserError(w, err, "Failed to set budget")
+		return
+	}
+	log.Info("Successfully set budget", zap.String("user_id", id.String()), zap.String("category", budget.Category))
+	h.sendResponse(w, budget, "Successfully set budget", http.StatusOK)
+}
+
+// DeleteBudget handles removing the budget of a category of a user.
+// @Summary Удалить бюджет категории
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param category path string true "Категория подписок"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id}/budgets/{category} [delete]
+func (h *SubscriptionHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling delete budget")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteBudget(r.Context(), id, r.PathValue("category")); err != nil {
+		log.Warn("Failed to delete budget", zap.Error(err))
+		if errors.Is(err, service.ErrNotFound) {
+			h.sendResponse(w, nil, "Budget not found", http.StatusNotFound)
+			return
+		}
+		h.sendError(w, err, "Failed to delete budget")
+		return
+	}
+	log.Info("Successfully deleted budget", zap.String("user_id", id.String()))
+	h.sendResponse(w, nil, "Successfully deleted budget", http.StatusOK)
+}
+
+// ListAlerts handles listing budget alerts.
+// @Summary Превышения бюджетов
+// @Description Возвращает превышения месячных бюджетов, начиная с последних. Бюджет проверяется при создании, обновлении,
+// @Description возобновлении и импорте подписок пользователя и дает не более одного превышения в месяц;
+// @Description о каждом превышении в фоне отправляется событие budget.exceeded, если настроен способ доставки.
+// @Tags budgets
+// @Produce json
+// @Param user_id query string false "ID пользователя"
+// @Param month query string false "Месяц в формате MM-YYYY"
+// @Success 200 {object} models.Response{data=[]models.BudgetAlert}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/alerts [get]
+func (h *SubscriptionHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling list budget alerts")
+
+	var filter models.AlertFilter
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			log.Warn("Invalid user_id parameter", zap.String("user_id", raw))
+			h.sendResponse(w, nil, "Invalid user_id parameter", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = &userID
+	}
+	if filter.Month = r.URL.Query().Get("month"); filter.Month != "" {
+		if _, err := time.Parse("01-2006", filter.Month); err != nil {
+			log.Warn("Invalid month parameter", zap.String("month", filter.Month))
+			h.sendResponse(w, nil, "Invalid month parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	alerts, err := h.service.ListAlerts(r.Context(), filter)
+	if err != nil {
+		log.Warn("Failed to list budget alerts", zap.Error(err))
+		h.sendError(w, err, "Failed to list budget alerts")
+		return
+	}
+	log.Info("Successfully listed budget alerts", zap.Int("count", len(alerts)))
+	h.sendResponse(w, alerts, "Successfully listed budget alerts", http.StatusOK)
+}
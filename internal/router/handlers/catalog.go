@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// ListServices handles listing the service catalog.
+// @Summary Список сервисов
+// @Description Возвращает сервисы каталога, упорядоченные по названию.
+// @Tags services
+// @Produce json
+// @Param category query string false "Категория сервиса"
+// @Success 200 {object} models.Response{data=[]models.Service}
+// @Failure 500 {object} models.Response
+// @Router /api/v1/services [get]
+func (h *SubscriptionHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling list services")
+
+	services, err := h.service.ListServices(r.Context(), r.URL.Query().Get("category"))
+	if err != nil {
+		log.Warn("Failed to list services", zap.Error(err))
+		h.sendError(w, err, "Failed to list services")
+		return
+	}
+	log.Info("Successfully listed services", zap.Int("count", len(services)))
+	h.sendResponse(w, services, "Successfully listed services", http.StatusOK)
+}
+
+// CreateService handles adding a service to the catalog.
+// @Summary Создать сервис
+// @Description Добавляет сервис в каталог. Slug — название в нижнем регистре, в котором все символы, кроме букв и цифр, заменены дефисом;
+// @Description названия, отличающиеся только регистром, пробелами или знаками препинания, относятся к одному сервису.
+// @Description Цена по умолчанию передается десятичной строкой в валюте сервиса.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body models.ServiceReq true "Данные сервиса"
+// @Success 200 {object} models.Response{data=models.Service}
+// @Failure 400 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/services [post]
+func (h *SubscriptionHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling create service")
+
+	svc, ok := h.decodeServiceReq(w, log, r)
+	if !ok {
+		return
+	}
+	svc.ID = uuid.New()
+
+	if err := h.service.CreateService(r.Context(), svc); err != nil {
+		log.Warn("Failed to create service", zap.Error(err))
+		h.sendServiceError(w, err, "Failed to create service")
+		return
+	}
+	log.Info("Successfully created service", zap.String("id", svc.ID.String()))
+	h.sendResponse(w, svc, "Successfully created service", http.StatusOK)
+}
+
+// GetService handles retrieving a catalog service by its ID.
+// @Summary Получить сервис по ID
+// @Tags services
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 200 {object} models.Response{data=models.Service}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/services/{id} [get]
+func (h *SubscriptionHandler) GetService(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling get service")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	svc, err := h.service.GetService(r.Context(), id)
+	if err != nil {
+		log.Warn("Failed to get service", zap.Error(err))
+		h.sendServiceError(w, err, "Failed to get service")
+		return
+	}
+	log.Info("Successfully got service", zap.String("id", id.String()))
+	h.sendResponse(w, svc, "Successfully got service", http.StatusOK)
+}
+
+// UpdateService handles replacing a catalog service.
+// @Summary Обновить сервис
+// @Description Заменяет данные сервиса каталога. Новое название сервиса переносится в его подписки, версии которых при этом меняются.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param service body models.ServiceReq true "Данные сервиса"
+// @Success 200 {object} models.Response{data=models.Service}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/services/{id} [put]
+func (h *SubscriptionHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling update service")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	svc, ok := h.decodeServiceReq(w, log, r)
+	if !ok {
+		return
+	}
+	svc.ID = id
+
+	if err := h.service.UpdateService(r.Context(), svc); err != nil {
+		log.Warn("Failed to update service", zap.Error(err))
+		h.sendServiceError(w, err, "Failed to update service")
+		return
+	}
+	log.Info("Successfully updated service", zap.String("id", id.String()))
+	h.sendResponse(w, svc, "Successfully updated service", http.StatusOK)
+}
+
+// DeleteService handles removing a service from the catalog.
+// @Summary Удалить сервис
+// @Description Удаляет сервис из каталога. Сервис, на который ссылаются подписки, удалить нельзя (409).
+// @Tags services
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/services/{id} [delete]
+func (h *SubscriptionHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling delete service")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteService(r.Context(), id); err != nil {
+		log.Warn("Failed to delete service", zap.Error(err))
+		h.sendServiceError(w, err, "Failed to delete service")
+		return
+	}
+	log.Info("Successfully deleted service", zap.String("id", id.String()))
+	h.sendResponse(w, nil, "Successfully deleted service", http.StatusOK)
+}
+
+// pathID parses the ID of the catalog service or user from the request path.
+// On failure it sends a 400 response and returns false.
+func (h *SubscriptionHandler) pathID(w http.ResponseWriter, log *zap.Logger, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		log.Warn("Invalid id parameter", zap.String("id", r.PathValue("id")))
+		h.sendResponse(w, nil, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// decodeServiceReq decodes and validates the service described by the request body.
+// On failure it sends a 400 response and returns false.
+func (h *SubscriptionHandler) decodeServiceReq(w http.ResponseWriter, log *zap.Logger, r *http.Request) (*models.Service, bool) {
+	var req models.ServiceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	svc, err := service.ValidateServiceReq(&req)
+	if err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return nil, false
+	}
+	return svc, true
+}
+
+// sendServiceError sends the error of a catalog operation; see sendError.
+func (h *SubscriptionHandler) sendServiceError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrNotFound) {
+		h.sendResponse(w, nil, "Service not found", http.StatusNotFound)
+		return
+	}
+	h.sendError(w, err, message)
+}
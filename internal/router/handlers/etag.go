@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errMissingIfMatch is returned by parseIfMatch when the request has no If-Match header.
+var errMissingIfMatch = errors.New("missing If-Match header")
+
+// setETag sets the ETag header of the response to the subscription version.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// parseIfMatch returns the subscription version from the If-Match header of the request.
+// Only a single strong ETag, as sent by setETag, is accepted: weak tags and "*" cannot
+// guarantee that the client has seen the current state of the subscription.
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errMissingIfMatch
+	}
+
+	tag, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, errors.New("If-Match must be a single quoted ETag")
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, errors.New("If-Match does not contain a subscription ETag")
+	}
+	return version, nil
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/export"
+	"Effective_Mobile/internal/models"
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFormat returns the spreadsheet format requested in the Accept header,
+// or an empty string if the client prefers JSON or does not state a preference.
+// Of several acceptable types the one with the highest quality wins, JSON on a tie.
+func exportFormat(r *http.Request) string {
+	format, best := "", 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		var candidate string
+		switch mediaType {
+		case export.ContentTypeCSV:
+			candidate = export.FormatCSV
+		case export.ContentTypeXLSX:
+			candidate = export.FormatXLSX
+		case "application/json", "application/*", "*/*":
+			candidate = ""
+		default:
+			continue
+		}
+		if quality > best || (quality == best && candidate == "") {
+			format, best = candidate, quality
+		}
+	}
+	return format
+}
+
+// startExport sends the response headers of a spreadsheet download named after name and
+// writes the header row. Nothing is sent before the first row is ready, so errors
+// that occur earlier can still be reported with a regular error response.
+func (h *SubscriptionHandler) startExport(w http.ResponseWriter, format string, name string, columns ...any) (export.Writer, error) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		return nil, err
+	}
+	return writer, writer.WriteRow(columns...)
+}
+
+// subscriptionColumns are the header cells of a subscription export.
+var subscriptionColumns = []any{"id", "service_id", "service_name", "price", "currency", "billing_interval", "interval_count", "user_id",
+	"start_date", "end_date", "trial_end_date", "status", "category", "tags"}
+
+// exportSubs streams the subscriptions matching the filter to the client as a spreadsheet.
+// An error after the download has started cannot be reported in the response, so the connection
+// is aborted instead, which tells the client that the file is incomplete.
+func (h *SubscriptionHandler) exportSubs(w http.ResponseWriter, r *http.Request, log *zap.Logger, format string, filter models.SubscriptionFilter, params models.ListParams) {
+	log.Info("Exporting subscriptions", zap.String("format", format))
+
+	var writer export.Writer
+	count := 0
+	err := h.service.ExportSubs(r.Context(), filter, params, func(sub *models.Subscription) error {
+		if writer == nil {
+			var err error
+			if writer, err = h.startExport(w, format, "subscriptions", subscriptionColumns...); err != nil {
+				return err
+			}
+		}
+		count++
+		sub = wireSubscription(r, sub)
+		return writer.WriteRow(sub.ID, sub.ServiceID, sub.ServiceName, export.Number(models.FormatAmount(sub.Price, sub.Currency)), sub.Currency,
+			sub.BillingInterval, sub.IntervalCount, sub.UserID, sub.StartDate, sub.EndDate, sub.TrialEndDate, sub.Status, sub.Category, strings.Join(sub.Tags, ","))
+	})
+	if err == nil && writer == nil {
+		// No subscriptions match: the file only has the header row.
+		writer, err = h.startExport(w, format, "subscriptions", subscriptionColumns...)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.failExport(w, log, writer != nil, err, "Failed to export subscriptions")
+		return
+	}
+	log.Info("Successfully exported subscriptions", zap.Int("count", count))
+}
+
+// exportSummary sends the total cost of a summary request as a spreadsheet with a single data row.
+func (h *SubscriptionHandler) exportSummary(w http.ResponseWriter, log *zap.Logger, format string, req *models.GetSummaryReq, total models.Decimal) {
+	var userID any
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+	// Both ends of the period are optional; a missing end leaves its cell empty.
+	month := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t.Format("01-2006")
+	}
+	writer, err := h.startExport(w, format, "summary", "from", "to", "user_id", "service_name", "total", "currency")
+	if err == nil {
+		err = writer.WriteRow(month(req.From), month(req.To), userID, req.ServiceName, export.Number(total), req.Currency)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.failExport(w, log, true, err, "Failed to export summary")
+	}
+}
+
+// exportBreakdown sends the monthly costs as a spreadsheet: one row per month,
+// or per group and month if the costs are grouped.
+func (h *SubscriptionHandler) exportBreakdown(w http.ResponseWriter, log *zap.Logger, format string, groupBy string, breakdown []models.MonthlyCost) {
+	columns := []any{"month", "total"}
+	if groupBy != "" {
+		columns = []any{"month", groupBy, "total"}
+	}
+	writer, err := h.startExport(w, format, "summary_monthly", columns...)
+	for i := 0; err == nil && i < len(breakdown); i++ {
+		month := breakdown[i]
+		if groupBy == "" {
+			err = writer.WriteRow(month.Month, export.Number(month.Total))
+			continue
+		}
+		for _, group := range month.Groups {
+			if err = writer.WriteRow(month.Month, group.Key, export.Number(group.Total)); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.failExport(w, log, true, err, "Failed to export breakdown")
+	}
+}
+
+// exportGroupedSummary sends the cost of every group as a spreadsheet, one row per group.
+func (h *SubscriptionHandler) exportGroupedSummary(w http.ResponseWriter, log *zap.Logger, format string, groupBy string, summary *models.GroupedSummary) {
+	writer, err := h.startExport(w, format, "summary_grouped", groupBy, "total", "currency")
+	for i := 0; err == nil && i < len(summary.Groups); i++ {
+		group := summary.Groups[i]
+		err = writer.WriteRow(group.Key, export.Number(group.Total), summary.Currency)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.failExport(w, log, true, err, "Failed to export grouped summary")
+	}
+}
+
+// failExport reports an export error: with a regular error response if the download
+// has not started yet, otherwise by aborting the connection.
+func (h *SubscriptionHandler) failExport(w http.ResponseWriter, log *zap.Logger, started bool, err error, message string) {
+	log.Error(message, zap.Error(err))
+	if !started {
+		h.sendError(w, err, message)
+		return
+	}
+	// http.ErrAbortHandler makes the server drop the connection without logging a stack trace.
+	panic(http.ErrAbortHandler)
+}
@@ -2,24 +2,68 @@ package handlers
 
 import (
 	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type subscriptionService interface {
-	CreateSubs(subs *models.Subscription) error
-	UpdateSubs(id uuid.UUID, newSubs *models.Subscription) error
-	DeleteSubs(id uuid.UUID) error
-	ListSubs(filter models.SubscriptionFilter) ([]models.Subscription, error)
-	GetSummary(sum *models.GetSummaryReq) (int, error)
-	GetSub(id uuid.UUID) (*models.Subscription, error)
-	SubscriptionExists(id uuid.UUID) (bool, error)
+	CreateSubs(ctx context.Context, subs *models.Subscription) error
+	UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) error
+	DeleteSubs(ctx context.Context, id uuid.UUID) error
+	BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error)
+	BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error)
+	BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error)
+	ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) (*models.SubsPage, error)
+	ExportSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error
+	GetSummary(ctx context.Context, sum *models.GetSummaryReq) (models.Decimal, error)
+	GetBreakdown(ctx context.Context, req *models.GetBreakdownReq) ([]models.MonthlyCost, error)
+	GetGroupedSummary(ctx context.Context, req *models.GetGroupedSummaryReq) (*models.GroupedSummary, error)
+	GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	PauseSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error)
+	ResumeSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error)
+	CancelSubs(ctx context.Context, id uuid.UUID, version int, mode string) (*models.Subscription, error)
+	ReactivateSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error)
+	ImportSubs(ctx context.Context, src service.ImportSource) (*models.ImportReport, error)
+	SetRate(ctx context.Context, rate *models.ExchangeRate) error
+	DeleteRate(ctx context.Context, currency string, month string) error
+	ListRates(ctx context.Context, currency string) ([]models.ExchangeRate, error)
+	CreateService(ctx context.Context, svc *models.Service) error
+	UpdateService(ctx context.Context, svc *models.Service) error
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	GetService(ctx context.Context, id uuid.UUID) (*models.Service, error)
+	ListServices(ctx context.Context, category string) ([]models.Service, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	GetUserOverview(ctx context.Context, id uuid.UUID) (*models.UserOverview, error)
+	SetBudget(ctx context.Context, budget *models.Budget) error
+	DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error
+	ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error)
+	ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error)
+	BeginIdempotentRequest(ctx context.Context, key string, requestHash string) (*models.IdempotencyRecord, error)
+	CompleteIdempotentRequest(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error
+	AbortIdempotentRequest(ctx context.Context, key string) error
 }
+
+// Page size limits of the subscription listing.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 1000
+)
+
 type SubscriptionHandler struct {
 	service subscriptionService
 }
@@ -31,31 +75,56 @@ func NewSubscriptionHandler(service subscriptionService) *SubscriptionHandler {
 }
 
 // CreateSubs handles the creation of a new subscription.
+// Requests with an Idempotency-Key header are processed once; retries with the same key
+// and body receive the stored response instead of creating a duplicate subscription.
 // @Summary Создать новую подписку
-// @Description Создает новую подписку для пользователя
+// @Description Создает новую подписку для пользователя.
+// @Description Цена передается десятичной строкой в валюте подписки, например "149.99"; знаков после запятой не больше, чем у валюты.
+// @Description Подписка оплачивается каждые interval_count (по умолчанию 1) периодов billing_interval: week, month (по умолчанию), quarter или year, начиная с месяца начала.
+// @Description Сервис задается полем service_id из каталога или названием service_name; сервис с тем же slug берется из каталога, а если его нет — добавляется в каталог.
+// @Description При повторе запроса с тем же заголовком Idempotency-Key и телом возвращается сохраненный ответ вместе с заголовком ETag.
+// @Description Пока первый запрос с ключом выполняется, повтор получает 409; если ответ не сохранен за 5 минут, повтор выполняется заново.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности запроса"
 // @Param subscription body models.SubReq true "Данные подписки"
 // @Success 200 {object} models.Response{data=models.Subscription}
 // @Failure 400 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 422 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /subscriptions [post]
+// @Router /api/v1/subscriptions [post]
 func (h *SubscriptionHandler) CreateSubs(w http.ResponseWriter, r *http.Request) {
 	// Retrieve logger from request context. This logger includes request-specific fields.
 	log := r.Context().Value("logger").(*zap.Logger)
 
 	log.Info("Handling create subscription")
+	// The raw body identifies the request for the idempotency check.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Warn("Failed to read request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	h.serveIdempotent(w, r, body, "Failed to create subscription", func(w http.ResponseWriter) {
+		h.createSubs(w, log, r, body)
+	})
+}
+
+// createSubs decodes, validates and creates the subscription described by the request body.
+func (h *SubscriptionHandler) createSubs(w http.ResponseWriter, log *zap.Logger, r *http.Request, body []byte) {
 	var subReq models.SubReq
 	// Decode the JSON request body into a SubReq struct.
-	if err := json.NewDecoder(r.Body).Decode(&subReq); err != nil {
+	if err := json.Unmarshal(body, &subReq); err != nil {
 		log.Warn("Invalid request body", zap.Error(err))
 		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the incoming subscription request data.
-	startDate, endDate, err := h.validateSubReq(&subReq)
+	sub, err := h.validateSubReq(&subReq)
 
 	if err != nil {
 		log.Warn("Invalid request body", zap.Error(err))
@@ -63,46 +132,41 @@ func (h *SubscriptionHandler) CreateSubs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create a new Subscription model with a generated UUID and validated dates.
-	sub := &models.Subscription{
-		ID:          uuid.New(), // Generate a new UUID for the subscription
-		ServiceName: subReq.ServiceName,
-		Price:       subReq.Price,
-		UserID:      subReq.UserID,
-		StartDate:   startDate,
-		EndDate:     endDate,
-	}
+	// Give the new subscription a generated UUID.
+	sub.ID = uuid.New()
 
 	// Call the service layer to create the subscription in the database.
-	if err := h.service.CreateSubs(sub); err != nil {
+	if err := h.service.CreateSubs(r.Context(), sub); err != nil {
 		log.Warn("Failed to create subscription", zap.Error(err))
-		h.sendResponse(w, nil, "Failed to create subscription", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to create subscription")
 		return
 	}
 	log.Info("Successfully created subscription")
 	// Send a success response with the created subscription data.
-	h.sendResponse(w, sub, "Successfully created subscription", http.StatusOK)
+	setETag(w, sub.Version)
+	h.sendResponse(w, wireSubscription(r, sub), "Successfully created subscription", http.StatusOK)
 }
 
 // GetSubs handles retrieving a subscription by its ID.
+// The ETag header of the response holds the subscription version expected by UpdateSubs in If-Match.
 // @Summary Получить подписку по ID
 // @Description Возвращает подписку по её идентификатору
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id query string true "ID подписки"
+// @Param id path string true "ID подписки"
 // @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
 // @Failure 400 {object} models.Response
 // @Failure 404 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /subscriptions [get]
+// @Router /api/v1/subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubs(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value("logger").(*zap.Logger)
 	log.Info("Handling get subscriptions")
-	query := r.URL.Query()
 
-	// Extract the 'id' parameter from the URL query.
-	idStr := query.Get("id")
+	// Extract the 'id' parameter from the URL path or query.
+	idStr := subscriptionID(r)
 	if idStr == "" {
 		log.Warn("Missing id parameter")
 		h.sendResponse(w, nil, "Missing id parameter", http.StatusBadRequest)
@@ -117,41 +181,53 @@ func (h *SubscriptionHandler) GetSubs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the service layer to get the subscription.
-	sub, err := h.service.GetSub(id)
+	sub, err := h.service.GetSub(r.Context(), id)
 	if err != nil {
 		log.Warn("Failed to get subscription", zap.Error(err))
-		// In a real application, differentiate between not found (404) and other errors (500).
-		h.sendResponse(w, nil, "Failed to get subscription", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to get subscription")
 		return
 	}
 
 	log.Info("Successfully get subscription")
 	// Send a success response with the retrieved subscription data.
-	h.sendResponse(w, sub, "Successfully get subscriptions", http.StatusOK)
+	setETag(w, sub.Version)
+	h.sendResponse(w, wireSubscription(r, sub), "Successfully get subscriptions", http.StatusOK)
 
 }
 
 // UpdateSubs handles updating an existing subscription.
+// The If-Match header must contain the ETag returned by GetSubs; if the subscription has been
+// changed since then, the update is rejected with 412 Precondition Failed. The end date of a paused
+// or canceled subscription is changed only by its lifecycle operations, so changing it is a 409 Conflict.
 // @Summary Обновить подписку
-// @Description Обновляет данные существующей подписки
+// @Description Обновляет данные существующей подписки.
+// @Description Заголовок If-Match должен содержать ETag, полученный при чтении подписки; если подписка была изменена, возвращается 412.
+// @Description Новая цена действует с текущего месяца (для еще не начавшейся подписки — с месяца начала); стоимость прошлых месяцев не меняется.
+// @Description Дата окончания меняется только у активной подписки; у приостановленной или отмененной возвращается 409 (для них служат cancel и reactivate).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id query string true "ID подписки"
+// @Param id path string true "ID подписки"
+// @Param If-Match header string true "ETag подписки"
 // @Param subscription body models.SubReq true "Новые данные подписки"
 // @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Новая версия подписки"
 // @Failure 400 {object} models.Response
 // @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 428 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /subscriptions [put]
+// @Router /api/v1/subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubs(w http.ResponseWriter, r *http.Request) {
 
 	log := r.Context().Value("logger").(*zap.Logger)
 
 	log.Info("Handling update subscription")
 
-	// Extract the 'id' parameter from the URL query.
-	idStr := r.URL.Query().Get("id")
+	// Extract the 'id' parameter from the URL path or query.
+	idStr := subscriptionID(r)
 	if idStr == "" {
 		log.Warn("Missing id parameter")
 		h.sendResponse(w, nil, "Missing id parameter", http.StatusBadRequest)
@@ -166,6 +242,19 @@ func (h *SubscriptionHandler) UpdateSubs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The version the client has seen; updates without it could overwrite concurrent changes.
+	version, err := parseIfMatch(r)
+	if errors.Is(err, errMissingIfMatch) {
+		log.Warn("Missing If-Match header")
+		h.sendResponse(w, nil, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+	if err != nil {
+		log.Warn("Invalid If-Match header", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid If-Match header: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	var subReq models.SubReq
 	// Decode the JSON request body into a SubReq struct.
 	if err := json.NewDecoder(r.Body).Decode(&subReq); err != nil {
@@ -175,7 +264,7 @@ func (h *SubscriptionHandler) UpdateSubs(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Validate the incoming subscription request data.
-	startDate, endDate, err := h.validateSubReq(&subReq)
+	sub, err := h.validateSubReq(&subReq)
 
 	if err != nil {
 		log.Warn("Invalid request body", zap.Error(err))
@@ -183,36 +272,139 @@ func (h *SubscriptionHandler) UpdateSubs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create a new Subscription model with a generated UUID and validated dates.
-	// Note: The ID here is a new UUID, but the update operation uses the ID from the URL query.
-	// This might be a point of confusion or potential bug if the intent was to update the existing ID.
-	sub := &models.Subscription{
-		ID:          uuid.New(), // This ID is not used for the update operation, as 'id' from URL is used.
-		ServiceName: subReq.ServiceName,
-		Price:       subReq.Price,
-		UserID:      subReq.UserID,
-		StartDate:   startDate,
-		EndDate:     endDate,
+	// The subscription keeps the ID from the URL.
+	sub.ID = id
+
+	// Call the service layer to update the subscription. The version check and the update
+	// happen atomically, so a missing subscription and a stale version are both reported here.
+	if err := h.service.UpdateSubs(r.Context(), id, version, sub); err != nil {
+		log.Warn("Failed to update subscription", zap.Error(err))
+		h.sendError(w, err, "Failed to update subscription")
+		return
+	}
+
+	log.Info("Successfully updated subscription")
+	// Send a success response with the updated subscription data.
+	setETag(w, sub.Version)
+	h.sendResponse(w, wireSubscription(r, sub), "Successfully updated subscription", http.StatusOK)
+}
+
+// PatchSubs handles a partial update of an existing subscription.
+// The body is a JSON Merge Patch (RFC 7396): only the supplied fields change and an explicit null
+// clears end_date. The patch is applied to the current subscription and the merged result is
+// validated like a full update. As with UpdateSubs, the If-Match header must contain the ETag
+// of the subscription.
+// @Summary Частично обновить подписку
+// @Description Изменяет только переданные поля подписки (JSON Merge Patch, RFC 7396).
+// @Description Значение null в поле end_date удаляет дату окончания. Заголовок If-Match должен содержать ETag подписки.
+// @Description Как и при полном обновлении, дата окончания меняется только у активной подписки.
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string true "ETag подписки"
+// @Param patch body models.SubReq true "Изменяемые поля подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 415 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 428 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/{id} [patch]
+func (h *SubscriptionHandler) PatchSubs(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling patch subscription")
+
+	// Parse the ID from the URL path.
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		log.Warn("Invalid id parameter", zap.String("id", idStr))
+		h.sendResponse(w, nil, "Invalid id format, example xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", http.StatusBadRequest)
+		return
 	}
 
-	// Check if the subscription exists before attempting to update.
-	exists, err := h.service.SubscriptionExists(id)
-	if err != nil || !exists {
-		log.Warn("Subscription does not exist", zap.Error(err))
-		h.sendResponse(w, nil, "Subscription does not exist", http.StatusNotFound)
+	version, err := parseIfMatch(r)
+	if errors.Is(err, errMissingIfMatch) {
+		log.Warn("Missing If-Match header")
+		h.sendResponse(w, nil, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+	if err != nil {
+		log.Warn("Invalid If-Match header", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid If-Match header: %s", err), http.StatusBadRequest)
 		return
 	}
 
-	// Call the service layer to update the subscription.
-	if err := h.service.UpdateSubs(id, sub); err != nil {
+	if !isMergePatch(r) {
+		log.Warn("Unsupported content type", zap.String("contentType", r.Header.Get("Content-Type")))
+		h.sendResponse(w, nil, fmt.Sprintf("Content-Type must be %s", mergePatchContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Warn("Failed to read request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// The patch is applied to the version of the subscription the client has seen.
+	current, err := h.service.GetSub(r.Context(), id)
+	if err != nil {
+		log.Warn("Failed to get subscription", zap.Error(err))
+		h.sendError(w, err, "Failed to update subscription")
+		return
+	}
+	if current.Version != version {
+		log.Warn("Subscription version mismatch", zap.Int("version", current.Version), zap.Int("expected", version))
+		h.sendError(w, fmt.Errorf("subscription version %d: %w", version, service.ErrPreconditionFailed), "Failed to update subscription")
+		return
+	}
+
+	// The service is not part of the base: it is found by its name again unless the patch sets service_id.
+	subReq, err := applyMergePatch(&models.SubReq{
+		ServiceName:     current.ServiceName,
+		Price:           models.FormatAmount(current.Price, current.Currency),
+		Currency:        current.Currency,
+		BillingInterval: current.BillingInterval,
+		IntervalCount:   current.IntervalCount,
+		UserID:          current.UserID,
+		StartDate:       current.StartDate,
+		EndDate:         current.EndDate,
+		TrialEndDate:    current.TrialEndDate,
+		Category:        current.Category,
+		Tags:            current.Tags,
+	}, patch)
+	if err != nil {
+		log.Warn("Invalid patch", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the merged subscription as a whole.
+	sub, err := h.validateSubReq(subReq)
+	if err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+	sub.ID = id
+
+	// The update fails with 412 if the subscription changed after it was read above.
+	if err := h.service.UpdateSubs(r.Context(), id, version, sub); err != nil {
 		log.Warn("Failed to update subscription", zap.Error(err))
-		h.sendResponse(w, nil, "Failed to update subscription", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to update subscription")
 		return
 	}
 
-	log.Info("Successfully updated subscription")
-	// Send a success response with the updated subscription data.
-	h.sendResponse(w, sub, "Successfully updated subscription", http.StatusOK)
+	log.Info("Successfully patched subscription")
+	setETag(w, sub.Version)
+	h.sendResponse(w, wireSubscription(r, sub), "Successfully updated subscription", http.StatusOK)
 }
 
 // DeleteSubs handles deleting a subscription by its ID.
@@ -221,19 +413,19 @@ func (h *SubscriptionHandler) UpdateSubs(w http.ResponseWriter, r *http.Request)
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id query string true "ID подписки"
+// @Param id path string true "ID подписки"
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Failure 404 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /subscriptions [delete]
+// @Router /api/v1/subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubs(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value("logger").(*zap.Logger)
 
 	log.Info("Handling delete subscription")
 
-	// Extract the 'id' parameter from the URL query.
-	idStr := r.URL.Query().Get("id")
+	// Extract the 'id' parameter from the URL path or query.
+	idStr := subscriptionID(r)
 	if idStr == "" {
 		log.Warn("Missing id parameter")
 		h.sendResponse(w, nil, "Missing id parameter", http.StatusBadRequest)
@@ -249,9 +441,9 @@ func (h *SubscriptionHandler) DeleteSubs(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Call the service layer to delete the subscription.
-	if err := h.service.DeleteSubs(id); err != nil {
+	if err := h.service.DeleteSubs(r.Context(), id); err != nil {
 		log.Warn("Failed to delete subscription", zap.Error(err))
-		h.sendResponse(w, nil, "Failed to delete subscription", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to delete subscription")
 		return
 	}
 
@@ -260,18 +452,41 @@ func (h *SubscriptionHandler) DeleteSubs(w http.ResponseWriter, r *http.Request)
 	h.sendResponse(w, nil, "Successfully deleted subscription", http.StatusOK)
 }
 
-// ListSubs handles listing subscriptions with optional filtering by user ID and service name.
+// ListSubs handles listing subscriptions with optional filtering by users, service name, price and dates.
+// Results are paginated with an opaque cursor: the next_cursor of a page is passed as the cursor
+// of the following request together with the same sort parameter.
+// Clients accepting CSV or XLSX receive all matching subscriptions as a streamed file instead.
 // @Summary Получить список подписок
-// @Description Возвращает список подписок с возможностью фильтрации
+// @Description Возвращает страницу подписок с возможностью фильтрации и сортировки.
+// @Description Для получения следующей страницы передайте next_cursor в параметре cursor с тем же sort.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet все подходящие подписки выгружаются файлом CSV или XLSX без постраничной разбивки.
 // @Tags subscriptions
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 // @Param userId query string false "ID пользователя для фильтрации"
 // @Param serviceName query string false "Название сервиса для фильтрации"
-// @Success 200 {object} models.Response{data=[]models.Subscription}
+// @Param userIds query string false "Список ID пользователей через запятую"
+// @Param serviceNamePrefix query string false "Начало названия сервиса (без учета регистра)"
+// @Param currency query string false "Код валюты ISO 4217: только подписки в этой валюте"
+// @Param minPrice query string false "Минимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param maxPrice query string false "Максимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param activeOn query string false "Месяц MM-YYYY, в котором подписка активна"
+// @Param startFrom query string false "Дата начала не раньше MM-YYYY"
+// @Param startTo query string false "Дата начала не позже MM-YYYY"
+// @Param endFrom query string false "Дата окончания не раньше MM-YYYY"
+// @Param endTo query string false "Дата окончания не позже MM-YYYY"
+// @Param openEnded query bool false "Только подписки без даты окончания"
+// @Param category query string false "Категория подписки"
+// @Param tags query string false "Теги через запятую: только подписки со всеми этими тегами"
+// @Param limit query int false "Размер страницы (1-1000, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Поле и направление сортировки: price, start_date, service_name с суффиксом :asc или :desc (по умолчанию start_date:asc)"
+// @Success 200 {object} models.Response{data=models.SubsPage}
 // @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /all-subscriptions [get]
+// @Router /api/v1/subscriptions [get]
 func (h *SubscriptionHandler) ListSubs(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value("logger").(*zap.Logger)
 
@@ -280,7 +495,11 @@ func (h *SubscriptionHandler) ListSubs(w http.ResponseWriter, r *http.Request) {
 	filter := models.SubscriptionFilter{}
 
 	// Extract and parse optional 'userId' and 'serviceName' query parameters.
-	userIdStr := query.Get("userId")
+	// On the per-user route the user comes from the {user_id} path value instead.
+	userIdStr := r.PathValue("user_id")
+	if userIdStr == "" {
+		userIdStr = query.Get("userId")
+	}
 	serviceName := query.Get("serviceName")
 
 	if userIdStr != "" {
@@ -296,30 +515,224 @@ func (h *SubscriptionHandler) ListSubs(w http.ResponseWriter, r *http.Request) {
 		filter.ServiceName = &serviceName
 	}
 
-	// Call the service layer to retrieve the list of subscriptions based on the filter.
-	subs, err := h.service.ListSubs(filter)
+	// Extract the remaining optional filters: price and date ranges, several users, name prefix.
+	if err := h.parseFilter(query, &filter); err != nil {
+		log.Warn("Invalid filter parameters", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid query parameters: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	params, err := h.parseListParams(query)
+	if err != nil {
+		log.Warn("Invalid list parameters", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid query parameters: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Spreadsheet clients get every matching subscription streamed as a file instead of a page.
+	if format := exportFormat(r); format != "" {
+		h.exportSubs(w, r, log, format, filter, params)
+		return
+	}
+
+	// Call the service layer to retrieve the page of subscriptions based on the filter.
+	page, err := h.service.ListSubs(r.Context(), filter, params)
 	if err != nil {
 		log.Warn("Failed to list subs", zap.Error(err))
-		h.sendResponse(w, nil, "Failed to get list subs", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to get list subs")
 		return
 	}
 
-	log.Info("Successfully list subs", zap.Int("count", len(subs)))
-	// Send a success response with the list of subscriptions.
-	h.sendResponse(w, subs, "Successfully get list subs", http.StatusOK)
+	log.Info("Successfully list subs", zap.Int("count", len(page.Items)))
+	// Send a success response with the page of subscriptions.
+	h.sendResponse(w, wirePage(r, page), "Successfully get list subs", http.StatusOK)
+}
+
+// ListUserSubs handles listing the subscriptions of the user given in the URL path.
+// It accepts the same filtering, sorting and pagination parameters as ListSubs.
+// @Summary Получить список подписок пользователя
+// @Description Возвращает страницу подписок пользователя с возможностью фильтрации и сортировки.
+// @Description Для получения следующей страницы передайте next_cursor в параметре cursor с тем же sort.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet все подходящие подписки выгружаются файлом CSV или XLSX без постраничной разбивки.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param user_id path string true "ID пользователя"
+// @Param serviceName query string false "Название сервиса для фильтрации"
+// @Param serviceNamePrefix query string false "Начало названия сервиса (без учета регистра)"
+// @Param currency query string false "Код валюты ISO 4217: только подписки в этой валюте"
+// @Param minPrice query string false "Минимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param maxPrice query string false "Максимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param activeOn query string false "Месяц MM-YYYY, в котором подписка активна"
+// @Param startFrom query string false "Дата начала не раньше MM-YYYY"
+// @Param startTo query string false "Дата начала не позже MM-YYYY"
+// @Param endFrom query string false "Дата окончания не раньше MM-YYYY"
+// @Param endTo query string false "Дата окончания не позже MM-YYYY"
+// @Param openEnded query bool false "Только подписки без даты окончания"
+// @Param category query string false "Категория подписки"
+// @Param tags query string false "Теги через запятую: только подписки со всеми этими тегами"
+// @Param limit query int false "Размер страницы (1-1000, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Поле и направление сортировки: price, start_date, service_name с суффиксом :asc или :desc (по умолчанию start_date:asc)"
+// @Success 200 {object} models.Response{data=models.SubsPage}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{user_id}/subscriptions [get]
+func (h *SubscriptionHandler) ListUserSubs(w http.ResponseWriter, r *http.Request) {
+	h.ListSubs(w, r)
+}
+
+// parseFilter extracts the extended filter query parameters of the listing into filter.
+// Months are expected in MM-YYYY form; 'userIds' and 'tags' are comma-separated lists.
+func (h *SubscriptionHandler) parseFilter(query url.Values, filter *models.SubscriptionFilter) error {
+	if userIdsStr := query.Get("userIds"); userIdsStr != "" {
+		for _, idStr := range strings.Split(userIdsStr, ",") {
+			userId, err := uuid.Parse(strings.TrimSpace(idStr))
+			if err != nil {
+				return errors.New("invalid userIds parameter")
+			}
+			filter.UserIDs = append(filter.UserIDs, userId)
+		}
+	}
+	if prefix := query.Get("serviceNamePrefix"); prefix != "" {
+		filter.ServiceNamePrefix = &prefix
+	}
+
+	// Prices are decimals in the filtered currency, or in the base currency if there is none.
+	currency := models.BaseCurrency
+	if currencyStr := query.Get("currency"); currencyStr != "" {
+		var err error
+		if currency, err = service.ValidateCurrency(currencyStr); err != nil {
+			return errors.New("invalid currency parameter")
+		}
+		filter.Currency = &currency
+	}
+	for _, price := range []struct {
+		name string
+		dst  **int64
+	}{
+		{"minPrice", &filter.MinPrice},
+		{"maxPrice", &filter.MaxPrice},
+	} {
+		priceStr := query.Get(price.name)
+		if priceStr == "" {
+			continue
+		}
+		value, err := models.ParseAmount(models.Decimal(priceStr), currency)
+		if err != nil {
+			return fmt.Errorf("invalid %s parameter", price.name)
+		}
+		*price.dst = &value
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return errors.New("minPrice is greater than maxPrice")
+	}
+
+	for _, month := range []struct {
+		name string
+		dst  **string
+	}{
+		{"activeOn", &filter.ActiveOn},
+		{"startFrom", &filter.StartFrom},
+		{"startTo", &filter.StartTo},
+		{"endFrom", &filter.EndFrom},
+		{"endTo", &filter.EndTo},
+	} {
+		monthStr := query.Get(month.name)
+		if monthStr == "" {
+			continue
+		}
+		parsed, err := time.Parse("01-2006", monthStr)
+		if err != nil {
+			return fmt.Errorf("invalid %s parameter, expected MM-YYYY", month.name)
+		}
+		formatted := parsed.Format("01-2006")
+		*month.dst = &formatted
+	}
+
+	if openEndedStr := query.Get("openEnded"); openEndedStr != "" {
+		openEnded, err := strconv.ParseBool(openEndedStr)
+		if err != nil {
+			return errors.New("invalid openEnded parameter")
+		}
+		filter.OpenEnded = openEnded
+	}
+
+	if category := strings.TrimSpace(query.Get("category")); category != "" {
+		filter.Category = &category
+	}
+	if tagsStr := query.Get("tags"); tagsStr != "" {
+		tags, err := service.NormalizeTags(strings.Split(tagsStr, ","))
+		if err != nil {
+			return errors.New("invalid tags parameter")
+		}
+		filter.Tags = tags
+	}
+
+	return nil
+}
+
+// parseListParams extracts the 'limit', 'sort' and 'cursor' query parameters of the listing.
+// The sort parameter has the form field[:asc|:desc]; a cursor is only valid with the sort it was issued for.
+func (h *SubscriptionHandler) parseListParams(query url.Values) (models.ListParams, error) {
+	params := models.ListParams{Limit: defaultPageLimit, SortBy: models.SortByStartDate}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, errors.New("invalid limit parameter")
+		}
+		params.Limit = limit
+	}
+
+	if sortStr := query.Get("sort"); sortStr != "" {
+		field, direction, _ := strings.Cut(sortStr, ":")
+		switch field {
+		case models.SortByPrice, models.SortByStartDate, models.SortByServiceName:
+			params.SortBy = field
+		default:
+			return params, errors.New("invalid sort parameter")
+		}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			params.Desc = true
+		default:
+			return params, errors.New("invalid sort parameter")
+		}
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := models.DecodeCursor(cursorStr)
+		if err != nil || cursor.SortBy != params.SortBy || cursor.Desc != params.Desc {
+			return params, errors.New("invalid cursor parameter")
+		}
+		params.Cursor = cursor
+	}
+
+	return params, nil
 }
 
 // GetSummary handles calculating the total cost of subscriptions for a given period and filters.
+// The result is sent as a spreadsheet if the client accepts CSV or XLSX.
 // @Summary Получить суммарную стоимость
-// @Description Возвращает суммарную стоимость подписок за период с фильтрацией
+// @Description Возвращает суммарную стоимость подписок за период с фильтрацией.
+// @Description Каждая подписка учитывается за каждый месяц, в котором она активна внутри периода, по цене, действовавшей в этом месяце.
+// @Description Подписки с периодом оплаты больше месяца учитываются в месяцах списания, еженедельные — за каждую неделю, начавшуюся в месяце; при amortize=true каждое списание равномерно распределяется по месяцам оплаченного периода.
+// @Description Цены пересчитываются в валюту currency (по умолчанию RUB) по курсу каждого месяца; при отсутствии курса возвращается 422.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet результат выгружается файлом CSV или XLSX.
 // @Tags subscriptions
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 // @Param summary body models.GetSummaryReq true "Параметры выборки"
-// @Success 200 {object} models.Response{data=object{total=int}}
+// @Success 200 {object} models.Response{data=object{total=string,currency=string}}
 // @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /subscriptions/summary [post]
+// @Router /api/v1/subscriptions/summary [post]
 func (h *SubscriptionHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value("logger").(*zap.Logger)
 
@@ -333,55 +746,207 @@ func (h *SubscriptionHandler) GetSummary(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := h.validateSummaryReq(&sumReq); err != nil {
+		log.Warn("Invalid summary request", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	// Call the service layer to calculate the summary.
-	total, err := h.service.GetSummary(&sumReq)
+	total, err := h.service.GetSummary(r.Context(), &sumReq)
 	if err != nil {
 		log.Warn("Failed to get summary", zap.Error(err))
-		h.sendResponse(w, nil, "Failed to get summary", http.StatusInternalServerError)
+		h.sendError(w, err, "Failed to get summary")
 		return
 	}
 	log.Info("Successfully get summary")
+	if format := exportFormat(r); format != "" {
+		h.exportSummary(w, log, format, &sumReq, total)
+		return
+	}
 	// Send a success response with the total summary.
 	h.sendResponse(w, struct {
-		Total int `json:"total"`
-	}{Total: total}, "Successfully get summary", http.StatusOK)
+		Total    models.Decimal `json:"total"`
+		Currency string         `json:"currency"`
+	}{Total: total, Currency: sumReq.Currency}, "Successfully get summary", http.StatusOK)
 }
 
-// validateSubReq performs validation on the incoming SubReq data.
-// It checks for non-empty service name, positive price, valid user ID, and correct date formats.
-// Returns formatted start and end dates as strings, or an error if validation fails.
-func (h *SubscriptionHandler) validateSubReq(sub *models.SubReq) (string, *string, error) {
-	// Validate ServiceName
-	if sub.ServiceName == "" {
-		return "", nil, errors.New("invalid service name")
+// GetBreakdown handles calculating the cost of subscriptions for every month of a given period.
+// The result is sent as a spreadsheet if the client accepts CSV or XLSX.
+// @Summary Получить помесячную стоимость
+// @Description Возвращает стоимость подписок за каждый месяц периода с фильтрацией.
+// @Description При указании group_by (service_name, user_id, category или tag) стоимость месяца разбивается по группам.
+// @Description Списания учитываются в месяцах, на которые они приходятся, или при amortize=true распределяются по месяцам оплаченного периода.
+// @Description Цены пересчитываются в валюту currency (по умолчанию RUB) по курсу каждого месяца.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet результат выгружается файлом CSV или XLSX.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param breakdown body models.GetBreakdownReq true "Параметры выборки"
+// @Success 200 {object} models.Response{data=[]models.MonthlyCost}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/summary/monthly [post]
+func (h *SubscriptionHandler) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling get breakdown")
+
+	var breakdownReq models.GetBreakdownReq
+	// Decode the JSON request body into a GetBreakdownReq struct.
+	if err := json.NewDecoder(r.Body).Decode(&breakdownReq); err != nil {
+		log.Warn("Invalid request body")
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
 	}
-	// Validate Price
-	if sub.Price <= 0 {
-		return "", nil, errors.New("invalid price")
+
+	if err := h.validateSummaryReq(&breakdownReq.GetSummaryReq); err != nil {
+		log.Warn("Invalid breakdown request", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
 	}
-	// Validate UserID
-	if sub.UserID == uuid.Nil {
-		return "", nil, errors.New("invalid user id")
+	switch breakdownReq.GroupBy {
+	case "", models.GroupByServiceName, models.GroupByUserID, models.GroupByCategory, models.GroupByTag:
+	default:
+		log.Warn("Invalid group by", zap.String("group_by", breakdownReq.GroupBy))
+		h.sendResponse(w, nil, "Invalid request body: invalid group by", http.StatusBadRequest)
+		return
 	}
 
-	// Parse and validate StartDate format (MM-YYYY).
-	startDate, err := time.Parse("01-2006", sub.StartDate)
+	// Call the service layer to calculate the breakdown.
+	breakdown, err := h.service.GetBreakdown(r.Context(), &breakdownReq)
 	if err != nil {
-		return "", nil, errors.New("invalid start date")
+		log.Warn("Failed to get breakdown", zap.Error(err))
+		h.sendError(w, err, "Failed to get breakdown")
+		return
 	}
-	// Parse and validate optional EndDate format (MM-YYYY).
-	if sub.EndDate != nil {
-		endDate, err := time.Parse("01-2006", *sub.EndDate)
-		if err != nil {
-			return "", nil, errors.New("invalid end date")
-		}
-		// Reformat EndDate to ensure consistency, though it's already parsed.
-		*sub.EndDate = endDate.Format("01-2006")
+	log.Info("Successfully get breakdown", zap.Int("months", len(breakdown)))
+	if format := exportFormat(r); format != "" {
+		h.exportBreakdown(w, log, format, breakdownReq.GroupBy, breakdown)
+		return
 	}
+	// Send a success response with the cost of every month.
+	h.sendResponse(w, breakdown, "Successfully get breakdown", http.StatusOK)
+}
 
-	// Return formatted start date and (potentially updated) end date.
-	return startDate.Format("01-2006"), sub.EndDate, nil
+// GetGroupedSummary handles calculating the total cost of subscriptions for a given period per group.
+// The result is sent as a spreadsheet if the client accepts CSV or XLSX.
+// @Summary Получить стоимость по группам
+// @Description Возвращает суммарную стоимость подписок за период и ее разбивку по группам group_by: service_name, user_id, category или tag.
+// @Description Подписки без категории или без тегов относятся к группе с пустым ключом. При группировке по тегам подписка учитывается в группе каждого своего тега, поэтому сумма групп может превышать total.
+// @Description Стоимость рассчитывается так же, как в /api/v1/subscriptions/summary.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet результат выгружается файлом CSV или XLSX.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param summary body models.GetGroupedSummaryReq true "Параметры выборки"
+// @Success 200 {object} models.Response{data=models.GroupedSummary}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/summary/grouped [post]
+func (h *SubscriptionHandler) GetGroupedSummary(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling get grouped summary")
+
+	var groupedReq models.GetGroupedSummaryReq
+	if err := json.NewDecoder(r.Body).Decode(&groupedReq); err != nil {
+		log.Warn("Invalid request body")
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
+	if err := h.validateSummaryReq(&groupedReq.GetSummaryReq); err != nil {
+		log.Warn("Invalid grouped summary request", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+	switch groupedReq.GroupBy {
+	case models.GroupByServiceName, models.GroupByUserID, models.GroupByCategory, models.GroupByTag:
+	default:
+		log.Warn("Invalid group by", zap.String("group_by", groupedReq.GroupBy))
+		h.sendResponse(w, nil, "Invalid request body: invalid group by", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.service.GetGroupedSummary(r.Context(), &groupedReq)
+	if err != nil {
+		log.Warn("Failed to get grouped summary", zap.Error(err))
+		h.sendError(w, err, "Failed to get grouped summary")
+		return
+	}
+	log.Info("Successfully get grouped summary", zap.Int("groups", len(summary.Groups)))
+	if format := exportFormat(r); format != "" {
+		h.exportGroupedSummary(w, log, format, groupedReq.GroupBy, summary)
+		return
+	}
+	h.sendResponse(w, summary, "Successfully get grouped summary", http.StatusOK)
+}
+
+// validateSummaryReq checks that the end of the requested period is not before its beginning
+// and normalizes the requested currency, which defaults to the base currency.
+// The period is inclusive and both of its ends are optional.
+func (h *SubscriptionHandler) validateSummaryReq(req *models.GetSummaryReq) error {
+	if !req.From.IsZero() && !req.To.IsZero() && req.To.Before(req.From) {
+		return errors.New("period end is before its start")
+	}
+	currency, err := service.ValidateCurrency(req.Currency)
+	if err != nil {
+		return err
+	}
+	req.Currency = currency
+	return nil
+}
+
+// subscriptionID returns the raw subscription ID of the request: the {id} path value
+// of the /api/v1 routes, or the 'id' query parameter of the legacy routes.
+func subscriptionID(r *http.Request) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("id")
+}
+
+// validateSubReq performs validation on the incoming SubReq data.
+// It applies the rules of service.ValidateSubReq, which are shared with the import of subscriptions.
+// Returns the subscription described by the request, without an ID, or an error if validation fails.
+func (h *SubscriptionHandler) validateSubReq(sub *models.SubReq) (*models.Subscription, error) {
+	return service.ValidateSubReq(sub)
+}
+
+// sendError translates an error returned by the service layer into an HTTP response.
+// Domain errors are mapped to 404, 409, 412 and 422 with the cause appended to the message;
+// any other error is reported as 500 with the given message only.
+func (h *SubscriptionHandler) sendError(w http.ResponseWriter, err error, message string) {
+	status, msg := h.errorStatus(err, message)
+	h.sendResponse(w, nil, msg, status)
+}
+
+// errorStatus returns the HTTP status and the client message for an error returned by the service layer.
+func (h *SubscriptionHandler) errorStatus(err error, message string) (int, string) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound, "Subscription not found"
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, fmt.Sprintf("%s: %s", message, service.ErrConflict)
+	case errors.Is(err, service.ErrInvalidTransition):
+		return http.StatusConflict, fmt.Sprintf("%s: %s", message, err)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, fmt.Sprintf("%s: subscription was modified", message)
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity, fmt.Sprintf("%s: %s", message, validationErr.Reason)
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusUnprocessableEntity, fmt.Sprintf("%s: %s", message, service.ErrValidation)
+	case errors.Is(err, service.ErrConstraintViolation):
+		return http.StatusUnprocessableEntity, fmt.Sprintf("%s: %s", message, service.ErrConstraintViolation)
+	default:
+		return http.StatusInternalServerError, message
+	}
 }
 
 // sendResponse is a helper function to standardize HTTP JSON responses.
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -22,42 +24,260 @@ type MockSubscriptionService struct {
 	mock.Mock
 }
 
-func (m *MockSubscriptionService) CreateSubs(subs *models.Subscription) error {
-	args := m.Called(subs)
+func (m *MockSubscriptionService) CreateSubs(ctx context.Context, subs *models.Subscription) error {
+	args := m.Called(ctx, subs)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) UpdateSubs(id uuid.UUID, newSubs *models.Subscription) error {
-	args := m.Called(id, newSubs)
+func (m *MockSubscriptionService) UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) error {
+	args := m.Called(ctx, id, version, newSubs)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) DeleteSubs(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockSubscriptionService) DeleteSubs(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) ListSubs(filter models.SubscriptionFilter) ([]models.Subscription, error) {
-	args := m.Called(filter)
-	return args.Get(0).([]models.Subscription), args.Error(1)
+func (m *MockSubscriptionService) BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	args := m.Called(ctx, subs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	args := m.Called(ctx, subs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error) {
+	args := m.Called(ctx, ids, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockSubscriptionService) PauseSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ResumeSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CancelSubs(ctx context.Context, id uuid.UUID, version int, mode string) (*models.Subscription, error) {
+	args := m.Called(ctx, id, version, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ReactivateSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ImportSubs(ctx context.Context, src service.ImportSource) (*models.ImportReport, error) {
+	args := m.Called(ctx, src)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportReport), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) (*models.SubsPage, error) {
+	args := m.Called(ctx, filter, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SubsPage), args.Error(1)
+}
+
+// ExportSubs passes the subscriptions set up for the call to fn and then returns the set up error.
+func (m *MockSubscriptionService) ExportSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error {
+	args := m.Called(ctx, filter, params)
+	for _, sub := range args.Get(0).([]models.Subscription) {
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetSummary(ctx context.Context, sum *models.GetSummaryReq) (models.Decimal, error) {
+	args := m.Called(ctx, sum)
+	return args.Get(0).(models.Decimal), args.Error(1)
 }
 
-func (m *MockSubscriptionService) GetSummary(sum *models.GetSummaryReq) (int, error) {
-	args := m.Called(sum)
-	return args.Int(0), args.Error(1)
+func (m *MockSubscriptionService) GetBreakdown(ctx context.Context, req *models.GetBreakdownReq) ([]models.MonthlyCost, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]models.MonthlyCost), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetGroupedSummary(ctx context.Context, req *models.GetGroupedSummaryReq) (*models.GroupedSummary, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GroupedSummary), args.Error(1)
 }
 
-func (m *MockSubscriptionService) GetSub(id uuid.UUID) (*models.Subscription, error) {
-	args := m.Called(id)
+func (m *MockSubscriptionService) GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionService) SubscriptionExists(id uuid.UUID) (bool, error) {
-	args := m.Called(id)
-	return args.Bool(0), args.Error(1)
+func (m *MockSubscriptionService) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) DeleteRate(ctx context.Context, currency string, month string) error {
+	args := m.Called(ctx, currency, month)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ListRates(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	args := m.Called(ctx, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ExchangeRate), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CreateService(ctx context.Context, svc *models.Service) error {
+	args := m.Called(ctx, svc)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) UpdateService(ctx context.Context, svc *models.Service) error {
+	args := m.Called(ctx, svc)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) DeleteService(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListServices(ctx context.Context, category string) ([]models.Service, error) {
+	args := m.Called(ctx, category)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Service), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CreateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) UpdateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListUsers(ctx context.Context) ([]models.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetUserOverview(ctx context.Context, id uuid.UUID) (*models.UserOverview, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserOverview), args.Error(1)
+}
+
+func (m *MockSubscriptionService) SetBudget(ctx context.Context, budget *models.Budget) error {
+	args := m.Called(ctx, budget)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error {
+	args := m.Called(ctx, userID, category)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Budget), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BudgetAlert), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BeginIdempotentRequest(ctx context.Context, key string, requestHash string) (*models.IdempotencyRecord, error) {
+	args := m.Called(ctx, key, requestHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CompleteIdempotentRequest(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error {
+	args := m.Called(ctx, key, statusCode, headers, response)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) AbortIdempotentRequest(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
 }
 
 func TestCreateSubs(t *testing.T) {
@@ -70,14 +290,14 @@ func TestCreateSubs(t *testing.T) {
 	// Test case 1: Successful creation
 	subReq := models.SubReq{
 		ServiceName: "Test Service",
-		Price:       100,
+		Price:       "100",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     nil,
 	}
 	reqBody, _ := json.Marshal(subReq)
 
-	mockService.On("CreateSubs", mock.AnythingOfType("*models.Subscription")).Return(nil).Once()
+	mockService.On("CreateSubs", mock.Anything, mock.AnythingOfType("*models.Subscription")).Return(nil).Once()
 
 	req := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
@@ -107,7 +327,7 @@ func TestCreateSubs(t *testing.T) {
 	// Test case 3: Invalid request body (validation error)
 	subReqInvalid := models.SubReq{
 		ServiceName: "", // Invalid service name
-		Price:       100,
+		Price:       "100",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     nil,
@@ -127,13 +347,13 @@ func TestCreateSubs(t *testing.T) {
 	// Test case 4: Service error
 	subReqValid := models.SubReq{
 		ServiceName: "Another Service",
-		Price:       200,
+		Price:       "200",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     nil,
 	}
 	reqBody, _ = json.Marshal(subReqValid)
-	mockService.On("CreateSubs", mock.AnythingOfType("*models.Subscription")).Return(errors.New("service error")).Once()
+	mockService.On("CreateSubs", mock.Anything, mock.AnythingOfType("*models.Subscription")).Return(errors.New("service error")).Once()
 	req = httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 	rr = httptest.NewRecorder()
@@ -146,6 +366,80 @@ func TestCreateSubs(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateSubsIdempotent(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	reqBody, _ := json.Marshal(models.SubReq{
+		ServiceName: "Test Service",
+		Price:       "100",
+		UserID:      uuid.New(),
+		StartDate:   "01-2025",
+	})
+
+	// Test case 1: First request is processed and its response stored
+	mockService.On("BeginIdempotentRequest", mock.Anything, "key-1", mock.AnythingOfType("string")).Return(nil, nil).Once()
+	mockService.On("CreateSubs", mock.Anything, mock.AnythingOfType("*models.Subscription")).Return(nil).Once()
+	mockService.On("CompleteIdempotentRequest", mock.Anything, "key-1", http.StatusOK, mock.Anything, mock.Anything).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Idempotency-Key", "key-1")
+	rr := httptest.NewRecorder()
+
+	handler.CreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+	headers := mockService.Calls[len(mockService.Calls)-1].Arguments.Get(3).(map[string]string)
+	assert.Equal(t, map[string]string{"ETag": rr.Header().Get("ETag")}, headers)
+	stored := mockService.Calls[len(mockService.Calls)-1].Arguments.Get(4).([]byte)
+	assert.Equal(t, rr.Body.Bytes(), stored)
+
+	// Test case 2: Retry replays the stored response and its headers without creating a subscription
+	record := &models.IdempotencyRecord{Key: "key-1", StatusCode: http.StatusOK, Headers: headers, Response: stored}
+	mockService.On("BeginIdempotentRequest", mock.Anything, "key-1", mock.AnythingOfType("string")).Return(record, nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Idempotency-Key", "key-1")
+	rr = httptest.NewRecorder()
+
+	handler.CreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, headers["ETag"], rr.Header().Get("ETag"))
+	assert.Equal(t, stored, rr.Body.Bytes())
+
+	// Test case 3: Key reused with a different body
+	mockService.On("BeginIdempotentRequest", mock.Anything, "key-1", mock.AnythingOfType("string")).
+		Return(nil, fmt.Errorf("%w: body differs", service.ErrValidation)).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBufferString(`{}`)).WithContext(ctx)
+	req.Header.Set("Idempotency-Key", "key-1")
+	rr = httptest.NewRecorder()
+
+	handler.CreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	// Test case 4: Server errors release the key
+	mockService.On("BeginIdempotentRequest", mock.Anything, "key-2", mock.AnythingOfType("string")).Return(nil, nil).Once()
+	mockService.On("CreateSubs", mock.Anything, mock.AnythingOfType("*models.Subscription")).Return(errors.New("db error")).Once()
+	mockService.On("AbortIdempotentRequest", mock.Anything, "key-2").Return(nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Idempotency-Key", "key-2")
+	rr = httptest.NewRecorder()
+
+	handler.CreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestGetSubs(t *testing.T) {
 	mockService := new(MockSubscriptionService)
 	handler := NewSubscriptionHandler(mockService)
@@ -155,8 +449,8 @@ func TestGetSubs(t *testing.T) {
 
 	// Test case 1: Successful retrieval
 	id := uuid.New()
-	expectedSub := &models.Subscription{ID: id, ServiceName: "Test Service"}
-	mockService.On("GetSub", id).Return(expectedSub, nil).Once()
+	expectedSub := &models.Subscription{ID: id, ServiceName: "Test Service", Version: 2}
+	mockService.On("GetSub", mock.Anything, id).Return(expectedSub, nil).Once()
 
 	req := httptest.NewRequest(http.MethodGet, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
 	rr := httptest.NewRecorder()
@@ -164,6 +458,7 @@ func TestGetSubs(t *testing.T) {
 	handler.GetSubs(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
 	var resp models.Response
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Successfully get subscriptions", resp.Msg)
@@ -193,7 +488,7 @@ func TestGetSubs(t *testing.T) {
 	mockService.AssertExpectations(t)
 
 	// Test case 4: Service error (e.g., subscription not found)
-	mockService.On("GetSub", id).Return(nil, errors.New("subscription not found")).Once()
+	mockService.On("GetSub", mock.Anything, id).Return(nil, errors.New("subscription not found")).Once()
 	req = httptest.NewRequest(http.MethodGet, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
 	rr = httptest.NewRecorder()
 
@@ -203,6 +498,18 @@ func TestGetSubs(t *testing.T) {
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Failed to get subscription", resp.Msg)
 	mockService.AssertExpectations(t)
+
+	// Test case 5: Subscription not found
+	mockService.On("GetSub", mock.Anything, id).Return(nil, fmt.Errorf("subscription %w", service.ErrNotFound)).Once()
+	req = httptest.NewRequest(http.MethodGet, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.GetSubs(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Subscription not found", resp.Msg)
+	mockService.AssertExpectations(t)
 }
 
 func TestUpdSubs(t *testing.T) {
@@ -216,23 +523,25 @@ func TestUpdSubs(t *testing.T) {
 	// Test case 1: Successful update
 	subReq := models.SubReq{
 		ServiceName: "Updated Service",
-		Price:       200,
+		Price:       "200",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     nil,
 	}
 	reqBody, _ := json.Marshal(subReq)
 
-	mockService.On("SubscriptionExists", id).Return(true, nil).Once()
-	mockService.On("UpdateSubs", id, mock.AnythingOfType("*models.Subscription")).Return(nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 3, mock.AnythingOfType("*models.Subscription")).
+		Run(func(args mock.Arguments) { args.Get(3).(*models.Subscription).Version = 4 }).Return(nil).Once()
 
 	req := httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 	rr := httptest.NewRecorder()
 
 	handler.UpdateSubs(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
 	var resp models.Response
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Successfully updated subscription", resp.Msg)
@@ -241,6 +550,7 @@ func TestUpdSubs(t *testing.T) {
 	// Test case 2: Missing ID parameter
 	req = httptest.NewRequest(http.MethodPut, "/subscriptions", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 	rr = httptest.NewRecorder()
 
 	handler.UpdateSubs(rr, req)
@@ -253,6 +563,7 @@ func TestUpdSubs(t *testing.T) {
 	// Test case 3: Invalid ID format
 	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id=invalid-uuid", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 	rr = httptest.NewRecorder()
 
 	handler.UpdateSubs(rr, req)
@@ -263,23 +574,25 @@ func TestUpdSubs(t *testing.T) {
 	mockService.AssertExpectations(t)
 
 	// Test case 4: Subscription does not exist
-	mockService.On("SubscriptionExists", id).Return(false, nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 3, mock.AnythingOfType("*models.Subscription")).
+		Return(fmt.Errorf("subscription %w", service.ErrNotFound)).Once()
 	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 	rr = httptest.NewRecorder()
 
 	handler.UpdateSubs(rr, req)
 
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Subscription does not exist", resp.Msg)
+	assert.Equal(t, "Subscription not found", resp.Msg)
 	mockService.AssertExpectations(t)
 
 	// Test case 5: Service error during update
-	mockService.On("SubscriptionExists", id).Return(true, nil).Once()
-	mockService.On("UpdateSubs", id, mock.AnythingOfType("*models.Subscription")).Return(errors.New("service update error")).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 3, mock.AnythingOfType("*models.Subscription")).Return(errors.New("service update error")).Once()
 	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
 	rr = httptest.NewRecorder()
 
 	handler.UpdateSubs(rr, req)
@@ -288,106 +601,867 @@ func TestUpdSubs(t *testing.T) {
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Failed to update subscription", resp.Msg)
 	mockService.AssertExpectations(t)
-}
-
-func TestDeleteSubs(t *testing.T) {
-	mockService := new(MockSubscriptionService)
-	handler := NewSubscriptionHandler(mockService)
 
-	logger, _ := zap.NewDevelopment()
-	ctx := context.WithValue(context.Background(), "logger", logger)
-
-	// Test case 1: Successful deletion
-	id := uuid.New()
-	mockService.On("DeleteSubs", id).Return(nil).Once()
-
-	req := httptest.NewRequest(http.MethodDelete, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
-	rr := httptest.NewRecorder()
+	// Test case 6: Subscription was modified concurrently
+	mockService.On("UpdateSubs", mock.Anything, id, 3, mock.AnythingOfType("*models.Subscription")).
+		Return(fmt.Errorf("subscription version 3: %w", service.ErrPreconditionFailed)).Once()
+	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("If-Match", `"3"`)
+	rr = httptest.NewRecorder()
 
-	handler.DeleteSubs(rr, req)
+	handler.UpdateSubs(rr, req)
 
-	assert.Equal(t, http.StatusOK, rr.Code)
-	var resp models.Response
-	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Successfully deleted subscription", resp.Msg)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
 	mockService.AssertExpectations(t)
 
-	// Test case 2: Missing ID parameter
-	req = httptest.NewRequest(http.MethodDelete, "/subscriptions", nil).WithContext(ctx)
+	// Test case 7: Missing If-Match header
+	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
 	rr = httptest.NewRecorder()
 
-	handler.DeleteSubs(rr, req)
+	handler.UpdateSubs(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Missing id parameter", resp.Msg)
-	mockService.AssertExpectations(t)
+	assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
 
-	// Test case 3: Invalid ID format
-	req = httptest.NewRequest(http.MethodDelete, "/subscriptions?id=invalid-uuid", nil).WithContext(ctx)
-	rr = httptest.NewRecorder()
+	// Test case 8: Invalid If-Match header
+	for _, ifMatch := range []string{"*", `W/"3"`, "3", `"abc"`} {
+		req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
+		req.Header.Set("If-Match", ifMatch)
+		rr = httptest.NewRecorder()
 
-	handler.DeleteSubs(rr, req)
+		handler.UpdateSubs(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Invalid id format", resp.Msg)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, ifMatch)
+	}
 	mockService.AssertExpectations(t)
 
-	// Test case 4: Service error
-	mockService.On("DeleteSubs", id).Return(errors.New("service delete error")).Once()
-	req = httptest.NewRequest(http.MethodDelete, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	// Test case 9: The end date of a canceled subscription cannot be cleared
+	mockService.On("UpdateSubs", mock.Anything, id, 3, mock.MatchedBy(func(sub *models.Subscription) bool { return sub.EndDate == nil })).
+		Return(fmt.Errorf("%w: cannot change the end date of a subscription that is canceled", service.ErrInvalidTransition)).Once()
+	req = httptest.NewRequest(http.MethodPut, "/subscriptions?id="+id.String(), bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("If-Match", `"3"`)
 	rr = httptest.NewRecorder()
 
-	handler.DeleteSubs(rr, req)
+	handler.UpdateSubs(rr, req)
 
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, http.StatusConflict, rr.Code)
 	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Failed to delete subscription", resp.Msg)
+	assert.Equal(t, "Failed to update subscription: invalid status transition: cannot change the end date of a subscription that is canceled", resp.Msg)
 	mockService.AssertExpectations(t)
 }
 
-func TestListSubs(t *testing.T) {
+func TestPatchSubs(t *testing.T) {
 	mockService := new(MockSubscriptionService)
 	handler := NewSubscriptionHandler(mockService)
 
 	logger, _ := zap.NewDevelopment()
 	ctx := context.WithValue(context.Background(), "logger", logger)
 
-	// Test case 1: Successful listing with no filters
-	filter := models.SubscriptionFilter{UserID: nil, ServiceName: nil}
-	expectedSubs := []models.Subscription{{ID: uuid.New(), ServiceName: "Service A"}}
-	mockService.On("ListSubs", filter).Return(expectedSubs, nil).Once()
+	id := uuid.New()
+	endDate := "2025-12-31"
+	current := &models.Subscription{
+		ID: id, ServiceName: "Yandex Plus", Price: 40000, Currency: "RUB", BillingInterval: models.IntervalYear, IntervalCount: 1,
+		UserID: uuid.New(), StartDate: "2025-01-01", EndDate: &endDate, Version: 2,
+	}
+	newPatchRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPatch, "/subscriptions/"+id.String(), bytes.NewBufferString(body)).WithContext(ctx)
+		req.SetPathValue("id", id.String())
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"2"`)
+		return req
+	}
 
-	req := httptest.NewRequest(http.MethodGet, "/all-subscriptions", nil).WithContext(ctx)
-	rr := httptest.NewRecorder()
+	// Test case 1: Only the supplied field changes
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 2, mock.MatchedBy(func(sub *models.Subscription) bool {
+		return sub.Price == 50000 && sub.ServiceName == "Yandex Plus" && sub.BillingInterval == models.IntervalYear && sub.StartDate == "2025-01-01" && sub.EndDate != nil && *sub.EndDate == "2025-12-31"
+	})).Run(func(args mock.Arguments) { args.Get(3).(*models.Subscription).Version = 3 }).Return(nil).Once()
 
-	handler.ListSubs(rr, req)
+	rr := httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"price": 500}`))
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var resp models.Response
-	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Successfully get list subs", resp.Msg)
-	assert.NotNil(t, resp.Data)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
 	mockService.AssertExpectations(t)
 
-	// Test case 2: Successful listing with userId filter
-	userID := uuid.New()
-	filterWithUser := models.SubscriptionFilter{UserID: &userID, ServiceName: nil}
-	mockService.On("ListSubs", filterWithUser).Return(expectedSubs, nil).Once()
+	// Test case 2: Explicit null clears the end date
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 2, mock.MatchedBy(func(sub *models.Subscription) bool {
+		return sub.Price == 40000 && sub.EndDate == nil
+	})).Return(nil).Once()
 
-	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?userId="+userID.String(), nil).WithContext(ctx)
 	rr = httptest.NewRecorder()
-
-	handler.ListSubs(rr, req)
+	handler.PatchSubs(rr, newPatchRequest(`{"end_date": null}`))
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	json.NewDecoder(rr.Body).Decode(&resp)
-	assert.Equal(t, "Successfully get list subs", resp.Msg)
 	mockService.AssertExpectations(t)
 
-	// Test case 3: Invalid userId filter
-	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?userId=invalid", nil).WithContext(ctx)
+	// Test case 3: Merged result is validated
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"service_name": null}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var resp models.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Invalid request body: invalid service name", resp.Msg)
+
+	// Test case 4: Unknown field
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"discount": 10}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 5: Stale ETag
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+
+	req := newPatchRequest(`{"price": 500}`)
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	// Test case 6: Unsupported content type
+	req = newPatchRequest(`price=500`)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	// Test case 7: Subscription not found
+	mockService.On("GetSub", mock.Anything, id).Return(nil, fmt.Errorf("subscription %w", service.ErrNotFound)).Once()
+
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"price": 500}`))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 8: The end date of a paused subscription cannot be set
+	paused := *current
+	paused.Status = models.StatusPaused
+	paused.EndDate = nil
+	mockService.On("GetSub", mock.Anything, id).Return(&paused, nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 2, mock.MatchedBy(func(sub *models.Subscription) bool {
+		return sub.EndDate != nil && *sub.EndDate == "2025-06-30"
+	})).Return(fmt.Errorf("%w: cannot change the end date of a subscription that is paused", service.ErrInvalidTransition)).Once()
+
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"end_date": "2025-06-30"}`))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Failed to update subscription: invalid status transition: cannot change the end date of a subscription that is paused", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 9: The subscription can be moved to another user
+	newOwner := uuid.New()
+	mockService.On("GetSub", mock.Anything, id).Return(current, nil).Once()
+	mockService.On("UpdateSubs", mock.Anything, id, 2, mock.MatchedBy(func(sub *models.Subscription) bool {
+		return sub.UserID == newOwner
+	})).Return(nil).Once()
+
+	rr = httptest.NewRecorder()
+	handler.PatchSubs(rr, newPatchRequest(`{"user_id": "`+newOwner.String()+`"}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	endDate := "06-2025"
+	current := &models.SubReq{ServiceName: "Netflix", Price: "100", UserID: uuid.New(), StartDate: "01-2025", EndDate: &endDate}
+
+	merged, err := applyMergePatch(current, []byte(`{"service_name": "Netflix Premium", "end_date": "09-2025"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Netflix Premium", merged.ServiceName)
+	assert.Equal(t, models.Decimal("100"), merged.Price)
+	assert.Equal(t, "09-2025", *merged.EndDate)
+	assert.Equal(t, "06-2025", *current.EndDate)
+
+	merged, err = applyMergePatch(current, []byte(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, current, merged)
+
+	_, err = applyMergePatch(current, []byte(`[1, 2]`))
+	assert.Error(t, err)
+
+	_, err = applyMergePatch(current, []byte(`{"price": true}`))
+	assert.Error(t, err)
+}
+
+func TestDeleteSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Successful deletion
+	id := uuid.New()
+	mockService.On("DeleteSubs", mock.Anything, id).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.DeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp models.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Successfully deleted subscription", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Missing ID parameter
+	req = httptest.NewRequest(http.MethodDelete, "/subscriptions", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.DeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Missing id parameter", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 3: Invalid ID format
+	req = httptest.NewRequest(http.MethodDelete, "/subscriptions?id=invalid-uuid", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.DeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Invalid id format", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 4: Service error
+	mockService.On("DeleteSubs", mock.Anything, id).Return(errors.New("service delete error")).Once()
+	req = httptest.NewRequest(http.MethodDelete, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.DeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Failed to delete subscription", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 5: Subscription not found
+	mockService.On("DeleteSubs", mock.Anything, id).Return(fmt.Errorf("subscription %w", service.ErrNotFound)).Once()
+	req = httptest.NewRequest(http.MethodDelete, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.DeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Subscription not found", resp.Msg)
+	mockService.AssertExpectations(t)
+}
+
+func TestLifecycle(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	id := uuid.New()
+	endDate := "10-2026"
+	canceled := &models.Subscription{ID: id, EndDate: &endDate, Version: 3, Status: models.StatusCanceled}
+
+	// Test case 1: Cancel at period end by default, without If-Match
+	mockService.On("CancelSubs", mock.Anything, id, 0, models.CancelAtPeriodEnd).Return(canceled, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/cancel", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr := httptest.NewRecorder()
+
+	handler.CancelSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+	var resp models.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Successfully canceled subscription", resp.Msg)
+
+	// Test case 2: Immediate cancellation with If-Match
+	mockService.On("CancelSubs", mock.Anything, id, 2, models.CancelImmediately).Return(canceled, nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/cancel?effective=immediate", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	req.Header.Set("If-Match", `"2"`)
+	rr = httptest.NewRecorder()
+
+	handler.CancelSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Test case 3: Invalid cancellation mode
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/cancel?effective=tomorrow", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.CancelSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 4: Invalid transition
+	mockService.On("PauseSubs", mock.Anything, id, 0).Return(nil, fmt.Errorf("%w: cannot pause a subscription that is canceled", service.ErrInvalidTransition)).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/pause", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.PauseSubs(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Failed to pause subscription: invalid status transition: cannot pause a subscription that is canceled", resp.Msg)
+
+	// Test case 5: Stale If-Match
+	mockService.On("ReactivateSubs", mock.Anything, id, 1).Return(nil, fmt.Errorf("subscription version 1: %w", service.ErrPreconditionFailed)).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/"+id.String()+"/reactivate", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+
+	handler.ReactivateSubs(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	// Test case 6: Invalid id
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/invalid/resume", nil).WithContext(ctx)
+	req.SetPathValue("id", "invalid")
+	rr = httptest.NewRecorder()
+
+	handler.ResumeSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestBatchCreateSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	reqBody, _ := json.Marshal([]models.SubReq{
+		{ServiceName: "Service A", Price: "100", UserID: uuid.New(), StartDate: "01-2025"},
+		{ServiceName: "", Price: "200", UserID: uuid.New(), StartDate: "02-2025"},
+		{ServiceName: "Service C", Price: "300", UserID: uuid.New(), StartDate: "03-2025"},
+	})
+	decodeReport := func(rr *httptest.ResponseRecorder) models.BatchReport {
+		var resp struct {
+			Data models.BatchReport `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	// Test case 1: Atomic batch with an invalid item is not applied
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/batch", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.BatchCreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	report := decodeReport(rr)
+	assert.Equal(t, models.BatchModeAtomic, report.Mode)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, http.StatusFailedDependency, report.Items[0].Status)
+	assert.Equal(t, http.StatusBadRequest, report.Items[1].Status)
+	assert.Equal(t, "Invalid request body: invalid service name", report.Items[1].Error)
+
+	// Test case 2: Best-effort batch applies the valid items
+	mockService.On("BatchCreateSubs", mock.Anything, mock.AnythingOfType("[]*models.Subscription"), false).
+		Run(func(args mock.Arguments) { args.Get(1).([]*models.Subscription)[0].Version = 1 }).
+		Return([]error{nil, fmt.Errorf("failed to create subscription: %w", service.ErrConflict)}, nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/batch?mode=best_effort", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.BatchCreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	report = decodeReport(rr)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, http.StatusOK, report.Items[0].Status)
+	assert.NotNil(t, report.Items[0].ID)
+	assert.Equal(t, 1, report.Items[0].Version)
+	assert.Equal(t, http.StatusBadRequest, report.Items[1].Status)
+	assert.Equal(t, http.StatusConflict, report.Items[2].Status)
+	mockService.AssertExpectations(t)
+
+	// Test case 3: Atomic batch of valid items is applied
+	validBody, _ := json.Marshal([]models.SubReq{{ServiceName: "Service A", Price: "100", UserID: uuid.New(), StartDate: "01-2025"}})
+	mockService.On("BatchCreateSubs", mock.Anything, mock.AnythingOfType("[]*models.Subscription"), true).Return([]error{nil}, nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/batch?mode=atomic", bytes.NewBuffer(validBody)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.BatchCreateSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	report = decodeReport(rr)
+	assert.Equal(t, 1, report.Succeeded)
+	mockService.AssertExpectations(t)
+
+	// Test case 4: Unsupported mode and empty batch
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/batch?mode=all", bytes.NewBuffer(validBody)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+	handler.BatchCreateSubs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/batch", bytes.NewBufferString(`[]`)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+	handler.BatchCreateSubs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestBatchUpdateSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	id := uuid.New()
+	item := models.SubUpdateReq{ID: id, Version: 2, SubReq: models.SubReq{ServiceName: "Service A", Price: "100", UserID: uuid.New(), StartDate: "01-2025"}}
+	noVersion := item
+	noVersion.Version = 0
+	reqBody, _ := json.Marshal([]models.SubUpdateReq{item, noVersion})
+
+	// Test case 1: Item without a version is rejected, the other one is stale
+	mockService.On("BatchUpdateSubs", mock.Anything, mock.MatchedBy(func(subs []*models.Subscription) bool {
+		return len(subs) == 1 && subs[0].ID == id && subs[0].Version == 2
+	}), false).Return([]error{fmt.Errorf("subscription version 2: %w", service.ErrPreconditionFailed)}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/subscriptions/batch?mode=best_effort", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.BatchUpdateSubs(rr, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	var resp struct {
+		Data models.BatchReport `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Data.Items[0].Status)
+	assert.Equal(t, http.StatusPreconditionRequired, resp.Data.Items[1].Status)
+	mockService.AssertExpectations(t)
+}
+
+func TestBatchDeleteSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	reqBody, _ := json.Marshal(ids)
+
+	// Test case 1: Atomic batch fails on a missing subscription
+	mockService.On("BatchDeleteSubs", mock.Anything, ids, true).Return([]error{nil, fmt.Errorf("subscription %w", service.ErrNotFound)}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/subscriptions/batch", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.BatchDeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var resp struct {
+		Data models.BatchReport `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, http.StatusFailedDependency, resp.Data.Items[0].Status)
+	assert.Equal(t, http.StatusNotFound, resp.Data.Items[1].Status)
+
+	// Test case 2: Service error
+	mockService.On("BatchDeleteSubs", mock.Anything, ids, true).Return(nil, errors.New("db error")).Once()
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/subscriptions/batch", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.BatchDeleteSubs(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	file := "Service;Amount;user_id;start_date\nYandex Plus;400;60601fee-2bf1-4721-ae6f-7636e79a0cba;07-2025\n"
+
+	// Test case 1: CSV file with mapping and delimiter
+	report := &models.ImportReport{Imported: 1, Errors: []models.ImportError{}}
+	mockService.On("ImportSubs", mock.Anything, mock.Anything).Return(report, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/import?mapping=service_name:Service,price:Amount&delimiter=%3B", bytes.NewBufferString(file)).WithContext(ctx)
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	rr := httptest.NewRecorder()
+
+	handler.ImportSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data models.ImportReport `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, int64(1), resp.Data.Imported)
+
+	// Test case 2: Header lacks a mapped column
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/import", bytes.NewBufferString(file)).WithContext(ctx)
+	req.Header.Set("Content-Type", "text/csv")
+	rr = httptest.NewRecorder()
+
+	handler.ImportSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 3: Unknown format
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/import", bytes.NewBufferString(file)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	rr = httptest.NewRecorder()
+
+	handler.ImportSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 4: Unreadable file
+	mockService.On("ImportSubs", mock.Anything, mock.Anything).Return(nil, &service.ValidationError{Reason: "failed to read file: unexpected EOF"}).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/import?format=ndjson", bytes.NewBufferString("{}")).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ImportSubs(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestExchangeRates(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Rate is set with the currency code normalized
+	rate := &models.ExchangeRate{Currency: "USD", Month: "01-2025", Rate: "92.35"}
+	mockService.On("SetRate", mock.Anything, rate).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/exchange-rates/usd/01-2025", bytes.NewBufferString(`{"rate":"92.35"}`)).WithContext(ctx)
+	req.SetPathValue("currency", "usd")
+	req.SetPathValue("month", "01-2025")
+	rr := httptest.NewRecorder()
+
+	handler.SetRate(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Test case 2: Base currency has no rate
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/exchange-rates/RUB/01-2025", bytes.NewBufferString(`{"rate":"1"}`)).WithContext(ctx)
+	req.SetPathValue("currency", "RUB")
+	req.SetPathValue("month", "01-2025")
+	rr = httptest.NewRecorder()
+
+	handler.SetRate(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 3: Rates of a currency are listed
+	rates := []models.ExchangeRate{*rate}
+	mockService.On("ListRates", mock.Anything, "USD").Return(rates, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/exchange-rates?currency=usd", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListRates(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data []models.ExchangeRate `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, rates, resp.Data)
+
+	// Test case 4: Deleting a missing rate
+	mockService.On("DeleteRate", mock.Anything, "USD", "02-2025").Return(fmt.Errorf("exchange rate of USD for 02-2025 %w", service.ErrNotFound)).Once()
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/exchange-rates/USD/02-2025", nil).WithContext(ctx)
+	req.SetPathValue("currency", "USD")
+	req.SetPathValue("month", "02-2025")
+	rr = httptest.NewRecorder()
+
+	handler.DeleteRate(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var deleteResp models.Response
+	json.NewDecoder(rr.Body).Decode(&deleteResp)
+	assert.Equal(t, "Exchange rate not found", deleteResp.Msg)
+	mockService.AssertExpectations(t)
+}
+
+func TestServiceCatalog(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Service is created with its slug and default price in minor units
+	mockService.On("CreateService", mock.Anything, mock.MatchedBy(func(svc *models.Service) bool {
+		return svc.ID != uuid.Nil && svc.Name == "Yandex Plus" && svc.Slug == "yandex-plus" && svc.DefaultPrice == 29900 && svc.Currency == "RUB"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/services", bytes.NewBufferString(`{"name":" Yandex Plus ","default_price":"299.00","currency":"rub"}`)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.CreateService(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "yandex-plus", resp.Data["slug"])
+	assert.Equal(t, "299.00", resp.Data["default_price"])
+
+	// Test case 2: Name without letters or digits
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/services", bytes.NewBufferString(`{"name":"+++"}`)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.CreateService(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 3: Missing service
+	id := uuid.New()
+	mockService.On("GetService", mock.Anything, id).Return(nil, fmt.Errorf("service %w", service.ErrNotFound)).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/services/"+id.String(), nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.GetService(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Service not found")
+
+	// Test case 4: Service still referred to by subscriptions
+	mockService.On("DeleteService", mock.Anything, id).Return(fmt.Errorf("service %s has subscriptions: %w", id, service.ErrConflict)).Once()
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/services/"+id.String(), nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.DeleteService(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUsers(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: User is created with the default time zone and the budget in minor units
+	mockService.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+		return user.ID != uuid.Nil && user.DisplayName == "Ivan" && user.Currency == "RUB" && user.Timezone == "UTC" && user.MonthlyBudget == 150000
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBufferString(`{"display_name":"Ivan","monthly_budget":"1500"}`)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "1500.00", resp.Data["monthly_budget"])
+	assert.NotContains(t, resp.Data, "email")
+
+	// Test case 2: Invalid time zone
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBufferString(`{"display_name":"Ivan","timezone":"Moscow"}`)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid timezone")
+
+	// Test case 3: Overview of the user
+	id := uuid.New()
+	overview := &models.UserOverview{UserID: id, Currency: "RUB", ActiveSubscriptions: 1, MonthlyCost: "299.00", YearToDate: "897.00",
+		NextRenewals: []models.Renewal{{SubscriptionID: uuid.New(), ServiceName: "Yandex Plus", Date: "2025-04-15", Price: "299.00", Currency: "RUB"}}}
+	mockService.On("GetUserOverview", mock.Anything, id).Return(overview, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id.String()+"/overview", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.GetUserOverview(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"next_renewals":[{"subscription_id"`)
+	assert.NotContains(t, rr.Body.String(), "monthly_budget")
+
+	// Test case 4: Missing user
+	mockService.On("GetUserOverview", mock.Anything, id).Return(nil, fmt.Errorf("user %w", service.ErrNotFound)).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id.String()+"/overview", nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.GetUserOverview(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "User not found")
+
+	// Test case 5: User still has subscriptions
+	mockService.On("DeleteUser", mock.Anything, id).Return(fmt.Errorf("user %s has subscriptions: %w", id, service.ErrConflict)).Once()
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+id.String(), nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr = httptest.NewRecorder()
+
+	handler.DeleteUser(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestBudgets(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+	userID := uuid.New()
+
+	// Test case 1: Budget of a category is set in minor units
+	mockService.On("SetBudget", mock.Anything, &models.Budget{UserID: userID, Category: "music", Amount: 30000, Currency: "RUB"}).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userID.String()+"/budgets/music", bytes.NewBufferString(`{"amount":"300"}`)).WithContext(ctx)
+	req.SetPathValue("id", userID.String())
+	req.SetPathValue("category", "music")
+	rr := httptest.NewRecorder()
+
+	handler.SetBudget(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"amount":"300.00"`)
+
+	// Test case 2: Unknown user; yen have no minor units
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userID.String()+"/budgets/music", bytes.NewBufferString(`{"amount":"300","currency":"JPY"}`)).WithContext(ctx)
+	req.SetPathValue("id", userID.String())
+	req.SetPathValue("category", "music")
+	rr = httptest.NewRecorder()
+	mockService.On("SetBudget", mock.Anything, &models.Budget{UserID: userID, Category: "music", Amount: 300, Currency: "JPY"}).Return(fmt.Errorf("user %w", service.ErrNotFound)).Once()
+
+	handler.SetBudget(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "User not found")
+
+	// Test case 3: Missing budget
+	mockService.On("DeleteBudget", mock.Anything, userID, "video").Return(fmt.Errorf("budget %w", service.ErrNotFound)).Once()
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/budgets/video", nil).WithContext(ctx)
+	req.SetPathValue("id", userID.String())
+	req.SetPathValue("category", "video")
+	rr = httptest.NewRecorder()
+
+	handler.DeleteBudget(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Budget not found")
+
+	// Test case 4: Alerts of a user in a month
+	alert := models.BudgetAlert{ID: uuid.New(), UserID: userID, Category: "music", Month: "05-2025", Budget: 30000, Spent: 39900, Currency: "RUB"}
+	mockService.On("ListAlerts", mock.Anything, models.AlertFilter{UserID: &userID, Month: "05-2025"}).Return([]models.BudgetAlert{alert}, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/alerts?user_id="+userID.String()+"&month=05-2025", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListAlerts(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"budget":"300.00","spent":"399.00"`)
+
+	// Test case 5: Invalid month
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/alerts?month=2025-05", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListAlerts(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestListSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	defaultParams := models.ListParams{Limit: defaultPageLimit, SortBy: models.SortByStartDate}
+
+	// Test case 1: Successful listing with no filters
+	filter := models.SubscriptionFilter{UserID: nil, ServiceName: nil}
+	expectedPage := &models.SubsPage{Items: []models.Subscription{{ID: uuid.New(), ServiceName: "Service A"}}}
+	mockService.On("ListSubs", mock.Anything, filter, defaultParams).Return(expectedPage, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/all-subscriptions", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp models.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Successfully get list subs", resp.Msg)
+	assert.NotNil(t, resp.Data)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Successful listing with userId filter
+	userID := uuid.New()
+	filterWithUser := models.SubscriptionFilter{UserID: &userID, ServiceName: nil}
+	mockService.On("ListSubs", mock.Anything, filterWithUser, defaultParams).Return(expectedPage, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?userId="+userID.String(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Successfully get list subs", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 3: Invalid userId filter
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?userId=invalid", nil).WithContext(ctx)
 	rr = httptest.NewRecorder()
 
 	handler.ListSubs(rr, req)
@@ -398,7 +1472,7 @@ func TestListSubs(t *testing.T) {
 	mockService.AssertExpectations(t)
 
 	// Test case 4: Service error
-	mockService.On("ListSubs", filter).Return([]models.Subscription{}, errors.New("service list error")).Once()
+	mockService.On("ListSubs", mock.Anything, filter, defaultParams).Return(nil, errors.New("service list error")).Once()
 	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions", nil).WithContext(ctx)
 	rr = httptest.NewRecorder()
 
@@ -408,6 +1482,163 @@ func TestListSubs(t *testing.T) {
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Failed to get list subs", resp.Msg)
 	mockService.AssertExpectations(t)
+
+	// Test case 5: Pagination and sorting parameters
+	cursor := models.Cursor{SortBy: models.SortByPrice, Desc: true, Value: "300", ID: uuid.New()}
+	pageParams := models.ListParams{Limit: 10, SortBy: models.SortByPrice, Desc: true, Cursor: &cursor}
+	mockService.On("ListSubs", mock.Anything, filter, pageParams).Return(expectedPage, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?limit=10&sort=price:desc&cursor="+cursor.Encode(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 6: Extended filters
+	userA, userB := uuid.New(), uuid.New()
+	prefix, currency, month := "yandex", "USD", "03-2025"
+	minPrice, maxPrice := int64(10050), int64(50000)
+	extendedFilter := models.SubscriptionFilter{
+		UserIDs:           []uuid.UUID{userA, userB},
+		ServiceNamePrefix: &prefix,
+		Currency:          &currency,
+		MinPrice:          &minPrice,
+		MaxPrice:          &maxPrice,
+		ActiveOn:          &month,
+		StartTo:           &month,
+		OpenEnded:         true,
+	}
+	mockService.On("ListSubs", mock.Anything, extendedFilter, defaultParams).Return(expectedPage, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?userIds="+userA.String()+","+userB.String()+
+		"&serviceNamePrefix=yandex&currency=usd&minPrice=100.50&maxPrice=500&activeOn=03-2025&startTo=03-2025&openEnded=true", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 7: Invalid pagination, sorting and filter parameters
+	invalidQueries := map[string]string{
+		"limit=0":             "Invalid query parameters: invalid limit parameter",
+		"limit=abc":           "Invalid query parameters: invalid limit parameter",
+		"sort=user_id":        "Invalid query parameters: invalid sort parameter",
+		"sort=price:up":       "Invalid query parameters: invalid sort parameter",
+		"cursor=not-a-cursor": "Invalid query parameters: invalid cursor parameter",
+		"sort=price:asc&cursor=" + cursor.Encode(): "Invalid query parameters: invalid cursor parameter",
+		"userIds=" + userA.String() + ",invalid":   "Invalid query parameters: invalid userIds parameter",
+		"minPrice=-1":                              "Invalid query parameters: invalid minPrice parameter",
+		"minPrice=500&maxPrice=100":                "Invalid query parameters: minPrice is greater than maxPrice",
+		"activeOn=2025-03":                         "Invalid query parameters: invalid activeOn parameter, expected MM-YYYY",
+		"openEnded=maybe":                          "Invalid query parameters: invalid openEnded parameter",
+	}
+	for query, msg := range invalidQueries {
+		req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?"+query, nil).WithContext(ctx)
+		rr = httptest.NewRecorder()
+
+		handler.ListSubs(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		json.NewDecoder(rr.Body).Decode(&resp)
+		assert.Equal(t, msg, resp.Msg, query)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestListUserSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: User is taken from the path
+	userID := uuid.New()
+	filter := models.SubscriptionFilter{UserID: &userID}
+	params := models.ListParams{Limit: 10, SortBy: models.SortByPrice}
+	mockService.On("ListSubs", mock.Anything, filter, params).Return(&models.SubsPage{Items: []models.Subscription{}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID.String()+"/subscriptions?limit=10&sort=price", nil).WithContext(ctx)
+	req.SetPathValue("user_id", userID.String())
+	rr := httptest.NewRecorder()
+
+	handler.ListUserSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Invalid user id in the path
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/invalid/subscriptions", nil).WithContext(ctx)
+	req.SetPathValue("user_id", "invalid")
+	rr = httptest.NewRecorder()
+
+	handler.ListUserSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Path-based route
+	id := uuid.New()
+	endDate := "2025-06-30"
+	sub := &models.Subscription{ID: id, StartDate: "2025-01-17", EndDate: &endDate, Version: 1}
+	mockService.On("GetSub", mock.Anything, id).Return(sub, nil).Twice()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/"+id.String(), nil).WithContext(ctx)
+	req.SetPathValue("id", id.String())
+	rr := httptest.NewRecorder()
+
+	handler.GetSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	assert.Contains(t, rr.Body.String(), `"start_date":"2025-01-17","end_date":"2025-06-30"`)
+
+	// Test case 2: Legacy query-based alias is marked as deprecated
+	req = httptest.NewRequest(http.MethodGet, "/subscriptions?id="+id.String(), nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.LegacyGetSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, fmt.Sprintf("@%d", legacyRoutesDeprecatedAt.Unix()), rr.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/subscriptions/`+id.String()+`>; rel="successor-version"`, rr.Header().Get("Link"))
+	// Legacy clients keep getting dates in MM-YYYY form; the stored subscription is not changed.
+	assert.Contains(t, rr.Body.String(), `"start_date":"01-2025","end_date":"06-2025"`)
+	assert.Equal(t, "2025-01-17", sub.StartDate)
+	mockService.AssertExpectations(t)
+
+	// Test case 3: Deprecation headers are sent with error responses as well
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions?limit=0", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.LegacyListSubs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/subscriptions>; rel="successor-version"`, rr.Header().Get("Link"))
+
+	// Test case 4: Pages listed through the legacy route have MM-YYYY dates as well
+	mockService.On("ListSubs", mock.Anything, models.SubscriptionFilter{}, mock.Anything).
+		Return(&models.SubsPage{Items: []models.Subscription{*sub}, NextCursor: "next"}, nil).Once()
+	req = httptest.NewRequest(http.MethodGet, "/all-subscriptions", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.LegacyListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"start_date":"01-2025","end_date":"06-2025"`)
+	assert.Contains(t, rr.Body.String(), `"next_cursor":"next"`)
+	mockService.AssertExpectations(t)
 }
 
 func TestGetSummary(t *testing.T) {
@@ -419,12 +1650,13 @@ func TestGetSummary(t *testing.T) {
 
 	// Test case 1: Successful summary retrieval
 	sumReq := models.GetSummaryReq{
-		From: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
-		To:   time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC),
+		From:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC),
+		Currency: "USD",
 	}
-	reqBody, _ := json.Marshal(sumReq)
+	reqBody, _ := json.Marshal(models.GetSummaryReq{From: sumReq.From, To: sumReq.To, Currency: "usd"})
 
-	mockService.On("GetSummary", &sumReq).Return(500, nil).Once()
+	mockService.On("GetSummary", mock.Anything, &sumReq).Return(models.Decimal("500.00"), nil).Once()
 
 	req := httptest.NewRequest(http.MethodPost, "/subscriptions/summary", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
@@ -436,7 +1668,7 @@ func TestGetSummary(t *testing.T) {
 	var resp models.Response
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Successfully get summary", resp.Msg)
-	assert.NotNil(t, resp.Data)
+	assert.Equal(t, map[string]interface{}{"total": "500.00", "currency": "USD"}, resp.Data)
 	mockService.AssertExpectations(t)
 
 	// Test case 2: Invalid request body
@@ -453,7 +1685,7 @@ func TestGetSummary(t *testing.T) {
 	mockService.AssertExpectations(t)
 
 	// Test case 3: Service error
-	mockService.On("GetSummary", &sumReq).Return(0, errors.New("service summary error")).Once()
+	mockService.On("GetSummary", mock.Anything, &sumReq).Return(models.Decimal(""), errors.New("service summary error")).Once()
 	reqBody, _ = json.Marshal(sumReq)
 	req = httptest.NewRequest(http.MethodPost, "/subscriptions/summary", bytes.NewBuffer(reqBody)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
@@ -465,6 +1697,288 @@ func TestGetSummary(t *testing.T) {
 	json.NewDecoder(rr.Body).Decode(&resp)
 	assert.Equal(t, "Failed to get summary", resp.Msg)
 	mockService.AssertExpectations(t)
+
+	// Test case 4: Period end before its start
+	reqBody, _ = json.Marshal(models.GetSummaryReq{From: sumReq.To, To: sumReq.From})
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions/summary", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+
+	handler.GetSummary(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Invalid request body: period end is before its start", resp.Msg)
+
+	// Test case 5: Invalid currency
+	reqBody, _ = json.Marshal(models.GetSummaryReq{From: sumReq.From, Currency: "dollars"})
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions/summary", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+
+	handler.GetSummary(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Invalid request body: invalid currency", resp.Msg)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetGroupedSummary(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Costs grouped by tag
+	groupedReq := models.GetGroupedSummaryReq{
+		GetSummaryReq: models.GetSummaryReq{
+			From:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+			Currency: models.BaseCurrency,
+		},
+		GroupBy: models.GroupByTag,
+	}
+	reqBody, _ := json.Marshal(groupedReq)
+	summary := &models.GroupedSummary{Total: "420.00", Currency: "RUB", Groups: []models.GroupCost{
+		{Key: "", Total: "20.00"},
+		{Key: "work", Total: "400.00"},
+	}}
+	mockService.On("GetGroupedSummary", mock.Anything, &groupedReq).Return(summary, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/summary/grouped", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.GetGroupedSummary(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data models.GroupedSummary `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, *summary, resp.Data)
+
+	// Test case 2: Grouping is required
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/summary/grouped", bytes.NewBufferString(`{"from": "2025-01-01T00:00:00Z"}`)).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.GetGroupedSummary(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Test case 3: Listing filtered by category and tags
+	category := "video"
+	filter := models.SubscriptionFilter{Category: &category, Tags: []string{"family", "work"}}
+	mockService.On("ListSubs", mock.Anything, filter, models.ListParams{Limit: defaultPageLimit, SortBy: models.SortByStartDate}).
+		Return(&models.SubsPage{Items: []models.Subscription{}}, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?category=video&tags=Work,family", nil).WithContext(ctx)
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetBreakdown(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Successful breakdown retrieval
+	breakdownReq := models.GetBreakdownReq{
+		GetSummaryReq: models.GetSummaryReq{
+			From:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+			Currency: models.BaseCurrency,
+		},
+		GroupBy: models.GroupByServiceName,
+	}
+	reqBody, _ := json.Marshal(breakdownReq)
+	breakdown := []models.MonthlyCost{
+		{Month: "01-2025", Total: "100.00", Groups: []models.GroupCost{{Key: "Service A", Total: "100.00"}}},
+		{Month: "02-2025", Total: "0.00"},
+	}
+
+	mockService.On("GetBreakdown", mock.Anything, &breakdownReq).Return(breakdown, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/summary/monthly", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.GetBreakdown(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp models.Response
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Successfully get breakdown", resp.Msg)
+	assert.Len(t, resp.Data, 2)
+	mockService.AssertExpectations(t)
+
+	// Test case 2: Invalid group by
+	reqBody = []byte(`{"group_by": "price"}`)
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions/summary/monthly", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+
+	handler.GetBreakdown(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Invalid request body: invalid group by", resp.Msg)
+	mockService.AssertExpectations(t)
+
+	// Test case 3: Service error
+	mockService.On("GetBreakdown", mock.Anything, &breakdownReq).Return([]models.MonthlyCost{}, errors.New("service breakdown error")).Once()
+	reqBody, _ = json.Marshal(breakdownReq)
+	req = httptest.NewRequest(http.MethodPost, "/subscriptions/summary/monthly", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+
+	handler.GetBreakdown(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&resp)
+	assert.Equal(t, "Failed to get breakdown", resp.Msg)
+	mockService.AssertExpectations(t)
+}
+
+func TestExportSubs(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	minPrice := int64(10000)
+	filter := models.SubscriptionFilter{MinPrice: &minPrice}
+	params := models.ListParams{Limit: defaultPageLimit, SortBy: models.SortByPrice}
+	endDate, trialEnd := "12-2025", "07-2025"
+	subs := []models.Subscription{
+		{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "Yandex Plus", Price: 40000, Currency: "RUB", BillingInterval: models.IntervalMonth, IntervalCount: 1, UserID: uuid.New(), StartDate: "07-2025",
+			TrialEndDate: &trialEnd, Status: models.StatusActive},
+		{ID: uuid.New(), ServiceID: uuid.New(), ServiceName: "=Netflix", Price: 29999, Currency: "USD", BillingInterval: models.IntervalYear, IntervalCount: 1, UserID: uuid.New(), StartDate: "08-2025", EndDate: &endDate,
+			Status: models.StatusCanceled, Category: "video", Tags: []string{"family", "work"}},
+	}
+
+	// Test case 1: CSV export with filters
+	mockService.On("ExportSubs", mock.Anything, filter, params).Return(subs, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?minPrice=100&sort=price", nil).WithContext(ctx)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=subscriptions.csv`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,service_id,service_name,price,currency,billing_interval,interval_count,user_id,start_date,end_date,trial_end_date,status,category,tags\n"+
+		subs[0].ID.String()+","+subs[0].ServiceID.String()+",Yandex Plus,400.00,RUB,month,1,"+subs[0].UserID.String()+",07-2025,,07-2025,active,,\n"+
+		subs[1].ID.String()+","+subs[1].ServiceID.String()+",'=Netflix,299.99,USD,year,1,"+subs[1].UserID.String()+",08-2025,12-2025,,canceled,video,\"family,work\"\n", rr.Body.String())
+
+	// Test case 2: Empty XLSX export still produces a file
+	mockService.On("ExportSubs", mock.Anything, models.SubscriptionFilter{}, models.ListParams{Limit: defaultPageLimit, SortBy: models.SortByStartDate}).Return([]models.Subscription{}, nil).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions", nil).WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "PK", rr.Body.String()[:2])
+
+	// Test case 3: Error before the first row is reported as usual
+	mockService.On("ExportSubs", mock.Anything, filter, params).Return([]models.Subscription{}, errors.New("db error")).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?minPrice=100&sort=price", nil).WithContext(ctx)
+	req.Header.Set("Accept", "text/csv")
+	rr = httptest.NewRecorder()
+
+	handler.ListSubs(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	// Test case 4: Error in the middle of the download aborts the response
+	mockService.On("ExportSubs", mock.Anything, filter, params).Return(subs[:1], errors.New("connection lost")).Once()
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions?minPrice=100&sort=price", nil).WithContext(ctx)
+	req.Header.Set("Accept", "text/csv")
+	rr = httptest.NewRecorder()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.ListSubs(rr, req) })
+	mockService.AssertExpectations(t)
+}
+
+func TestExportSummary(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	logger, _ := zap.NewDevelopment()
+	ctx := context.WithValue(context.Background(), "logger", logger)
+
+	// Test case 1: Summary as CSV
+	sumReq := models.GetSummaryReq{ServiceName: "Netflix", From: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), Currency: models.BaseCurrency}
+	reqBody, _ := json.Marshal(sumReq)
+	mockService.On("GetSummary", mock.Anything, &sumReq).Return(models.Decimal("900.00"), nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/summary", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+
+	handler.GetSummary(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "from,to,user_id,service_name,total,currency\n01-2025,,,Netflix,900.00,RUB\n", rr.Body.String())
+
+	// Test case 2: Grouped breakdown as CSV has a row per group
+	breakdownReq := models.GetBreakdownReq{GetSummaryReq: models.GetSummaryReq{Currency: models.BaseCurrency}, GroupBy: models.GroupByServiceName}
+	reqBody, _ = json.Marshal(breakdownReq)
+	breakdown := []models.MonthlyCost{
+		{Month: "01-2025", Total: "300.00", Groups: []models.GroupCost{{Key: "Netflix", Total: "100.00"}, {Key: "Yandex Plus", Total: "200.00"}}},
+		{Month: "02-2025", Total: "0.00"},
+	}
+	mockService.On("GetBreakdown", mock.Anything, &breakdownReq).Return(breakdown, nil).Once()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/summary/monthly", bytes.NewBuffer(reqBody)).WithContext(ctx)
+	req.Header.Set("Accept", "text/csv")
+	rr = httptest.NewRecorder()
+
+	handler.GetBreakdown(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "month,service_name,total\n01-2025,Netflix,100.00\n01-2025,Yandex Plus,200.00\n", rr.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		format string
+	}{
+		{"", ""},
+		{"*/*", ""},
+		{"application/json", ""},
+		{"text/csv", "csv"},
+		{"text/csv; charset=utf-8", "csv"},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"},
+		{"application/json;q=0.5, text/csv", "csv"},
+		{"text/csv;q=0.8, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"},
+		{"text/csv, */*", ""},
+		{"text/html, text/csv;q=0.1", "csv"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions", nil)
+		req.Header.Set("Accept", tt.accept)
+		assert.Equal(t, tt.format, exportFormat(req), tt.accept)
+	}
 }
 
 func TestValidateSubReq(t *testing.T) {
@@ -473,64 +1987,140 @@ func TestValidateSubReq(t *testing.T) {
 	// Test case 1: Valid request
 	subReq := models.SubReq{
 		ServiceName: "Valid Service",
-		Price:       10,
+		Price:       "10",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     nil,
 	}
-	startDate, endDate, err := handler.validateSubReq(&subReq)
+	sub, err := handler.validateSubReq(&subReq)
 	assert.NoError(t, err)
-	assert.Equal(t, "01-2025", startDate)
-	assert.Nil(t, endDate)
+	assert.Equal(t, int64(1000), sub.Price)
+	assert.Equal(t, models.BaseCurrency, sub.Currency)
+	assert.Equal(t, "2025-01-01", sub.StartDate)
+	assert.Nil(t, sub.EndDate)
 
-	// Test case 2: Valid request with EndDate
+	// Test case 2: Valid request with EndDate, a month ending on its last day
 	endDateStr := "12-2025"
 	subReqWithEndDate := models.SubReq{
 		ServiceName: "Valid Service",
-		Price:       10,
+		Price:       "10",
 		UserID:      uuid.New(),
 		StartDate:   "01-2025",
 		EndDate:     &endDateStr,
 	}
-	startDate, endDate, err = handler.validateSubReq(&subReqWithEndDate)
+	sub, err = handler.validateSubReq(&subReqWithEndDate)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-01", sub.StartDate)
+	assert.Equal(t, "2025-12-31", *sub.EndDate)
+
+	// ISO 8601 dates and timestamps are kept to the day
+	endDateStr = "2025-03-05T23:30:00+03:00"
+	subReqWithEndDate.StartDate = "2025-01-17"
+	sub, err = handler.validateSubReq(&subReqWithEndDate)
 	assert.NoError(t, err)
-	assert.Equal(t, "01-2025", startDate)
-	assert.Equal(t, "12-2025", *endDate)
+	assert.Equal(t, "2025-01-17", sub.StartDate)
+	assert.Equal(t, "2025-03-05", *sub.EndDate)
 
 	// Test case 3: Invalid ServiceName
 	subReq.ServiceName = ""
-	_, _, err = handler.validateSubReq(&subReq)
+	_, err = handler.validateSubReq(&subReq)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid service name")
 	subReq.ServiceName = "Valid Service" // Reset
 
 	// Test case 4: Invalid Price
-	subReq.Price = 0
-	_, _, err = handler.validateSubReq(&subReq)
+	subReq.Price = "0"
+	_, err = handler.validateSubReq(&subReq)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid price")
-	subReq.Price = 10 // Reset
+	subReq.Price = "10.999"
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "invalid price: more than 2 decimal places in RUB")
+	subReq.Price = "10" // Reset
+
+	// Test case 5: Invalid billing interval
+	subReq.BillingInterval = "daily"
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "invalid billing interval")
+	subReq.BillingInterval = models.IntervalQuarter
+	subReq.IntervalCount = -1
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "invalid interval count")
+	subReq.IntervalCount = 2
+	sub, err = handler.validateSubReq(&subReq)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IntervalQuarter, sub.BillingInterval)
+	assert.Equal(t, 2, sub.IntervalCount)
 
-	// Test case 5: Invalid UserID
+	// Test case 6: Invalid UserID
 	subReq.UserID = uuid.Nil
-	_, _, err = handler.validateSubReq(&subReq)
+	_, err = handler.validateSubReq(&subReq)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid user id")
 	subReq.UserID = uuid.New() // Reset
 
-	// Test case 6: Invalid StartDate format
+	// Test case 7: Invalid StartDate format
 	subReq.StartDate = "2025-01"
-	_, _, err = handler.validateSubReq(&subReq)
+	_, err = handler.validateSubReq(&subReq)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid start date")
 	subReq.StartDate = "01-2025" // Reset
 
-	// Test case 7: Invalid EndDate format
+	// Test case 8: Invalid EndDate format
 	invalidEndDateStr := "2025-12"
 	subReq.EndDate = &invalidEndDateStr
-	_, _, err = handler.validateSubReq(&subReq)
+	_, err = handler.validateSubReq(&subReq)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "invalid end date")
+
+	// Test case 9: EndDate before StartDate
+	earlyEndDateStr := "12-2024"
+	subReq.EndDate = &earlyEndDateStr
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "end date is before start date")
+	subReq.EndDate = &endDateStr // Reset
+
+	// Test case 10: The trial ends within the subscription
+	trialEndStr := "2025-01-14"
+	subReq.TrialEndDate = &trialEndStr
+	sub, err = handler.validateSubReq(&subReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-14", *sub.TrialEndDate)
+	trialEndStr = "2024-12-31"
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "trial end date is before start date")
+	trialEndStr = "2025-03-06"
+	_, err = handler.validateSubReq(&subReq)
+	assert.EqualError(t, err, "trial end date is after end date")
+}
+
+func TestSendError(t *testing.T) {
+	handler := &SubscriptionHandler{}
+
+	cases := []struct {
+		err    error
+		status int
+		msg    string
+	}{
+		{fmt.Errorf("subscription %w", service.ErrNotFound), http.StatusNotFound, "Subscription not found"},
+		{fmt.Errorf("failed to create subscription: %w", service.ErrConflict), http.StatusConflict, "Failed: conflict"},
+		{fmt.Errorf("subscription version 1: %w", service.ErrPreconditionFailed), http.StatusPreconditionFailed, "Failed: subscription was modified"},
+		{&service.ValidationError{Reason: "period end is before its start"}, http.StatusUnprocessableEntity, "Failed: period end is before its start"},
+		{fmt.Errorf("failed: %w", service.ErrValidation), http.StatusUnprocessableEntity, "Failed: validation failed"},
+		{fmt.Errorf("failed: %w", service.ErrConstraintViolation), http.StatusUnprocessableEntity, "Failed: constraint violation"},
+		{errors.New("db error"), http.StatusInternalServerError, "Failed"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+
+		handler.sendError(w, c.err, "Failed")
+
+		assert.Equal(t, c.status, w.Code, c.err.Error())
+		var resp models.Response
+		json.NewDecoder(w.Body).Decode(&resp)
+		assert.Equal(t, c.status, resp.Status)
+		assert.Equal(t, c.msg, resp.Msg)
+	}
 }
 
 func TestSendResponse(t *testing.T) {
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// idempotencyKeyHeader is the request header identifying retries of the same request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength matches the size of the key column.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with the response and replayed with it.
+var replayedHeaders = []string{"ETag"}
+
+// responseRecorder passes the response through to the client while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// serveIdempotent calls handle at most once per Idempotency-Key header value.
+// The first response for a key is stored with its replayedHeaders and replayed for repeated requests with the same body;
+// a repeated key with a different body is rejected. Requests without the header are handled as usual.
+// Server errors are not stored, so a failed request can be retried with the same key.
+func (h *SubscriptionHandler) serveIdempotent(w http.ResponseWriter, r *http.Request, body []byte, message string, handle func(w http.ResponseWriter)) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		handle(w)
+		return
+	}
+
+	log := r.Context().Value("logger").(*zap.Logger).With(zap.String("idempotency_key", key))
+	if len(key) > maxIdempotencyKeyLength {
+		log.Warn("Idempotency key is too long")
+		h.sendResponse(w, nil, "Invalid Idempotency-Key header", http.StatusBadRequest)
+		return
+	}
+
+	hash := sha256.Sum256(body)
+	record, err := h.service.BeginIdempotentRequest(r.Context(), key, hex.EncodeToString(hash[:]))
+	if err != nil {
+		log.Warn("Failed to begin idempotent request", zap.Error(err))
+		h.sendError(w, err, message)
+		return
+	}
+	if record != nil {
+		log.Info("Replaying stored response")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		for name, value := range record.Headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Response)
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: w}
+	handle(rec)
+
+	// The outcome is recorded even if the client has gone away in the meantime.
+	ctx := context.WithoutCancel(r.Context())
+	if rec.status >= http.StatusInternalServerError || rec.status == 0 {
+		if err := h.service.AbortIdempotentRequest(ctx, key); err != nil {
+			log.Error("Failed to release idempotency key", zap.Error(err))
+		}
+		return
+	}
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := w.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if err := h.service.CompleteIdempotentRequest(ctx, key, rec.status, headers, rec.body.Bytes()); err != nil {
+		log.Error("Failed to store idempotent response", zap.Error(err))
+	}
+}
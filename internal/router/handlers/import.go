@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/importer"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+)
+
+// ImportSubs handles the import of subscriptions from a CSV or NDJSON file sent as the request body.
+// The file is parsed while it is being uploaded; rows that fail validation are skipped
+// and listed by line number in the report, the others are created.
+// @Summary Импортировать подписки из файла
+// @Description Создает подписки из файла CSV (с заголовком) или NDJSON, переданного в теле запроса.
+// @Description Строки с ошибками пропускаются и перечисляются в отчете с номерами строк, остальные подписки создаются.
+// @Description Параметр mapping задает соответствие полей подписки столбцам файла: service_name:Service,price:Amount.
+// @Tags subscriptions
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Формат файла: csv или ndjson; по умолчанию определяется по Content-Type"
+// @Param mapping query string false "Соответствие полей столбцам файла"
+// @Param delimiter query string false "Разделитель полей CSV, по умолчанию запятая"
+// @Param file body string true "Содержимое файла"
+// @Success 200 {object} models.Response{data=models.ImportReport}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubs(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling import subscriptions")
+	opts, err := importOptions(r)
+	if err != nil {
+		log.Warn("Invalid import parameters", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid import parameters: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	reader, err := importer.NewReader(r.Body, opts)
+	if err != nil {
+		log.Warn("Invalid import file", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid import file: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.ImportSubs(r.Context(), reader)
+	if err != nil {
+		log.Error("Failed to import subscriptions", zap.Error(err))
+		h.sendError(w, err, "Failed to import subscriptions")
+		return
+	}
+
+	log.Info("Subscriptions imported", zap.Int64("imported", report.Imported), zap.Int("rejected", report.Rejected))
+	h.sendResponse(w, report, "Subscriptions imported", http.StatusOK)
+}
+
+// importOptions reads the file format, column mapping and CSV delimiter of an import request.
+// Without the format parameter the format is taken from the Content-Type header.
+func importOptions(r *http.Request) (importer.Options, error) {
+	query := r.URL.Query()
+	opts := importer.Options{Format: query.Get("format")}
+
+	if opts.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			opts.Format = importer.FormatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			opts.Format = importer.FormatNDJSON
+		default:
+			return opts, errors.New("format is required for Content-Type other than text/csv or application/x-ndjson")
+		}
+	}
+	if opts.Format != importer.FormatCSV && opts.Format != importer.FormatNDJSON {
+		return opts, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+
+	var err error
+	if opts.Mapping, err = importer.ParseMapping(query.Get("mapping")); err != nil {
+		return opts, err
+	}
+
+	if delimiter := query.Get("delimiter"); delimiter != "" {
+		if opts.Comma, err = importer.ParseDelimiter(delimiter); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// legacyRoutesDeprecatedAt is the moment the unversioned routes were superseded by the /api/v1 ones.
+var legacyRoutesDeprecatedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// deprecate marks the response of a legacy route as deprecated (RFC 9745)
+// and points the client to the route that replaces it.
+// It returns the request marked as coming through a legacy route, see legacyDates.
+func deprecate(w http.ResponseWriter, r *http.Request, successor string) *http.Request {
+	w.Header().Set("Deprecation", fmt.Sprintf("@%d", legacyRoutesDeprecatedAt.Unix()))
+	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+	return r.WithContext(context.WithValue(r.Context(), legacyRouteKey{}, true))
+}
+
+// legacyRouteKey is the context key marking the requests of legacy routes.
+type legacyRouteKey struct{}
+
+// legacyDates reports whether the subscription dates of the response to r are sent in MM-YYYY form.
+// The clients of the legacy routes were written when dates had month precision, so they keep
+// receiving them in that form; the /api/v1 routes send YYYY-MM-DD.
+func legacyDates(r *http.Request) bool {
+	legacy, _ := r.Context().Value(legacyRouteKey{}).(bool)
+	return legacy
+}
+
+// wireSubscription returns the subscription as it is sent in the response to r:
+// a copy with MM-YYYY dates on legacy routes, sub itself otherwise.
+func wireSubscription(r *http.Request, sub *models.Subscription) *models.Subscription {
+	if !legacyDates(r) {
+		return sub
+	}
+	legacy := *sub
+	legacy.StartDate = legacyMonth(sub.StartDate)
+	legacy.EndDate = legacyMonthPtr(sub.EndDate)
+	legacy.TrialEndDate = legacyMonthPtr(sub.TrialEndDate)
+	return &legacy
+}
+
+// wirePage returns the page of subscriptions as it is sent in the response to r, see wireSubscription.
+func wirePage(r *http.Request, page *models.SubsPage) *models.SubsPage {
+	if !legacyDates(r) {
+		return page
+	}
+	legacy := &models.SubsPage{Items: make([]models.Subscription, len(page.Items)), NextCursor: page.NextCursor}
+	for i := range page.Items {
+		legacy.Items[i] = *wireSubscription(r, &page.Items[i])
+	}
+	return legacy
+}
+
+// legacyMonth formats a YYYY-MM-DD date as the MM-YYYY month it falls in.
+// A value that is not such a date is returned as is.
+func legacyMonth(date string) string {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return day.Format("01-2006")
+}
+
+// legacyMonthPtr is legacyMonth for an optional date.
+func legacyMonthPtr(date *string) *string {
+	if date == nil {
+		return nil
+	}
+	month := legacyMonth(*date)
+	return &month
+}
+
+// LegacyCreateSubs serves the deprecated POST /subscriptions route as an alias of CreateSubs.
+// @Summary Создать новую подписку (устаревший маршрут)
+// @Description Устаревший маршрут, используйте POST /api/v1/subscriptions.
+// @Description Даты подписки в ответе передаются в прежнем формате MM-YYYY.
+// @Description Создает новую подписку для пользователя.
+// @Description При повторе запроса с тем же заголовком Idempotency-Key и телом возвращается сохраненный ответ вместе с заголовком ETag.
+// @Description Пока первый запрос с ключом выполняется, повтор получает 409; если ответ не сохранен за 5 минут, повтор выполняется заново.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности запроса"
+// @Param subscription body models.SubReq true "Данные подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Failure 400 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions [post]
+func (h *SubscriptionHandler) LegacyCreateSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions")
+	h.CreateSubs(w, r)
+}
+
+// LegacyGetSubs serves the deprecated GET /subscriptions?id= route as an alias of GetSubs.
+// @Summary Получить подписку по ID (устаревший маршрут)
+// @Description Устаревший маршрут, используйте GET /api/v1/subscriptions/{id}.
+// @Description Даты подписки в ответе передаются в прежнем формате MM-YYYY.
+// @Description Возвращает подписку по её идентификатору
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id query string true "ID подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions [get]
+func (h *SubscriptionHandler) LegacyGetSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/"+url.PathEscape(subscriptionID(r)))
+	h.GetSubs(w, r)
+}
+
+// LegacyUpdateSubs serves the deprecated PUT /subscriptions?id= route as an alias of UpdateSubs.
+// @Summary Обновить подписку (устаревший маршрут)
+// @Description Устаревший маршрут, используйте PUT /api/v1/subscriptions/{id}.
+// @Description Даты подписки в ответе передаются в прежнем формате MM-YYYY.
+// @Description Обновляет данные существующей подписки.
+// @Description Заголовок If-Match должен содержать ETag, полученный при чтении подписки; если подписка была изменена, возвращается 412.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id query string true "ID подписки"
+// @Param If-Match header string true "ETag подписки"
+// @Param subscription body models.SubReq true "Новые данные подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 428 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions [put]
+func (h *SubscriptionHandler) LegacyUpdateSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/"+url.PathEscape(subscriptionID(r)))
+	h.UpdateSubs(w, r)
+}
+
+// LegacyPatchSubs serves the deprecated PATCH /subscriptions/{id} route as an alias of PatchSubs.
+// @Summary Частично обновить подписку (устаревший маршрут)
+// @Description Устаревший маршрут, используйте PATCH /api/v1/subscriptions/{id}.
+// @Description Даты подписки в ответе передаются в прежнем формате MM-YYYY.
+// @Description Изменяет только переданные поля подписки (JSON Merge Patch, RFC 7396).
+// @Description Значение null в поле end_date удаляет дату окончания. Заголовок If-Match должен содержать ETag подписки.
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string true "ETag подписки"
+// @Param patch body models.SubReq true "Изменяемые поля подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 415 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 428 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) LegacyPatchSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/"+url.PathEscape(subscriptionID(r)))
+	h.PatchSubs(w, r)
+}
+
+// LegacyDeleteSubs serves the deprecated DELETE /subscriptions?id= route as an alias of DeleteSubs.
+// @Summary Удалить подписку (устаревший маршрут)
+// @Description Устаревший маршрут, используйте DELETE /api/v1/subscriptions/{id}.
+// @Description Удаляет подписку по её идентификатору
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id query string true "ID подписки"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions [delete]
+func (h *SubscriptionHandler) LegacyDeleteSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/"+url.PathEscape(subscriptionID(r)))
+	h.DeleteSubs(w, r)
+}
+
+// LegacyListSubs serves the deprecated GET /all-subscriptions route as an alias of ListSubs.
+// @Summary Получить список подписок (устаревший маршрут)
+// @Description Устаревший маршрут, используйте GET /api/v1/subscriptions.
+// @Description Даты подписки в ответе передаются в прежнем формате MM-YYYY.
+// @Description Возвращает страницу подписок с возможностью фильтрации и сортировки.
+// @Description Для получения следующей страницы передайте next_cursor в параметре cursor с тем же sort.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet все подходящие подписки выгружаются файлом CSV или XLSX без постраничной разбивки.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param userId query string false "ID пользователя для фильтрации"
+// @Param serviceName query string false "Название сервиса для фильтрации"
+// @Param userIds query string false "Список ID пользователей через запятую"
+// @Param serviceNamePrefix query string false "Начало названия сервиса (без учета регистра)"
+// @Param currency query string false "Код валюты ISO 4217: только подписки в этой валюте"
+// @Param minPrice query string false "Минимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param maxPrice query string false "Максимальная цена, десятичное число в валюте currency (по умолчанию в рублях)"
+// @Param activeOn query string false "Месяц MM-YYYY, в котором подписка активна"
+// @Param startFrom query string false "Дата начала не раньше MM-YYYY"
+// @Param startTo query string false "Дата начала не позже MM-YYYY"
+// @Param endFrom query string false "Дата окончания не раньше MM-YYYY"
+// @Param endTo query string false "Дата окончания не позже MM-YYYY"
+// @Param openEnded query bool false "Только подписки без даты окончания"
+// @Param limit query int false "Размер страницы (1-1000, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Поле и направление сортировки: price, start_date, service_name с суффиксом :asc или :desc (по умолчанию start_date:asc)"
+// @Success 200 {object} models.Response{data=models.SubsPage}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /all-subscriptions [get]
+func (h *SubscriptionHandler) LegacyListSubs(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions")
+	h.ListSubs(w, r)
+}
+
+// LegacyGetSummary serves the deprecated POST /subscriptions/summary route as an alias of GetSummary.
+// @Summary Получить суммарную стоимость (устаревший маршрут)
+// @Description Устаревший маршрут, используйте POST /api/v1/subscriptions/summary.
+// @Description Возвращает суммарную стоимость подписок за период с фильтрацией.
+// @Description Каждая подписка учитывается за каждый месяц, в котором она активна внутри периода.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet результат выгружается файлом CSV или XLSX.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param summary body models.GetSummaryReq true "Параметры выборки"
+// @Success 200 {object} models.Response{data=object{total=string,currency=string}}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions/summary [post]
+func (h *SubscriptionHandler) LegacyGetSummary(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/summary")
+	h.GetSummary(w, r)
+}
+
+// LegacyGetBreakdown serves the deprecated POST /subscriptions/summary/monthly route as an alias of GetBreakdown.
+// @Summary Получить помесячную стоимость (устаревший маршрут)
+// @Description Устаревший маршрут, используйте POST /api/v1/subscriptions/summary/monthly.
+// @Description Возвращает стоимость подписок за каждый месяц периода с фильтрацией.
+// @Description При указании group_by (service_name или user_id) стоимость месяца разбивается по группам.
+// @Description С заголовком Accept: text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet результат выгружается файлом CSV или XLSX.
+// @Tags subscriptions
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Accept header string false "Формат ответа: application/json, text/csv или application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+// @Param breakdown body models.GetBreakdownReq true "Параметры выборки"
+// @Success 200 {object} models.Response{data=[]models.MonthlyCost}
+// @Failure 400 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Deprecated
+// @Router /subscriptions/summary/monthly [post]
+func (h *SubscriptionHandler) LegacyGetBreakdown(w http.ResponseWriter, r *http.Request) {
+	r = deprecate(w, r, "/api/v1/subscriptions/summary/monthly")
+	h.GetBreakdown(w, r)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// PauseSubs handles pausing an active subscription; paused months are not included in the cost.
+// @Summary Приостановить подписку
+// @Description Приостанавливает активную подписку с текущего месяца; месяцы приостановки не учитываются в стоимости.
+// @Description Заголовок If-Match необязателен; если он передан, подписка должна иметь указанную версию.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubs(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, "pause", h.service.PauseSubs)
+}
+
+// ResumeSubs handles resuming a paused subscription from the current month.
+// @Summary Возобновить подписку
+// @Description Возобновляет приостановленную подписку; текущий месяц снова оплачивается.
+// @Description Заголовок If-Match необязателен; если он передан, подписка должна иметь указанную версию.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubs(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, "resume", h.service.ResumeSubs)
+}
+
+// CancelSubs handles canceling an active or paused subscription by setting its end month.
+// @Summary Отменить подписку
+// @Description Отменяет подписку, устанавливая месяц окончания: по окончании текущего месяца (period_end, по умолчанию)
+// @Description или немедленно (immediate), тогда текущий месяц не оплачивается.
+// @Description Заголовок If-Match необязателен; если он передан, подписка должна иметь указанную версию.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param effective query string false "Момент отмены: period_end или immediate"
+// @Param If-Match header string false "ETag подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubs(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("effective")
+	switch mode {
+	case "":
+		mode = models.CancelAtPeriodEnd
+	case models.CancelAtPeriodEnd, models.CancelImmediately:
+	default:
+		h.sendResponse(w, nil, "Invalid effective parameter", http.StatusBadRequest)
+		return
+	}
+	h.changeStatus(w, r, "cancel", func(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+		return h.service.CancelSubs(ctx, id, version, mode)
+	})
+}
+
+// ReactivateSubs handles making a canceled subscription active again.
+// @Summary Возобновить отмененную подписку
+// @Description Снова делает отмененную подписку активной без даты окончания.
+// @Description Месяцы между окончанием подписки и текущим месяцем не оплачиваются.
+// @Description Заголовок If-Match необязателен; если он передан, подписка должна иметь указанную версию.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки"
+// @Success 200 {object} models.Response{data=models.Subscription}
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 412 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/subscriptions/{id}/reactivate [post]
+func (h *SubscriptionHandler) ReactivateSubs(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, "reactivate", h.service.ReactivateSubs)
+}
+
+// changeStatus performs a lifecycle operation on the subscription in the URL path.
+// The If-Match header is optional: without it the operation applies to the current version.
+func (h *SubscriptionHandler) changeStatus(w http.ResponseWriter, r *http.Request, action string,
+	apply func(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error)) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling subscription status change", zap.String("action", action))
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		log.Warn("Invalid id parameter", zap.String("id", r.PathValue("id")))
+		h.sendResponse(w, nil, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil && !errors.Is(err, errMissingIfMatch) {
+		log.Warn("Invalid If-Match header", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid If-Match header: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := apply(r.Context(), id, version)
+	if err != nil {
+		log.Warn("Failed to change subscription status", zap.String("action", action), zap.Error(err))
+		h.sendError(w, err, fmt.Sprintf("Failed to %s subscription", action))
+		return
+	}
+
+	log.Info("Subscription status changed", zap.String("action", action), zap.String("status", sub.Status))
+	setETag(w, sub.Version)
+	h.sendResponse(w, sub, fmt.Sprintf("Successfully %s subscription", pastTense[action]), http.StatusOK)
+}
+
+// pastTense holds the past tense of the lifecycle operations for response messages.
+var pastTense = map[string]string{
+	"pause":      "paused",
+	"resume":     "resumed",
+	"cancel":     "canceled",
+	"reactivate": "reactivated",
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// mergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396).
+const mergePatchContentType = "application/merge-patch+json"
+
+// applyMergePatch applies a JSON Merge Patch document to the subscription data.
+// Only the members present in the patch change; a null member removes the field,
+// which clears end_date and leaves required fields empty so that validation rejects them.
+// Unknown members are reported as an error.
+func applyMergePatch(current *models.SubReq, patch []byte) (*models.SubReq, error) {
+	var changes map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return nil, errors.New("patch must be a JSON object")
+	}
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(original, &merged); err != nil {
+		return nil, err
+	}
+
+	for name, value := range changes {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = value
+	}
+
+	document, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var result models.SubReq
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	return &result, nil
+}
+
+// isMergePatch reports whether the request body is declared as a JSON Merge Patch.
+// Plain application/json is accepted as well, as is a request without a Content-Type.
+func isMergePatch(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchContentType || mediaType == "application/json"
+}
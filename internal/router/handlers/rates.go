@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// ListRates handles listing the exchange rates used to convert subscription prices.
+// @Summary Список курсов валют
+// @Description Возвращает курсы валют к рублю, упорядоченные по валюте и месяцу.
+// @Description Курс действует с указанного месяца до месяца следующего курса той же валюты.
+// @Tags exchange-rates
+// @Produce json
+// @Param currency query string false "Код валюты ISO 4217"
+// @Success 200 {object} models.Response{data=[]models.ExchangeRate}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/exchange-rates [get]
+func (h *SubscriptionHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling list exchange rates")
+
+	var currency string
+	if raw := r.URL.Query().Get("currency"); raw != "" {
+		var err error
+		if currency, err = service.ValidateCurrency(raw); err != nil {
+			log.Warn("Invalid currency", zap.String("currency", raw))
+			h.sendResponse(w, nil, "Invalid currency parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rates, err := h.service.ListRates(r.Context(), currency)
+	if err != nil {
+		log.Warn("Failed to list exchange rates", zap.Error(err))
+		h.sendError(w, err, "Failed to list exchange rates")
+		return
+	}
+	log.Info("Successfully listed exchange rates", zap.Int("count", len(rates)))
+	h.sendResponse(w, rates, "Successfully listed exchange rates", http.StatusOK)
+}
+
+// SetRate handles setting the exchange rate of a currency for a month.
+// @Summary Установить курс валюты
+// @Description Устанавливает стоимость одной единицы валюты в рублях начиная с месяца month (MM-YYYY).
+// @Description Курс, ранее установленный для того же месяца, заменяется. Курс передается строкой, например "92.35".
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param currency path string true "Код валюты ISO 4217"
+// @Param month path string true "Месяц в формате MM-YYYY"
+// @Param rate body object{rate=string} true "Курс"
+// @Success 200 {object} models.Response{data=models.ExchangeRate}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/exchange-rates/{currency}/{month} [put]
+func (h *SubscriptionHandler) SetRate(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling set exchange rate")
+
+	var body struct {
+		Rate string `json:"rate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Warn("Invalid request body")
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate := &models.ExchangeRate{Currency: r.PathValue("currency"), Month: r.PathValue("month"), Rate: body.Rate}
+	if err := service.ValidateRate(rate); err != nil {
+		log.Warn("Invalid exchange rate", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetRate(r.Context(), rate); err != nil {
+		log.Warn("Failed to set exchange rate", zap.Error(err))
+		h.sendError(w, err, "Failed to set exchange rate")
+		return
+	}
+	log.Info("Successfully set exchange rate", zap.String("currency", rate.Currency), zap.String("month", rate.Month))
+	h.sendResponse(w, rate, "Successfully set exchange rate", http.StatusOK)
+}
+
+// DeleteRate handles removing the exchange rate of a currency for a month.
+// @Summary Удалить курс валюты
+// @Description Удаляет курс валюты, установленный для месяца month (MM-YYYY).
+// @Tags exchange-rates
+// @Produce json
+// @Param currency path string true "Код валюты ISO 4217"
+// @Param month path string true "Месяц в формате MM-YYYY"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/exchange-rates/{currency}/{month} [delete]
+func (h *SubscriptionHandler) DeleteRate(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling delete exchange rate")
+
+	// The rate value is irrelevant for deletion; a placeholder lets ValidateRate check the key.
+	rate := &models.ExchangeRate{Currency: r.PathValue("currency"), Month: r.PathValue("month"), Rate: "1"}
+	if err := service.ValidateRate(rate); err != nil {
+		log.Warn("Invalid exchange rate key", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRate(r.Context(), rate.Currency, rate.Month); err != nil {
+		log.Warn("Failed to delete exchange rate", zap.Error(err))
+		h.sendRateError(w, err, "Failed to delete exchange rate")
+		return
+	}
+	log.Info("Successfully deleted exchange rate", zap.String("currency", rate.Currency), zap.String("month", rate.Month))
+	h.sendResponse(w, nil, "Successfully deleted exchange rate", http.StatusOK)
+}
+
+// sendRateError sends the error of an exchange rate operation; see sendError.
+func (h *SubscriptionHandler) sendRateError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrNotFound) {
+		h.sendResponse(w, nil, "Exchange rate not found", http.StatusNotFound)
+		return
+	}
+	h.sendError(w, err, message)
+}
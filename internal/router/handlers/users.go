@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"Effective_Mobile/internal/models"
+	"Effective_Mobile/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// ListUsers handles listing users.
+// @Summary Список пользователей
+// @Description Возвращает пользователей, упорядоченных по имени. Пользователи, которые появились вместе со своими подписками, не имеют имени и email.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.Response{data=[]models.User}
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users [get]
+func (h *SubscriptionHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling list users")
+
+	users, err := h.service.ListUsers(r.Context())
+	if err != nil {
+		log.Warn("Failed to list users", zap.Error(err))
+		h.sendError(w, err, "Failed to list users")
+		return
+	}
+	log.Info("Successfully listed users", zap.Int("count", len(users)))
+	h.sendResponse(w, users, "Successfully listed users", http.StatusOK)
+}
+
+// CreateUser handles registering a user.
+// @Summary Создать пользователя
+// @Description Регистрирует пользователя. Email уникален без учета регистра. Валюта по умолчанию — RUB, часовой пояс — UTC;
+// @Description часовой пояс задается названием из базы IANA, например Europe/Moscow. Месячный бюджет передается десятичной строкой в валюте пользователя.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body models.UserReq true "Данные пользователя"
+// @Success 200 {object} models.Response{data=models.User}
+// @Failure 400 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users [post]
+func (h *SubscriptionHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling create user")
+
+	user, ok := h.decodeUserReq(w, log, r)
+	if !ok {
+		return
+	}
+	user.ID = uuid.New()
+
+	if err := h.service.CreateUser(r.Context(), user); err != nil {
+		log.Warn("Failed to create user", zap.Error(err))
+		h.sendUserError(w, err, "Failed to create user")
+		return
+	}
+	log.Info("Successfully created user", zap.String("id", user.ID.String()))
+	h.sendResponse(w, user, "Successfully created user", http.StatusOK)
+}
+
+// GetUser handles retrieving a user by its ID.
+// @Summary Получить пользователя по ID
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.Response{data=models.User}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id} [get]
+func (h *SubscriptionHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling get user")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	user, err := h.service.GetUser(r.Context(), id)
+	if err != nil {
+		log.Warn("Failed to get user", zap.Error(err))
+		h.sendUserError(w, err, "Failed to get user")
+		return
+	}
+	log.Info("Successfully got user", zap.String("id", id.String()))
+	h.sendResponse(w, user, "Successfully got user", http.StatusOK)
+}
+
+// UpdateUser handles replacing a user.
+// @Summary Обновить пользователя
+// @Description Заменяет данные пользователя. Поля, которые не переданы, получают значения по умолчанию, как при создании.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param user body models.UserReq true "Данные пользователя"
+// @Success 200 {object} models.Response{data=models.User}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id} [put]
+func (h *SubscriptionHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling update user")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	user, ok := h.decodeUserReq(w, log, r)
+	if !ok {
+		return
+	}
+	user.ID = id
+
+	if err := h.service.UpdateUser(r.Context(), user); err != nil {
+		log.Warn("Failed to update user", zap.Error(err))
+		h.sendUserError(w, err, "Failed to update user")
+		return
+	}
+	log.Info("Successfully updated user", zap.String("id", id.String()))
+	h.sendResponse(w, user, "Successfully updated user", http.StatusOK)
+}
+
+// DeleteUser handles removing a user.
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя. Пользователя, у которого есть подписки, удалить нельзя (409).
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id} [delete]
+func (h *SubscriptionHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling delete user")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteUser(r.Context(), id); err != nil {
+		log.Warn("Failed to delete user", zap.Error(err))
+		h.sendUserError(w, err, "Failed to delete user")
+		return
+	}
+	log.Info("Successfully deleted user", zap.String("id", id.String()))
+	h.sendResponse(w, nil, "Successfully deleted user", http.StatusOK)
+}
+
+// GetUserOverview handles the spending overview of a user.
+// @Summary Сводка расходов пользователя
+// @Description Возвращает сводку расходов пользователя в его валюте на текущий день в его часовом поясе: число активных подписок,
+// @Description стоимость текущего месяца, расходы с начала года по текущий месяц включительно, месячный бюджет
+// @Description и до пяти ближайших списаний активных подписок по их текущей цене.
+// @Description Если для пересчета в валюту пользователя не хватает курса, возвращается 422.
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.Response{data=models.UserOverview}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 422 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/users/{id}/overview [get]
+func (h *SubscriptionHandler) GetUserOverview(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value("logger").(*zap.Logger)
+
+	log.Info("Handling get user overview")
+
+	id, ok := h.pathID(w, log, r)
+	if !ok {
+		return
+	}
+	overview, err := h.service.GetUserOverview(r.Context(), id)
+	if err != nil {
+		log.Warn("Failed to get user overview", zap.Error(err))
+		h.sendUserError(w, err, "Failed to get user overview")
+		return
+	}
+	log.Info("Successfully got user overview", zap.String("id", id.String()))
+	h.sendResponse(w, overview, "Successfully got user overview", http.StatusOK)
+}
+
+// decodeUserReq decodes and validates the user described by the request body.
+// On failure it sends a 400 response and returns false.
+func (h *SubscriptionHandler) decodeUserReq(w http.ResponseWriter, log *zap.Logger, r *http.Request) (*models.User, bool) {
+	var req models.UserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	user, err := service.ValidateUserReq(&req)
+	if err != nil {
+		log.Warn("Invalid request body", zap.Error(err))
+		h.sendResponse(w, nil, fmt.Sprintf("Invalid request body: %s", err), http.StatusBadRequest)
+		return nil, false
+	}
+	return user, true
+}
+
+// sendUserError sends the error of a user operation; see sendError.
+func (h *SubscriptionHandler) sendUserError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrNotFound) {
+		h.sendResponse(w, nil, "User not found", http.StatusNotFound)
+		return
+	}
+	h.sendError(w, err, message)
+}
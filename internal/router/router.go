@@ -5,6 +5,7 @@ import (
 	"Effective_Mobile/internal/router/handlers"
 	"context"
 	"golang.org/x/time/rate"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -31,7 +32,10 @@ func NewRouter(subsHandler *handlers.SubscriptionHandler, log *zap.Logger) *Rout
 	}
 }
 
-func (r *Router) RunRouter(addr string, requestPerSec int, burst int) error {
+// RunRouter starts the HTTP server and blocks until it fails or a termination signal is received.
+// Every request context derives from a base context that is canceled once the shutdown timeout
+// expires, so database queries still running at that point are aborted.
+func (r *Router) RunRouter(addr string, requestPerSec int, burst int, shutdownTimeout time.Duration) error {
 	// Apply rate limiting middleware to all routes
 	// 1 request per second, with a burst of 5 requests
 	rateLimitedMux := middleware.RateLimiterMiddleware(rate.Limit(requestPerSec), burst, r.log)(r.mux)
@@ -41,16 +45,60 @@ func (r *Router) RunRouter(addr string, requestPerSec int, burst int) error {
 	r.mux.HandleFunc("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"), // URL указывает на сгенерированный файл
 	))
-	r.mux.HandleFunc("POST /subscriptions", r.subsHandler.CreateSubs)
-	r.mux.HandleFunc("GET /subscriptions", r.subsHandler.GetSubs)
-	r.mux.HandleFunc("PUT /subscriptions", r.subsHandler.UpdateSubs)
-	r.mux.HandleFunc("DELETE /subscriptions", r.subsHandler.DeleteSubs)
-	r.mux.HandleFunc("POST /subscriptions/summary", r.subsHandler.GetSummary)
-	r.mux.HandleFunc("GET /all-subscriptions", r.subsHandler.ListSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions", r.subsHandler.CreateSubs)
+	r.mux.HandleFunc("GET /api/v1/subscriptions", r.subsHandler.ListSubs)
+	r.mux.HandleFunc("GET /api/v1/subscriptions/{id}", r.subsHandler.GetSubs)
+	r.mux.HandleFunc("PUT /api/v1/subscriptions/{id}", r.subsHandler.UpdateSubs)
+	r.mux.HandleFunc("PATCH /api/v1/subscriptions/{id}", r.subsHandler.PatchSubs)
+	r.mux.HandleFunc("DELETE /api/v1/subscriptions/{id}", r.subsHandler.DeleteSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/{id}/pause", r.subsHandler.PauseSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/{id}/resume", r.subsHandler.ResumeSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/{id}/cancel", r.subsHandler.CancelSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/{id}/reactivate", r.subsHandler.ReactivateSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/batch", r.subsHandler.BatchCreateSubs)
+	r.mux.HandleFunc("PUT /api/v1/subscriptions/batch", r.subsHandler.BatchUpdateSubs)
+	r.mux.HandleFunc("DELETE /api/v1/subscriptions/batch", r.subsHandler.BatchDeleteSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/import", r.subsHandler.ImportSubs)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/summary", r.subsHandler.GetSummary)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/summary/monthly", r.subsHandler.GetBreakdown)
+	r.mux.HandleFunc("POST /api/v1/subscriptions/summary/grouped", r.subsHandler.GetGroupedSummary)
+	r.mux.HandleFunc("GET /api/v1/users/{user_id}/subscriptions", r.subsHandler.ListUserSubs)
+	r.mux.HandleFunc("GET /api/v1/exchange-rates", r.subsHandler.ListRates)
+	r.mux.HandleFunc("PUT /api/v1/exchange-rates/{currency}/{month}", r.subsHandler.SetRate)
+	r.mux.HandleFunc("DELETE /api/v1/exchange-rates/{currency}/{month}", r.subsHandler.DeleteRate)
+	r.mux.HandleFunc("GET /api/v1/services", r.subsHandler.ListServices)
+	r.mux.HandleFunc("POST /api/v1/services", r.subsHandler.CreateService)
+	r.mux.HandleFunc("GET /api/v1/services/{id}", r.subsHandler.GetService)
+	r.mux.HandleFunc("PUT /api/v1/services/{id}", r.subsHandler.UpdateService)
+	r.mux.HandleFunc("DELETE /api/v1/services/{id}", r.subsHandler.DeleteService)
+	r.mux.HandleFunc("GET /api/v1/users", r.subsHandler.ListUsers)
+	r.mux.HandleFunc("POST /api/v1/users", r.subsHandler.CreateUser)
+	r.mux.HandleFunc("GET /api/v1/users/{id}", r.subsHandler.GetUser)
+	r.mux.HandleFunc("PUT /api/v1/users/{id}", r.subsHandler.UpdateUser)
+	r.mux.HandleFunc("DELETE /api/v1/users/{id}", r.subsHandler.DeleteUser)
+	r.mux.HandleFunc("GET /api/v1/users/{id}/overview", r.subsHandler.GetUserOverview)
+	r.mux.HandleFunc("GET /api/v1/users/{id}/budgets", r.subsHandler.ListBudgets)
+	r.mux.HandleFunc("PUT /api/v1/users/{id}/budgets/{category}", r.subsHandler.SetBudget)
+	r.mux.HandleFunc("DELETE /api/v1/users/{id}/budgets/{category}", r.subsHandler.DeleteBudget)
+	r.mux.HandleFunc("GET /api/v1/alerts", r.subsHandler.ListAlerts)
+
+	// Устаревшие маршруты без версии: ответы содержат заголовки Deprecation и Link на новый маршрут
+	r.mux.HandleFunc("POST /subscriptions", r.subsHandler.LegacyCreateSubs)
+	r.mux.HandleFunc("GET /subscriptions", r.subsHandler.LegacyGetSubs)
+	r.mux.HandleFunc("PUT /subscriptions", r.subsHandler.LegacyUpdateSubs)
+	r.mux.HandleFunc("PATCH /subscriptions/{id}", r.subsHandler.LegacyPatchSubs)
+	r.mux.HandleFunc("DELETE /subscriptions", r.subsHandler.LegacyDeleteSubs)
+	r.mux.HandleFunc("POST /subscriptions/summary", r.subsHandler.LegacyGetSummary)
+	r.mux.HandleFunc("POST /subscriptions/summary/monthly", r.subsHandler.LegacyGetBreakdown)
+	r.mux.HandleFunc("GET /all-subscriptions", r.subsHandler.LegacyListSubs)
+
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	r.server = &http.Server{
-		Addr:    addr,
-		Handler: loggingMux(rateLimitedMux),
+		Addr:        addr,
+		Handler:     loggingMux(rateLimitedMux),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	serverErr := make(chan error, 1)
@@ -74,12 +122,18 @@ func (r *Router) RunRouter(addr string, requestPerSec int, burst int) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
 	r.log.Info("Shutting down server...")
 	if err := r.server.Shutdown(ctx); err != nil {
+		// Requests did not finish in time: cancel their contexts to abort running queries.
 		r.log.Error("Forced shutdown", zap.Error(err))
+		cancelRequests()
+		r.server.Close()
 		return err
 	}
 
@@ -0,0 +1,121 @@
+// Package scheduler runs the background jobs of the service on a fixed cadence.
+package scheduler
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Defaults used when the cadence or the lead time is not configured.
+const (
+	DefaultInterval         = time.Hour
+	DefaultDeliveryInterval = time.Minute
+	DefaultLeadDays         = 3
+)
+
+// batchSize is the number of reminders or budget alerts claimed at a time.
+const batchSize = 100
+
+// purgeBatchSize is the number of expired idempotency keys deleted at a time.
+const purgeBatchSize = 1000
+
+// Jobs queues and sends the reminders of upcoming charges and trial ends, sends the budget alerts
+// and purges the expired idempotency keys. It is implemented by service.SubscriptionService.
+type Jobs interface {
+	QueueReminders(ctx context.Context, now time.Time, leadDays int) (int64, error)
+	SendReminders(ctx context.Context, now time.Time, limit int) (int, error)
+	SendAlerts(ctx context.Context, limit int) (int, error)
+	PurgeIdempotencyKeys(ctx context.Context, limit int) (int, error)
+}
+
+// Scheduler purges the expired idempotency keys and queues the reminders of the next leadDays days,
+// if reminders are enabled, every interval, and sends the queued reminders and the budget alerts
+// every deliveryInterval.
+// Several replicas may run a scheduler against the same database: the queue does not hold duplicates
+// and every reminder and alert is claimed by a single replica.
+type Scheduler struct {
+	jobs             Jobs
+	reminders        bool
+	interval         time.Duration
+	deliveryInterval time.Duration
+	leadDays         int
+	log              *zap.Logger
+}
+
+// NewScheduler creates a Scheduler. A non-positive interval, deliveryInterval or leadDays selects DefaultInterval,
+// DefaultDeliveryInterval or DefaultLeadDays. Without reminders only the other jobs run.
+func NewScheduler(jobs Jobs, reminders bool, interval time.Duration, deliveryInterval time.Duration, leadDays int, log *zap.Logger) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if deliveryInterval <= 0 {
+		deliveryInterval = DefaultDeliveryInterval
+	}
+	if leadDays <= 0 {
+		leadDays = DefaultLeadDays
+	}
+	return &Scheduler{jobs: jobs, reminders: reminders, interval: interval, deliveryInterval: deliveryInterval, leadDays: leadDays, log: log}
+}
+
+// Run runs the jobs right away and then on their cadence until ctx is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.log.Info("Scheduler started", zap.Bool("reminders", s.reminders), zap.Duration("interval", s.interval),
+		zap.Duration("delivery_interval", s.deliveryInterval), zap.Int("lead_days", s.leadDays))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	deliveryTicker := time.NewTicker(s.deliveryInterval)
+	defer deliveryTicker.Stop()
+	s.tick(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("Scheduler stopped")
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		case now := <-deliveryTicker.C:
+			s.deliver(ctx, now)
+		}
+	}
+}
+
+// tick purges the expired idempotency keys, queues the reminders due as of now, if reminders are enabled,
+// and delivers what is queued. Failures are logged; the jobs are retried on the next tick.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	s.drain(ctx, "purge idempotency keys", purgeBatchSize, func(ctx context.Context) (int, error) {
+		return s.jobs.PurgeIdempotencyKeys(ctx, purgeBatchSize)
+	})
+	if s.reminders {
+		queued, err := s.jobs.QueueReminders(ctx, now, s.leadDays)
+		if err != nil {
+			s.log.Error("Failed to queue reminders", zap.Error(err))
+		} else if queued > 0 {
+			s.log.Info("Reminders queued", zap.Int64("count", queued))
+		}
+	}
+	s.deliver(ctx, now)
+}
+
+// deliver sends the queued reminders, if reminders are enabled, and the budget alerts in batches
+// until none is left to claim. A failed send ends the delivery of that kind until the next tick.
+func (s *Scheduler) deliver(ctx context.Context, now time.Time) {
+	if s.reminders {
+		s.drain(ctx, "send reminders", batchSize, func(ctx context.Context) (int, error) { return s.jobs.SendReminders(ctx, now, batchSize) })
+	}
+	s.drain(ctx, "send budget alerts", batchSize, func(ctx context.Context) (int, error) { return s.jobs.SendAlerts(ctx, batchSize) })
+}
+
+// drain runs the job until it processes less than a full batch of size items, fails or ctx is canceled.
+func (s *Scheduler) drain(ctx context.Context, what string, size int, job func(ctx context.Context) (int, error)) {
+	for ctx.Err() == nil {
+		processed, err := job(ctx)
+		if err != nil {
+			s.log.Error("Failed to "+what, zap.Error(err))
+			return
+		}
+		if processed < size {
+			return
+		}
+	}
+}
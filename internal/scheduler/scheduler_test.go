@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockJobs struct {
+	mock.Mock
+}
+
+func (m *MockJobs) QueueReminders(ctx context.Context, now time.Time, leadDays int) (int64, error) {
+	args := m.Called(ctx, now, leadDays)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockJobs) SendReminders(ctx context.Context, now time.Time, limit int) (int, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobs) SendAlerts(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockJobs) PurgeIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestTick(t *testing.T) {
+	jobs := new(MockJobs)
+	logger, _ := zap.NewDevelopment()
+	scheduler := NewScheduler(jobs, true, time.Minute, 0, 0, logger)
+	now := time.Date(2025, time.March, 8, 12, 0, 0, 0, time.UTC)
+
+	// Test case 1: Expired idempotency keys are purged, and reminders and alerts are sent, in batches until a batch is not full
+	jobs.On("PurgeIdempotencyKeys", mock.Anything, purgeBatchSize).Return(purgeBatchSize, nil).Once()
+	jobs.On("PurgeIdempotencyKeys", mock.Anything, purgeBatchSize).Return(10, nil).Once()
+	jobs.On("QueueReminders", mock.Anything, now, DefaultLeadDays).Return(int64(150), nil).Once()
+	jobs.On("SendReminders", mock.Anything, now, batchSize).Return(batchSize, nil).Once()
+	jobs.On("SendReminders", mock.Anything, now, batchSize).Return(50, nil).Once()
+	jobs.On("SendAlerts", mock.Anything, batchSize).Return(batchSize, nil).Once()
+	jobs.On("SendAlerts", mock.Anything, batchSize).Return(0, nil).Once()
+	scheduler.tick(context.Background(), now)
+
+	// Test case 2: Queued reminders are still sent if queueing fails; a failed job does not hold up the others
+	jobs.On("PurgeIdempotencyKeys", mock.Anything, purgeBatchSize).Return(0, errors.New("connection refused")).Once()
+	jobs.On("QueueReminders", mock.Anything, now, DefaultLeadDays).Return(int64(0), errors.New("connection refused")).Once()
+	jobs.On("SendReminders", mock.Anything, now, batchSize).Return(0, errors.New("connection refused")).Once()
+	jobs.On("SendAlerts", mock.Anything, batchSize).Return(1, nil).Once()
+	scheduler.tick(context.Background(), now)
+
+	// Test case 3: Without reminders only the keys are purged and the alerts are sent
+	jobs.On("PurgeIdempotencyKeys", mock.Anything, purgeBatchSize).Return(0, nil).Once()
+	jobs.On("SendAlerts", mock.Anything, batchSize).Return(0, nil).Once()
+	NewScheduler(jobs, false, time.Minute, 0, 0, logger).tick(context.Background(), now)
+	jobs.AssertNumberOfCalls(t, "QueueReminders", 2)
+
+	jobs.AssertExpectations(t)
+}
+
+func TestRun(t *testing.T) {
+	jobs := new(MockJobs)
+	logger, _ := zap.NewDevelopment()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The jobs run as soon as the scheduler starts, which stops once the context is canceled.
+	jobs.On("PurgeIdempotencyKeys", mock.Anything, purgeBatchSize).Return(0, nil).Once()
+	jobs.On("QueueReminders", mock.Anything, mock.Anything, 7).Return(int64(0), nil).Once()
+	jobs.On("SendReminders", mock.Anything, mock.Anything, batchSize).Return(0, nil).Once()
+	jobs.On("SendAlerts", mock.Anything, batchSize).Run(func(mock.Arguments) { cancel() }).Return(0, nil).Once()
+	done := make(chan struct{})
+	go func() {
+		NewScheduler(jobs, true, time.Hour, time.Hour, 7, logger).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+	jobs.AssertExpectations(t)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaxBatchSize is the largest number of items accepted by a single batch operation.
+const MaxBatchSize = 5000
+
+// BatchCreateSubs creates several subscriptions in a single transaction.
+// In atomic mode either every subscription is created or, if one fails, none is;
+// in best-effort mode failing subscriptions are skipped.
+// Returns the error of every subscription, in order, and an error if the batch as a whole fails.
+func (c *SubscriptionService) BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(subs)); err != nil {
+		return nil, err
+	}
+	errs, err := c.repository.BatchCreateSubs(ctx, subs, atomic)
+	c.logBatch("create", errs, err)
+	if err == nil {
+		c.checkBudgets(ctx, batchUsers(subs, errs)...)
+	}
+	return errs, err
+}
+
+// BatchUpdateSubs updates several subscriptions in a single transaction.
+// The ID and Version of every subscription select the row and the version it must still have;
+// on success Version holds the new version. The modes and results are those of BatchCreateSubs.
+// The budgets are evaluated for the previous and the new owners of the updated subscriptions.
+func (c *SubscriptionService) BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(subs)); err != nil {
+		return nil, err
+	}
+	oldOwners, errs, err := c.repository.BatchUpdateSubs(ctx, subs, atomic)
+	c.logBatch("update", errs, err)
+	if err == nil {
+		users := batchUsers(subs, errs)
+		for i, owner := range oldOwners {
+			if i < len(errs) && errs[i] == nil {
+				users = append(users, owner)
+			}
+		}
+		c.checkBudgets(ctx, users...)
+	}
+	return errs, err
+}
+
+// BatchDeleteSubs deletes several subscriptions in a single transaction.
+// The modes and results are those of BatchCreateSubs.
+func (c *SubscriptionService) BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error) {
+	if err := checkBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+	errs, err := c.repository.BatchDeleteSubs(ctx, ids, atomic)
+	c.logBatch("delete", errs, err)
+	return errs, err
+}
+
+// batchUsers returns the users of the subscriptions of a batch that were stored successfully.
+func batchUsers(subs []*models.Subscription, errs []error) []uuid.UUID {
+	users := make([]uuid.UUID, 0, len(subs))
+	for i, sub := range subs {
+		if i < len(errs) && errs[i] == nil {
+			users = append(users, sub.UserID)
+		}
+	}
+	return users
+}
+
+// checkBatchSize rejects empty batches and batches larger than MaxBatchSize.
+func checkBatchSize(n int) error {
+	if n == 0 || n > MaxBatchSize {
+		return newValidationError("batch must contain from 1 to %d items, got %d", MaxBatchSize, n)
+	}
+	return nil
+}
+
+// logBatch records the outcome of a batch operation.
+func (c *SubscriptionService) logBatch(operation string, errs []error, err error) {
+	if err != nil {
+		c.log.Error("Batch failed", zap.String("operation", operation), zap.Error(err))
+		return
+	}
+	failed := 0
+	for _, itemErr := range errs {
+		if itemErr != nil {
+			failed++
+		}
+	}
+	c.log.Debug("Batch processed", zap.String("operation", operation), zap.Int("items", len(errs)), zap.Int("failed", failed))
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// billing is the schedule a subscription is charged on: every count intervals, starting on its start date,
+// for as long as it lasts. A zero end means the subscription has no end date. The zero value charges every month.
+// A subscription with a free trial starts on 'started' and is charged from 'start', the day after its trial.
+type billing struct {
+	started  time.Time
+	start    time.Time
+	end      time.Time
+	interval string
+	count    int
+}
+
+// newBilling returns the billing schedule of the subscription.
+func newBilling(sub *models.Subscription) (billing, error) {
+	start, end, err := subscriptionRange(sub)
+	if err != nil {
+		return billing{}, err
+	}
+	b := billing{started: start, start: start, end: end, interval: sub.BillingInterval, count: sub.IntervalCount}
+	if sub.TrialEndDate != nil {
+		trialEnd, err := time.Parse(dateLayout, *sub.TrialEndDate)
+		if err != nil {
+			return billing{}, fmt.Errorf("invalid trial end date %q: %w", *sub.TrialEndDate, err)
+		}
+		b.start = trialEnd.AddDate(0, 0, 1)
+	}
+	return b, nil
+}
+
+// months returns the length of a billing period in months, or 0 for weekly billing.
+func (b billing) months() int {
+	count := max(b.count, 1)
+	switch b.interval {
+	case models.IntervalWeek:
+		return 0
+	case models.IntervalQuarter:
+		return 3 * count
+	case models.IntervalYear:
+		return 12 * count
+	default:
+		return count
+	}
+}
+
+// days returns the length of a weekly billing period in days.
+func (b billing) days() int {
+	return 7 * max(b.count, 1)
+}
+
+// factor returns how many charges of the subscription the month is billed: the number of charges falling in it,
+// or with amortize the share of charges attributed to it. A subscription charged every month is billed for each
+// month as a whole, so that its partial first and last months are billed only for the days it lasts.
+func (b billing) factor(month time.Time, amortize bool) *big.Rat {
+	if amortize || b.months() == 1 {
+		return b.share(month)
+	}
+	return big.NewRat(int64(b.charges(month)), 1)
+}
+
+// charges returns how many times the subscription is charged in the month: once on the day a billing period
+// starts in it, and for weekly billing once for every period starting within the month. Charges falling
+// after the end of the subscription are not counted.
+func (b billing) charges(month time.Time) int {
+	if months := b.months(); months > 0 {
+		n := monthsBetween(b.start, month)
+		if n >= 0 && n%months == 0 && b.lasts(addMonths(b.start, n)) {
+			return 1
+		}
+		return 0
+	}
+	from, to, ok := b.span(month)
+	if !ok {
+		return 0
+	}
+	// Charges fall on the days k*days after the start; count those within the span.
+	days := b.days()
+	first := daysBetween(b.start, from)
+	last := daysBetween(b.start, to) - 1
+	return last/days - (first+days-1)/days + 1
+}
+
+// share returns the part of a charge that is attributed to the month when charges are amortized:
+// an equal part of a billing period for every day of the month the subscription lasts.
+func (b billing) share(month time.Time) *big.Rat {
+	from, to, ok := b.span(month)
+	if !ok {
+		return new(big.Rat)
+	}
+	days := int64(daysBetween(from, to))
+	if months := b.months(); months > 0 {
+		return big.NewRat(days, int64(months*daysBetween(month, month.AddDate(0, 1, 0))))
+	}
+	return big.NewRat(days, int64(b.days()))
+}
+
+// span returns the days of the month the subscription lasts, from 'from' until 'to' exclusive.
+// ok is false if it does not last any day of the month.
+func (b billing) span(month time.Time) (from, to time.Time, ok bool) {
+	from, to = month, month.AddDate(0, 1, 0)
+	if b.start.After(from) {
+		from = b.start
+	}
+	if !b.end.IsZero() && b.end.Before(to) {
+		to = b.end.AddDate(0, 0, 1)
+	}
+	return from, to, to.After(from)
+}
+
+// next returns the first day on or after the given one the subscription is charged on.
+// ok is false if the subscription ends before it is charged again.
+func (b billing) next(day time.Time) (charge time.Time, ok bool) {
+	if day.Before(b.start) {
+		day = b.start
+	}
+	if months := b.months(); months > 0 {
+		n := monthsBetween(b.start, day) / months * months
+		if charge = addMonths(b.start, n); charge.Before(day) {
+			charge = addMonths(b.start, n+months)
+		}
+	} else {
+		days := b.days()
+		charge = b.start.AddDate(0, 0, (daysBetween(b.start, day)+days-1)/days*days)
+	}
+	return charge, b.lasts(charge)
+}
+
+// lasts reports whether the subscription is paid for on the day.
+func (b billing) lasts(day time.Time) bool {
+	return !day.Before(b.start) && (b.end.IsZero() || !day.After(b.end))
+}
+
+// active reports whether the subscription lasts on the day, including the days of its free trial.
+func (b billing) active(day time.Time) bool {
+	return !day.Before(b.started) && (b.end.IsZero() || !day.After(b.end))
+}
+
+// addMonths adds n months to the date. A day that the resulting month does not have is moved
+// to its last day, so that a subscription started on the 31st is charged at the end of shorter months.
+func addMonths(date time.Time, n int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(date.Day(), last)-1)
+}
+
+// daysBetween returns the number of days from 'from' to 'to', both at midnight UTC.
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
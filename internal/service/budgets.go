@@ -0,0 +1,218 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Notifier delivers events to external systems, e.g. by webhook.
+type Notifier interface {
+	Notify(ctx context.Context, event *models.Event) error
+}
+
+// SetBudget sets the monthly budget of a category of the user, replacing the budget set for it.
+// The budget must have been checked with ValidateBudgetReq. Returns ErrNotFound if there is no such user.
+func (c *SubscriptionService) SetBudget(ctx context.Context, budget *models.Budget) error {
+	if err := c.repository.SetBudget(ctx, budget); err != nil {
+		return err
+	}
+	c.log.Info("Budget set", zap.String("user_id", budget.UserID.String()), zap.String("category", budget.Category))
+	return nil
+}
+
+// DeleteBudget removes the budget of the category of the user.
+// Returns ErrNotFound if no budget is set for it.
+func (c *SubscriptionService) DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error {
+	return c.repository.DeleteBudget(ctx, userID, strings.TrimSpace(category))
+}
+
+// ListBudgets returns the budgets of the user: the overall one with an empty category,
+// if the user has a monthly budget, followed by the category budgets ordered by category.
+// Returns ErrNotFound if there is no such user.
+func (c *SubscriptionService) ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	user, err := c.repository.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.userBudgets(ctx, user)
+}
+
+// ListAlerts returns the budget alerts matching the filter, the most recent first.
+func (c *SubscriptionService) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error) {
+	return c.repository.ListAlerts(ctx, filter)
+}
+
+// userBudgets returns the overall budget of the user, if any, followed by its category budgets.
+func (c *SubscriptionService) userBudgets(ctx context.Context, user *models.User) ([]models.Budget, error) {
+	categories, err := c.repository.ListBudgets(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MonthlyBudget == 0 {
+		return categories, nil
+	}
+	overall := models.Budget{UserID: user.ID, Amount: user.MonthlyBudget, Currency: user.Currency}
+	return append([]models.Budget{overall}, categories...), nil
+}
+
+// checkBudgets evaluates the budgets of the users after their subscriptions changed.
+// The change has already been stored, so a failed evaluation is only logged.
+func (c *SubscriptionService) checkBudgets(ctx context.Context, userIDs ...uuid.UUID) {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := c.evaluateBudgets(ctx, id, time.Now()); err != nil {
+			c.log.Error("Failed to evaluate budgets", zap.String("user_id", id.String()), zap.Error(err))
+		}
+	}
+}
+
+// evaluateBudgets compares the cost of the subscriptions of the user in the current month in their time zone
+// with each of their budgets, calculated as in GetSummary in the currency of the budget. A budget that is exceeded
+// raises an alert, once a month, which is sent to the notifier if there is one.
+func (c *SubscriptionService) evaluateBudgets(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	user, err := c.repository.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	budgets, err := c.userBudgets(ctx, user)
+	if err != nil || len(budgets) == 0 {
+		return err
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", user.Timezone, err)
+	}
+	month := monthStart(now.In(loc))
+
+	// The cost of every category, and of all subscriptions under the empty key, in each budget currency.
+	costs := make(map[string]map[string]*big.Rat)
+	for _, budget := range budgets {
+		spent, ok := costs[budget.Currency]
+		if !ok {
+			if spent, err = c.monthCosts(ctx, userID, budget.Currency, month); err != nil {
+				return err
+			}
+			costs[budget.Currency] = spent
+		}
+		cost, ok := spent[budget.Category]
+		if !ok {
+			continue
+		}
+		// Costs are compared as they are reported, rounded to minor units.
+		units, err := models.ParseAmount(formatTotal(cost, budget.Currency), budget.Currency)
+		if err != nil {
+			return err
+		}
+		if units <= budget.Amount {
+			continue
+		}
+		if err := c.raiseAlert(ctx, &budget, month, units); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monthCosts returns the cost of the subscriptions of the user in the month in the currency,
+// per category and in total under the empty key.
+func (c *SubscriptionService) monthCosts(ctx context.Context, userID uuid.UUID, currency string, month time.Time) (map[string]*big.Rat, error) {
+	basis, err := c.costBasis(ctx, &models.GetSummaryReq{From: month, To: month, UserID: &userID, Currency: currency})
+	if err != nil {
+		return nil, err
+	}
+	costs := make(map[string]*big.Rat)
+	for _, sub := range basis.subs {
+		cost, err := basis.period.cost(&sub, basis.timelines[sub.ID], basis.converter)
+		if err != nil {
+			return nil, err
+		}
+		addAmount(costs, "", cost)
+		if sub.Category != "" {
+			addAmount(costs, sub.Category, cost)
+		}
+	}
+	return costs, nil
+}
+
+// raiseAlert records that the budget was exceeded in the month, unless it had already been recorded.
+// The alert is sent to the notifier later by SendAlerts, so that the change that exceeded the budget
+// does not wait for the delivery and a failed delivery is retried.
+func (c *SubscriptionService) raiseAlert(ctx context.Context, budget *models.Budget, month time.Time, spent int64) error {
+	alert := &models.BudgetAlert{
+		ID:       uuid.New(),
+		UserID:   budget.UserID,
+		Category: budget.Category,
+		Month:    month.Format(monthLayout),
+		Budget:   budget.Amount,
+		Spent:    spent,
+		Currency: budget.Currency,
+	}
+	created, err := c.repository.CreateAlert(ctx, alert)
+	if err != nil || !created {
+		return err
+	}
+	c.log.Info("Budget exceeded", zap.String("user_id", alert.UserID.String()), zap.String("category", alert.Category), zap.String("month", alert.Month))
+	return nil
+}
+
+// SendAlerts claims up to limit budget alerts that have not been delivered yet and sends them to the notifier
+// as events whose ID is the ID of the alert, so that a receiver can recognize an alert delivered again.
+// An alert that fails to be delivered is sent again on a later call once its lease expires.
+// Returns the number of alerts claimed; nothing is claimed without a notifier.
+func (c *SubscriptionService) SendAlerts(ctx context.Context, limit int) (int, error) {
+	if c.notifier == nil {
+		return 0, nil
+	}
+	alerts, err := c.repository.ClaimAlerts(ctx, limit, deliveryLease, maxDeliveryAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range alerts {
+		alert := &alerts[i]
+		event := &models.Event{ID: alert.ID, Type: models.EventBudgetExceeded, CreatedAt: alert.CreatedAt, Data: alert}
+		if err := c.notifier.Notify(ctx, event); err != nil {
+			c.log.Warn("Failed to send budget alert", zap.String("id", alert.ID.String()), zap.Error(err))
+			continue
+		}
+		if err := c.repository.MarkAlertSent(ctx, alert.ID); err != nil {
+			return len(alerts), err
+		}
+		c.log.Info("Budget alert sent", zap.String("id", alert.ID.String()), zap.String("user_id", alert.UserID.String()), zap.String("month", alert.Month))
+	}
+	return len(alerts), nil
+}
+
+// ValidateBudgetReq checks the budget of a category received from a client: a non-empty category,
+// a valid currency code and a positive amount in that currency. Returns the budget described
+// by the request, without a user.
+func ValidateBudgetReq(category string, req *models.BudgetReq) (*models.Budget, error) {
+	category = strings.TrimSpace(category)
+	if category == "" || utf8.RuneCountInString(category) > maxCategoryLength {
+		return nil, errors.New("invalid category")
+	}
+	currency, err := ValidateCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := models.ParseAmount(req.Amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %s", err)
+	}
+	if amount <= 0 {
+		return nil, errors.New("invalid amount")
+	}
+	return &models.Budget{Category: category, Amount: amount, Currency: currency}, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Length limits of the text fields of catalog services.
+const (
+	maxServiceNameLength = 255
+	maxCategoryLength    = 100
+	maxWebsiteLength     = 2048
+)
+
+// CreateService adds the service to the catalog.
+// Returns ErrConflict if the catalog already has a service with the same slug.
+func (c *SubscriptionService) CreateService(ctx context.Context, svc *models.Service) error {
+	if err := c.repository.CreateService(ctx, svc); err != nil {
+		return err
+	}
+	c.log.Info("Service created", zap.String("id", svc.ID.String()), zap.String("slug", svc.Slug))
+	return nil
+}
+
+// UpdateService replaces the catalog entry with the ID of svc. A new name is copied
+// to the subscriptions of the service, whose versions change accordingly.
+// Returns ErrNotFound if there is no such service, or ErrConflict if another service has the new slug.
+func (c *SubscriptionService) UpdateService(ctx context.Context, svc *models.Service) error {
+	if err := c.repository.UpdateService(ctx, svc); err != nil {
+		return err
+	}
+	c.log.Info("Service updated", zap.String("id", svc.ID.String()), zap.String("slug", svc.Slug))
+	return nil
+}
+
+// DeleteService removes the service from the catalog.
+// Returns ErrNotFound if there is no such service, or ErrConflict if subscriptions still refer to it.
+func (c *SubscriptionService) DeleteService(ctx context.Context, id uuid.UUID) error {
+	return c.repository.DeleteService(ctx, id)
+}
+
+// GetService returns the catalog service with the ID, or ErrNotFound.
+func (c *SubscriptionService) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	return c.repository.GetService(ctx, id)
+}
+
+// ListServices returns the services of the catalog in the category, or all of them if it is empty,
+// ordered by name.
+func (c *SubscriptionService) ListServices(ctx context.Context, category string) ([]models.Service, error) {
+	return c.repository.ListServices(ctx, strings.TrimSpace(category))
+}
+
+// ValidateServiceReq checks a catalog service received from a client: a name with a non-empty slug,
+// a valid currency code, a positive default price in that currency if given, and an http or https
+// website if given. Returns the service described by the request, without an ID.
+func ValidateServiceReq(req *models.ServiceReq) (*models.Service, error) {
+	name := strings.TrimSpace(req.Name)
+	slug := models.Slug(name)
+	if slug == "" || utf8.RuneCountInString(name) > maxServiceNameLength {
+		return nil, errors.New("invalid service name")
+	}
+	category := strings.TrimSpace(req.Category)
+	if utf8.RuneCountInString(category) > maxCategoryLength {
+		return nil, errors.New("invalid category")
+	}
+	currency, err := ValidateCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var price int64
+	if req.DefaultPrice != nil {
+		if price, err = models.ParseAmount(*req.DefaultPrice, currency); err != nil {
+			return nil, fmt.Errorf("invalid default price: %s", err)
+		}
+		if price <= 0 {
+			return nil, errors.New("invalid default price")
+		}
+	}
+	website := strings.TrimSpace(req.Website)
+	if website != "" {
+		u, err := url.Parse(website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(website) > maxWebsiteLength {
+			return nil, errors.New("invalid website")
+		}
+	}
+	return &models.Service{
+		Name:         name,
+		Slug:         slug,
+		Category:     category,
+		DefaultPrice: price,
+		Currency:     currency,
+		Website:      website,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+)
+
+// ratePattern matches exchange rates: positive decimal numbers without an exponent.
+var ratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// SetRate stores the exchange rate of a currency for a month, replacing the rate set for the same month.
+// The rate must have been checked with ValidateRate.
+func (c *SubscriptionService) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	return c.repository.SetRate(ctx, rate)
+}
+
+// DeleteRate removes the exchange rate of the currency for the month.
+// Returns ErrNotFound if no rate is set for that month.
+func (c *SubscriptionService) DeleteRate(ctx context.Context, currency string, month string) error {
+	return c.repository.DeleteRate(ctx, currency, month)
+}
+
+// ListRates returns the exchange rates of the currency, or of all currencies if it is empty,
+// ordered by currency and month.
+func (c *SubscriptionService) ListRates(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	var currencies []string
+	if currency != "" {
+		currencies = []string{currency}
+	}
+	return c.repository.ListRates(ctx, currencies, "")
+}
+
+// ValidateRate checks an exchange rate received from a client and normalizes its currency code and month.
+// Rates are quoted in models.BaseCurrency, which therefore has no rate of its own.
+func ValidateRate(rate *models.ExchangeRate) error {
+	currency, err := ValidateCurrency(rate.Currency)
+	if err != nil {
+		return err
+	}
+	if currency == models.BaseCurrency {
+		return fmt.Errorf("rates are quoted in %s, it has no rate of its own", models.BaseCurrency)
+	}
+	month, err := time.Parse(monthLayout, rate.Month)
+	if err != nil {
+		return errors.New("invalid month")
+	}
+	if !ratePattern.MatchString(rate.Rate) {
+		return errors.New("invalid rate")
+	}
+	if value, _ := new(big.Rat).SetString(rate.Rate); value.Sign() <= 0 {
+		return errors.New("rate must be positive")
+	}
+	rate.Currency = currency
+	rate.Month = month.Format(monthLayout)
+	return nil
+}
+
+// rateTable holds the exchange rates of currencies to models.BaseCurrency,
+// each ordered by the month the rate applies from.
+type rateTable map[string][]monthRate
+
+type monthRate struct {
+	from time.Time
+	rate *big.Rat
+}
+
+// parseRates builds a rate table from rates ordered by currency and month.
+func parseRates(rates []models.ExchangeRate) (rateTable, error) {
+	table := make(rateTable)
+	for _, rate := range rates {
+		from, err := time.Parse(monthLayout, rate.Month)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate month %q: %w", rate.Month, err)
+		}
+		value, ok := new(big.Rat).SetString(rate.Rate)
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q", rate.Rate)
+		}
+		table[rate.Currency] = append(table[rate.Currency], monthRate{from: from, rate: value})
+	}
+	return table, nil
+}
+
+// rate returns the rate of the currency in force in the month: the latest one set not later than it.
+// The rate of models.BaseCurrency is 1. A missing rate is reported as a ValidationError,
+// since the cost cannot be calculated in the requested currency.
+func (t rateTable) rate(currency string, month time.Time) (*big.Rat, error) {
+	if currency == models.BaseCurrency {
+		return big.NewRat(1, 1), nil
+	}
+	var rate *big.Rat
+	for _, entry := range t[currency] {
+		if entry.from.After(month) {
+			break
+		}
+		rate = entry.rate
+	}
+	if rate == nil {
+		return nil, newValidationError("no exchange rate for %s in %s", currency, month.Format(monthLayout))
+	}
+	return rate, nil
+}
+
+// converter converts amounts into the target currency with the rates of each month.
+type converter struct {
+	target string
+	rates  rateTable
+}
+
+// convert returns the amount in minor units of the currency, charged in the month, in major units
+// of the target currency. The result is exact; it is rounded only once the amounts are added up.
+func (c converter) convert(units int64, currency string, month time.Time) (*big.Rat, error) {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(models.Exponent(currency))), nil)
+	value := new(big.Rat).SetFrac(big.NewInt(units), scale)
+	if currency == c.target {
+		return value, nil
+	}
+	from, err := c.rates.rate(currency, month)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.rates.rate(c.target, month)
+	if err != nil {
+		return nil, err
+	}
+	return value.Mul(value, from).Quo(value, to), nil
+}
+
+// formatTotal rounds an amount in major units of the currency to its minor units, halves away from zero.
+func formatTotal(amount *big.Rat, currency string) models.Decimal {
+	return models.Decimal(amount.FloatString(models.Exponent(currency)))
+}
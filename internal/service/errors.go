@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors returned by the service and repository layers.
+// Handlers translate them into HTTP status codes with errors.Is.
+var (
+	// ErrNotFound is returned when the requested subscription does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the operation clashes with the current state, e.g. a duplicate key.
+	ErrConflict = errors.New("conflict")
+	// ErrPreconditionFailed is returned when the subscription was modified since the version the client has seen.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrValidation is returned when the input is well-formed but semantically invalid.
+	ErrValidation = errors.New("validation failed")
+	// ErrConstraintViolation is returned when the database rejects data violating one of its constraints.
+	ErrConstraintViolation = errors.New("constraint violation")
+)
+
+// ValidationError describes why the input of an operation is invalid.
+// It matches ErrValidation, and its reason is safe to show to the client.
+type ValidationError struct {
+	Reason string
+}
+
+// newValidationError creates a ValidationError with a formatted reason.
+func newValidationError(format string, args ...any) *ValidationError {
+	return &ValidationError{Reason: fmt.Sprintf(format, args...)}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// Is reports whether the target is ErrValidation, so errors.Is works for every ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
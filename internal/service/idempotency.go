@@ -0,0 +1,63 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long a stored response is replayed for repeated requests.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyLease is how long a key is held for a request that has not stored its response yet.
+// A retry after it expires is processed again, so that a request whose process stopped does not
+// hold the key until DefaultIdempotencyTTL.
+const idempotencyLease = 5 * time.Minute
+
+// BeginIdempotentRequest starts processing a request carrying an idempotency key.
+// It returns nil if the request is the first one with the key and must be processed;
+// the outcome is then recorded with CompleteIdempotentRequest or AbortIdempotentRequest.
+// If a response was already stored for the same request it is returned to be replayed.
+// A key reused with a different request is a ValidationError, and a key whose first request
+// is still being processed, for at most idempotencyLease, is an ErrConflict.
+func (c *SubscriptionService) BeginIdempotentRequest(ctx context.Context, key string, requestHash string) (*models.IdempotencyRecord, error) {
+	record, err := c.repository.ReserveIdempotencyKey(ctx, key, requestHash, c.idempotencyTTL, idempotencyLease)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		c.log.Warn("Idempotency key reused with a different request", zap.String("key", key))
+		return nil, newValidationError("idempotency key was already used with a different request")
+	}
+	if record.StatusCode == 0 {
+		return nil, fmt.Errorf("%w: request with this idempotency key is still in progress", ErrConflict)
+	}
+
+	c.log.Debug("Replaying idempotent response", zap.String("key", key), zap.Int("status", record.StatusCode))
+	return record, nil
+}
+
+// CompleteIdempotentRequest stores the response of a request started with BeginIdempotentRequest,
+// including the headers to replay with it.
+func (c *SubscriptionService) CompleteIdempotentRequest(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error {
+	return c.repository.SaveIdempotentResponse(ctx, key, statusCode, headers, response)
+}
+
+// AbortIdempotentRequest frees the key of a request that failed without a definitive response,
+// so that the client can retry it.
+func (c *SubscriptionService) AbortIdempotentRequest(ctx context.Context, key string) error {
+	return c.repository.ReleaseIdempotencyKey(ctx, key)
+}
+
+// PurgeIdempotencyKeys deletes up to limit stored responses whose idempotency key has expired.
+// Returns the number deleted.
+func (c *SubscriptionService) PurgeIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	purged, err := c.repository.PurgeIdempotencyKeys(ctx, limit)
+	return int(purged), err
+}
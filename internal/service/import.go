@@ -0,0 +1,86 @@
+package service
+
+import (
+	"Effective_Mobile/internal/importer"
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+)
+
+// MaxImportErrors is the largest number of rejected rows listed in an import report.
+const MaxImportErrors = 1000
+
+// ImportSource provides the rows of an import file; importer.Reader implements it.
+// Next returns io.EOF after the last row.
+type ImportSource interface {
+	Next() (*importer.Row, error)
+}
+
+// ImportSubs creates a subscription for every valid row of src. The rows are validated with the
+// same rules as subscriptions created through the API; invalid rows are skipped and reported by line.
+// Valid rows are streamed to the database as they are read and are stored in a single transaction,
+// so nothing is imported if the file turns out to be unreadable halfway through.
+// The budgets of the users of the imported subscriptions are evaluated once the import is stored.
+// Returns a ValidationError if the file cannot be read.
+func (c *SubscriptionService) ImportSubs(ctx context.Context, src ImportSource) (*models.ImportReport, error) {
+	report := &models.ImportReport{Errors: []models.ImportError{}}
+	var readErr error
+	users := make(map[uuid.UUID]bool)
+
+	next := func() (*models.Subscription, error) {
+		for {
+			row, err := src.Next()
+			if errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if err != nil {
+				readErr = newValidationError("failed to read file: %v", err)
+				return nil, readErr
+			}
+
+			if row.Err != nil {
+				rejectRow(report, row.Line, row.Err)
+				continue
+			}
+			sub, err := ValidateSubReq(&row.Req)
+			if err != nil {
+				rejectRow(report, row.Line, err)
+				continue
+			}
+			sub.ID = uuid.New()
+			users[sub.UserID] = true
+			return sub, nil
+		}
+	}
+
+	imported, err := c.repository.CopySubs(ctx, next)
+	if err != nil {
+		if readErr != nil {
+			err = readErr
+		}
+		c.log.Error("Import failed", zap.Error(err))
+		return nil, err
+	}
+	report.Imported = imported
+	c.log.Info("Subscriptions imported", zap.Int64("imported", report.Imported), zap.Int("rejected", report.Rejected))
+
+	ids := make([]uuid.UUID, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	c.checkBudgets(ctx, ids...)
+	return report, nil
+}
+
+// rejectRow records a row that cannot be imported; only the first MaxImportErrors rows are listed.
+func rejectRow(report *models.ImportReport, line int, err error) {
+	report.Rejected++
+	if len(report.Errors) >= MaxImportErrors {
+		report.Truncated = true
+		return
+	}
+	report.Errors = append(report.Errors, models.ImportError{Line: line, Error: err.Error()})
+}
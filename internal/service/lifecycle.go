@@ -0,0 +1,177 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ErrInvalidTransition is returned when a lifecycle operation is not allowed in the current status of the subscription.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// Lifecycle operations, as named in transition errors.
+const (
+	actionPause      = "pause"
+	actionResume     = "resume"
+	actionCancel     = "cancel"
+	actionReactivate = "reactivate"
+)
+
+// PauseSubs pauses an active subscription from the current month on; the paused months are not paid.
+// A subscription that has not started yet is paused from its first month.
+// If version is not zero, the subscription must still have it, as with If-Match.
+func (c *SubscriptionService) PauseSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	return c.changeStatus(ctx, id, version, actionPause, "")
+}
+
+// ResumeSubs resumes a paused subscription. The current month is paid again,
+// so a subscription paused and resumed within the same month loses no month.
+func (c *SubscriptionService) ResumeSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	return c.changeStatus(ctx, id, version, actionResume, "")
+}
+
+// CancelSubs cancels an active or paused subscription by setting its end date.
+// With models.CancelAtPeriodEnd the current month is the last one paid, with models.CancelImmediately
+// the previous month is; the subscription ends on the last day of that month. An earlier end date
+// that is already set is kept.
+func (c *SubscriptionService) CancelSubs(ctx context.Context, id uuid.UUID, version int, mode string) (*models.Subscription, error) {
+	if mode != models.CancelAtPeriodEnd && mode != models.CancelImmediately {
+		return nil, newValidationError("unsupported cancellation mode %q", mode)
+	}
+	return c.changeStatus(ctx, id, version, actionCancel, mode)
+}
+
+// ReactivateSubs makes a canceled subscription active again without an end date.
+// If it has already ended, the months between its end and the current month are recorded
+// as a pause, so they are not paid.
+func (c *SubscriptionService) ReactivateSubs(ctx context.Context, id uuid.UUID, version int) (*models.Subscription, error) {
+	return c.changeStatus(ctx, id, version, actionReactivate, "")
+}
+
+// changeStatus loads the subscription, checks that the operation is allowed and stores the transition.
+func (c *SubscriptionService) changeStatus(ctx context.Context, id uuid.UUID, version int, action string, mode string) (*models.Subscription, error) {
+	sub, err := c.repository.GetSub(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && sub.Version != version {
+		return nil, fmt.Errorf("subscription version %d: %w", version, ErrPreconditionFailed)
+	}
+
+	change, err := transition(sub, action, mode, monthStart(time.Now()))
+	if err != nil {
+		c.log.Debug("Transition rejected", zap.String("id", id.String()), zap.String("action", action), zap.Error(err))
+		return nil, err
+	}
+	newVersion, err := c.repository.ChangeStatus(ctx, id, sub.Version, change)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Status = change.Status
+	sub.EndDate = change.EndDate
+	sub.Version = newVersion
+	c.log.Info("Subscription status changed", zap.String("id", id.String()), zap.String("action", action), zap.String("status", sub.Status))
+	// Only subscriptions that become active again add to the cost of the month.
+	if sub.Status == models.StatusActive {
+		c.checkBudgets(ctx, sub.UserID)
+	}
+	return sub, nil
+}
+
+// transition computes the effect of a lifecycle operation on the subscription in the given month.
+// The allowed transitions are:
+//
+//	active   -> paused   (pause)
+//	paused   -> active   (resume)
+//	active   -> canceled (cancel)
+//	paused   -> canceled (cancel; the pause lasts until the end month)
+//	canceled -> active   (reactivate)
+func transition(sub *models.Subscription, action string, mode string, now time.Time) (*models.StatusChange, error) {
+	start, end, err := subscriptionRange(sub)
+	if err != nil {
+		return nil, err
+	}
+	previous := now.AddDate(0, -1, 0)
+	change := &models.StatusChange{Status: sub.Status, EndDate: sub.EndDate}
+
+	switch action {
+	case actionPause:
+		if sub.Status != models.StatusActive {
+			return nil, invalidTransition(action, sub.Status)
+		}
+		if !end.IsZero() && end.Before(now) {
+			return nil, fmt.Errorf("%w: subscription ended in %s", ErrInvalidTransition, *sub.EndDate)
+		}
+		from := now
+		if start.After(from) {
+			from = monthStart(start)
+		}
+		change.Status = models.StatusPaused
+		change.OpenPause = monthString(from)
+
+	case actionResume:
+		if sub.Status != models.StatusPaused {
+			return nil, invalidTransition(action, sub.Status)
+		}
+		change.Status = models.StatusActive
+		change.ClosePause = monthString(previous)
+
+	case actionCancel:
+		if sub.Status != models.StatusActive && sub.Status != models.StatusPaused {
+			return nil, invalidTransition(action, sub.Status)
+		}
+		last := monthEnd(now)
+		if mode == models.CancelImmediately {
+			last = monthEnd(previous)
+		}
+		if !end.IsZero() && end.Before(last) {
+			last = end
+		}
+		if last.Before(start) {
+			return nil, fmt.Errorf("%w: subscription starts in %s, after its last paid month; delete it instead",
+				ErrInvalidTransition, sub.StartDate)
+		}
+		if sub.Status == models.StatusPaused {
+			change.ClosePause = monthString(last)
+		}
+		change.Status = models.StatusCanceled
+		change.EndDate = dateString(last)
+
+	case actionReactivate:
+		if sub.Status != models.StatusCanceled {
+			return nil, invalidTransition(action, sub.Status)
+		}
+		// The months after the end and before the current one were not subscribed to.
+		if !end.IsZero() && end.Before(previous) {
+			change.AddPause = &models.Pause{StartDate: *monthString(monthStart(end).AddDate(0, 1, 0)), EndDate: monthString(previous)}
+		}
+		change.Status = models.StatusActive
+		change.EndDate = nil
+
+	default:
+		return nil, fmt.Errorf("unknown lifecycle operation %q", action)
+	}
+	return change, nil
+}
+
+// invalidTransition reports an operation that is not allowed in the given status.
+func invalidTransition(action string, status string) error {
+	return fmt.Errorf("%w: cannot %s a subscription that is %s", ErrInvalidTransition, action, status)
+}
+
+// monthString formats a month in MM-YYYY form.
+func monthString(month time.Time) *string {
+	s := month.Format(monthLayout)
+	return &s
+}
+
+// dateString formats a date in YYYY-MM-DD form.
+func dateString(date time.Time) *string {
+	s := date.Format(dateLayout)
+	return &s
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// monthLayout is the MM-YYYY format of months: the bounds of cost calculations, pauses and price history.
+// Subscription dates were given in it before they had day precision and may still be.
+const monthLayout = "01-2006"
+
+// dateLayout is the ISO 8601 YYYY-MM-DD format subscription dates are stored in.
+const dateLayout = "2006-01-02"
+
+// period is an inclusive range of months used for cost calculations.
+// Both bounds point to the first day of their month; a zero 'from' means
+// the period is unbounded at the beginning. With amortize set, every charge is spread
+// over the months of its billing period instead of being counted in the month it falls in.
+type period struct {
+	from     time.Time
+	to       time.Time
+	amortize bool
+}
+
+// newPeriod builds a period from the requested bounds.
+// A missing 'to' defaults to the month of 'now', since open-ended subscriptions
+// would otherwise accumulate cost forever.
+func newPeriod(from, to, now time.Time) period {
+	p := period{from: monthStart(from), to: monthStart(to)}
+	if to.IsZero() {
+		p.to = monthStart(now)
+	}
+	return p
+}
+
+// cost returns the amount the subscription is charged inside the period: the sum of monthCost
+// over every month it is active. The subscription range is clipped to both ends of the period;
+// the end month is inclusive.
+func (p period) cost(sub *models.Subscription, t timeline, conv converter) (*big.Rat, error) {
+	total := new(big.Rat)
+	start, end, ok, err := p.activeRange(sub)
+	if err != nil || !ok {
+		return total, err
+	}
+	b, err := newBilling(sub)
+	if err != nil {
+		return nil, err
+	}
+	for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+		amount, err := p.monthCost(sub, b, t, conv, month)
+		if err != nil {
+			return nil, err
+		}
+		if amount != nil {
+			total.Add(total, amount)
+		}
+	}
+	return total, nil
+}
+
+// monthCost returns the amount the subscription is charged in a month it is active: the price in force
+// in that month converted into the target currency, times the factor of the month in its billing schedule.
+// Returns nil if nothing is charged, including in months in which the subscription is paused.
+func (p period) monthCost(sub *models.Subscription, b billing, t timeline, conv converter, month time.Time) (*big.Rat, error) {
+	if t.pauses.covers(month) {
+		return nil, nil
+	}
+	factor := b.factor(month, p.amortize)
+	if factor.Sign() == 0 {
+		return nil, nil
+	}
+	price, currency := t.prices.at(month, sub)
+	amount, err := conv.convert(price, currency, month)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Mul(amount, factor), nil
+}
+
+// activeRange returns the first and last month the subscription is active inside the period.
+// ok is false when the subscription does not overlap the period at all.
+func (p period) activeRange(sub *models.Subscription) (time.Time, time.Time, bool, error) {
+	start, end, err := subscriptionRange(sub)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	start, end = monthStart(start), monthStart(end)
+
+	if !p.from.IsZero() && start.Before(p.from) {
+		start = p.from
+	}
+	if end.IsZero() || end.After(p.to) {
+		end = p.to
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	return start, end, true, nil
+}
+
+// subscriptionRange parses the start and optional end date of the subscription; the end date is its last day.
+// A zero end means the subscription has no end date.
+func subscriptionRange(sub *models.Subscription) (time.Time, time.Time, error) {
+	start, err := time.Parse(dateLayout, sub.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date %q: %w", sub.StartDate, err)
+	}
+	var end time.Time
+	if sub.EndDate != nil {
+		end, err = time.Parse(dateLayout, *sub.EndDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %q: %w", *sub.EndDate, err)
+		}
+	}
+	return start, end, nil
+}
+
+// pauseRanges are the pauses of a subscription as inclusive month ranges. The ranges do not overlap;
+// a zero 'to' means the subscription is still paused.
+type pauseRanges []period
+
+// parsePauses converts the pauses of a subscription into month ranges.
+func parsePauses(pauses []models.Pause) (pauseRanges, error) {
+	ranges := make(pauseRanges, 0, len(pauses))
+	for _, pause := range pauses {
+		from, err := time.Parse(monthLayout, pause.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pause start %q: %w", pause.StartDate, err)
+		}
+		var to time.Time
+		if pause.EndDate != nil {
+			if to, err = time.Parse(monthLayout, *pause.EndDate); err != nil {
+				return nil, fmt.Errorf("invalid pause end %q: %w", *pause.EndDate, err)
+			}
+		}
+		ranges = append(ranges, period{from: from, to: to})
+	}
+	return ranges, nil
+}
+
+// covers reports whether the subscription is paused in the month.
+func (r pauseRanges) covers(month time.Time) bool {
+	for _, pause := range r {
+		if !month.Before(pause.from) && (pause.to.IsZero() || !month.After(pause.to)) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeline is the history of a subscription that affects its cost in every month.
+type timeline struct {
+	pauses pauseRanges
+	prices priceSchedule
+}
+
+// priceSchedule is the price history of a subscription ordered by the month each price takes effect.
+type priceSchedule []pricePeriod
+
+type pricePeriod struct {
+	from     time.Time
+	price    int64
+	currency string
+}
+
+// parsePrices converts the price history of a subscription into a schedule.
+func parsePrices(prices []models.PricePeriod) (priceSchedule, error) {
+	schedule := make(priceSchedule, 0, len(prices))
+	for _, price := range prices {
+		from, err := time.Parse(monthLayout, price.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price effective month %q: %w", price.EffectiveFrom, err)
+		}
+		schedule = append(schedule, pricePeriod{from: from, price: price.Price, currency: price.Currency})
+	}
+	return schedule, nil
+}
+
+// at returns the price in force in the month and its currency: the last price that took effect
+// not later than it. Months before the first entry are charged the earliest price, and
+// a subscription without a history is charged its current price.
+func (s priceSchedule) at(month time.Time, sub *models.Subscription) (int64, string) {
+	if len(s) == 0 {
+		return sub.Price, sub.Currency
+	}
+	entry := s[0]
+	for _, next := range s[1:] {
+		if next.from.After(month) {
+			break
+		}
+		entry = next
+	}
+	return entry.price, entry.currency
+}
+
+// monthStart truncates t to the first day of its month. Zero time stays zero.
+func monthStart(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthEnd returns the last day of the month of t.
+func monthEnd(t time.Time) time.Time {
+	return monthStart(t).AddDate(0, 1, -1)
+}
+
+// monthsBetween returns the number of whole months from 'from' to 'to'.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Delivery of reminders and budget alerts: a claimed reminder or alert is reserved for the replica sending it
+// for deliveryLease, after which it is sent again if its delivery failed, at most maxDeliveryAttempts times.
+const (
+	deliveryLease       = 5 * time.Minute
+	maxDeliveryAttempts = 5
+)
+
+// upcomingPageSize is the number of subscriptions whose reminders are queued at a time.
+const upcomingPageSize = 1000
+
+// QueueReminders queues a reminder for every charge of an active subscription, and for every end of a free trial,
+// falling within leadDays days of the day of now in UTC. A charge in a month the subscription is paused in
+// is not reminded of. Reminders already queued are not queued again, so that the queueing may be repeated
+// by every replica. The subscriptions are read upcomingPageSize at a time, and the reminders of each page
+// are queued before the next one is read. Returns the number of reminders queued.
+func (c *SubscriptionService) QueueReminders(ctx context.Context, now time.Time, leadDays int) (int64, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.AddDate(0, 0, leadDays)
+
+	var total int64
+	after := uuid.Nil
+	for {
+		subs, err := c.repository.ListUpcomingSubs(ctx, today.Format(dateLayout), horizon.Format(dateLayout), after, upcomingPageSize)
+		if err != nil {
+			return total, err
+		}
+		if len(subs) == 0 {
+			break
+		}
+		queued, err := c.queueReminders(ctx, subs, today, horizon)
+		total += queued
+		if err != nil {
+			return total, err
+		}
+		if len(subs) < upcomingPageSize {
+			break
+		}
+		after = subs[len(subs)-1].ID
+	}
+	c.log.Debug("Reminders queued", zap.Int64("count", total))
+	return total, nil
+}
+
+// queueReminders queues the reminders of the subscriptions due between today and horizon.
+func (c *SubscriptionService) queueReminders(ctx context.Context, subs []models.Subscription, today time.Time, horizon time.Time) (int64, error) {
+	timelines, err := c.loadTimelines(ctx, subs)
+	if err != nil {
+		return 0, err
+	}
+
+	var reminders []models.Reminder
+	for _, sub := range subs {
+		b, err := newBilling(&sub)
+		if err != nil {
+			c.log.Error("Invalid subscription dates", zap.String("id", sub.ID.String()), zap.Error(err))
+			return 0, err
+		}
+		t := timelines[sub.ID]
+		remind := func(kind string, due time.Time, charge time.Time) {
+			price, currency := t.prices.at(monthStart(charge), &sub)
+			reminders = append(reminders, models.Reminder{
+				ID:             uuid.New(),
+				SubscriptionID: sub.ID,
+				UserID:         sub.UserID,
+				Kind:           kind,
+				DueDate:        due.Format(dateLayout),
+				ServiceName:    sub.ServiceName,
+				Price:          price,
+				Currency:       currency,
+			})
+		}
+
+		if charge, ok := b.next(today); ok && !charge.After(horizon) && !t.pauses.covers(monthStart(charge)) {
+			remind(models.ReminderRenewal, charge, charge)
+		}
+		// The trial ends on the day before the subscription is first charged.
+		if sub.TrialEndDate != nil {
+			if trialEnd := b.start.AddDate(0, 0, -1); !trialEnd.Before(today) && !trialEnd.After(horizon) {
+				remind(models.ReminderTrialEnd, trialEnd, b.start)
+			}
+		}
+	}
+	if len(reminders) == 0 {
+		return 0, nil
+	}
+	return c.repository.QueueReminders(ctx, reminders)
+}
+
+// SendReminders claims up to limit queued reminders whose date has not passed as of the day of now in UTC, and sends
+// them to the notifier as events whose ID is the ID of the reminder, so that a receiver can recognize a reminder
+// delivered again. A reminder that fails to be delivered is sent again on a later call once its lease expires.
+// Returns the number of reminders claimed; nothing is claimed without a notifier.
+func (c *SubscriptionService) SendReminders(ctx context.Context, now time.Time, limit int) (int, error) {
+	if c.notifier == nil {
+		return 0, nil
+	}
+	reminders, err := c.repository.ClaimReminders(ctx, now.UTC().Format(dateLayout), limit, deliveryLease, maxDeliveryAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, reminder := range reminders {
+		event := &models.Event{ID: reminder.ID, Type: reminder.EventType(), CreatedAt: now, Data: reminder}
+		if err := c.notifier.Notify(ctx, event); err != nil {
+			c.log.Warn("Failed to send reminder", zap.String("id", reminder.ID.String()), zap.Error(err))
+			continue
+		}
+		if err := c.repository.MarkReminderSent(ctx, reminder.ID); err != nil {
+			return len(reminders), err
+		}
+		c.log.Info("Reminder sent", zap.String("id", reminder.ID.String()), zap.String("kind", reminder.Kind),
+			zap.String("subscription_id", reminder.SubscriptionID.String()), zap.String("due_date", reminder.DueDate))
+	}
+	return len(reminders), nil
+}
@@ -2,7 +2,12 @@ package service
 
 import (
 	"Effective_Mobile/internal/models"
+	"context"
 	"github.com/google/uuid"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -11,89 +16,487 @@ import (
 // This interface allows the service layer to be decoupled from the concrete repository implementation,
 // making it easier to test and swap out different data storage solutions.
 type Subsrepository interface {
-	CreateSubs(subs *models.Subscription) error
-	UpdateSubs(id uuid.UUID, newSubs *models.Subscription) error
-	DeleteSubs(id uuid.UUID) error
-	ListSubs(filter models.SubscriptionFilter) ([]models.Subscription, error)
-	GetSummary(sum *models.GetSummary) (int, error)
-	GetSub(id uuid.UUID) (*models.Subscription, error)
-	SubscriptionExists(id uuid.UUID) (bool, error)
+	CreateSubs(ctx context.Context, subs *models.Subscription) error
+	UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) (uuid.UUID, error)
+	DeleteSubs(ctx context.Context, id uuid.UUID) error
+	BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error)
+	BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]uuid.UUID, []error, error)
+	BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error)
+	CopySubs(ctx context.Context, next func() (*models.Subscription, error)) (int64, error)
+	ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) ([]models.Subscription, error)
+	StreamSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error
+	ListSubsInPeriod(ctx context.Context, sum *models.GetSummary) ([]models.Subscription, error)
+	GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	ChangeStatus(ctx context.Context, id uuid.UUID, version int, change *models.StatusChange) (int, error)
+	ListPauses(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Pause, error)
+	ListPrices(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.PricePeriod, error)
+	SetRate(ctx context.Context, rate *models.ExchangeRate) error
+	DeleteRate(ctx context.Context, currency string, month string) error
+	ListRates(ctx context.Context, currencies []string, until string) ([]models.ExchangeRate, error)
+	CreateService(ctx context.Context, svc *models.Service) error
+	UpdateService(ctx context.Context, svc *models.Service) error
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	GetService(ctx context.Context, id uuid.UUID) (*models.Service, error)
+	ListServices(ctx context.Context, category string) ([]models.Service, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	SetBudget(ctx context.Context, budget *models.Budget) error
+	DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error
+	ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error)
+	CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error)
+	ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error)
+	ClaimAlerts(ctx context.Context, limit int, lease time.Duration, maxAttempts int) ([]models.BudgetAlert, error)
+	MarkAlertSent(ctx context.Context, id uuid.UUID) error
+	ListUpcomingSubs(ctx context.Context, from string, to string, after uuid.UUID, limit int) ([]models.Subscription, error)
+	QueueReminders(ctx context.Context, reminders []models.Reminder) (int64, error)
+	ClaimReminders(ctx context.Context, from string, limit int, lease time.Duration, maxAttempts int) ([]models.Reminder, error)
+	MarkReminderSent(ctx context.Context, id uuid.UUID) error
+	SubscriptionExists(ctx context.Context, id uuid.UUID) (bool, error)
+	ReserveIdempotencyKey(ctx context.Context, key string, requestHash string, ttl time.Duration, lease time.Duration) (*models.IdempotencyRecord, error)
+	SaveIdempotentResponse(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error
+	PurgeIdempotencyKeys(ctx context.Context, limit int) (int64, error)
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
 }
 
 // SubscriptionService provides business logic for managing subscriptions.
 // It interacts with the repository layer to perform CRUD operations and data aggregation.
 type SubscriptionService struct {
-	repository Subsrepository
-	log        *zap.Logger
+	repository     Subsrepository
+	notifier       Notifier
+	log            *zap.Logger
+	idempotencyTTL time.Duration
 }
 
 // NewSubscriptionService creates and returns a new instance of SubscriptionService.
-// It takes a repository implementation and a logger as dependencies.
-func NewSubscriptionService(repository Subsrepository, log *zap.Logger) *SubscriptionService {
-	return &SubscriptionService{repository: repository, log: log.Named("Service")}
+// It takes a repository implementation, the lifetime of stored idempotent responses
+// (DefaultIdempotencyTTL if not positive), the notifier budget alerts and reminders are sent to
+// (nil to only record them) and a logger as dependencies.
+func NewSubscriptionService(repository Subsrepository, idempotencyTTL time.Duration, notifier Notifier, log *zap.Logger) *SubscriptionService {
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = DefaultIdempotencyTTL
+	}
+	return &SubscriptionService{repository: repository, notifier: notifier, log: log.Named("Service"), idempotencyTTL: idempotencyTTL}
 }
 
 // CreateSubs handles the creation of a new subscription.
-// It delegates the operation to the underlying repository.
-func (c *SubscriptionService) CreateSubs(subs *models.Subscription) error {
-	// In a more complex scenario, additional business logic or validation
-	// could be performed here before calling the repository.
-	return c.repository.CreateSubs(subs)
+// It delegates the operation to the underlying repository and then evaluates the budgets of the user.
+func (c *SubscriptionService) CreateSubs(ctx context.Context, subs *models.Subscription) error {
+	if err := c.repository.CreateSubs(ctx, subs); err != nil {
+		return err
+	}
+	c.checkBudgets(ctx, subs.UserID)
+	return nil
 }
 
 // UpdateSubs handles the update of an existing subscription.
-// It delegates the operation to the underlying repository.
-func (c *SubscriptionService) UpdateSubs(id uuid.UUID, newSubs *models.Subscription) error {
-	// Similar to CreateSubs, business rules for updates could be applied here.
-	return c.repository.UpdateSubs(id, newSubs)
+// The update is applied only if the subscription still has the given version;
+// otherwise ErrPreconditionFailed is returned. On success newSubs.Version holds the new version.
+// It delegates the operation to the underlying repository and then evaluates the budgets of the user
+// the subscription belonged to and, if it moved to another user, of that user.
+func (c *SubscriptionService) UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) error {
+	oldOwner, err := c.repository.UpdateSubs(ctx, id, version, newSubs)
+	if err != nil {
+		return err
+	}
+	c.checkBudgets(ctx, oldOwner, newSubs.UserID)
+	return nil
 }
 
 // DeleteSubs handles the deletion of a subscription by its ID.
 // It delegates the operation to the underlying repository.
-func (c *SubscriptionService) DeleteSubs(id uuid.UUID) error {
-	return c.repository.DeleteSubs(id)
+func (c *SubscriptionService) DeleteSubs(ctx context.Context, id uuid.UUID) error {
+	return c.repository.DeleteSubs(ctx, id)
 }
 
 // GetSummary calculates the total cost of subscriptions based on the provided request criteria.
+// Every subscription is charged, for each month it is active inside [From, To], the price that was
+// in force in that month, with the subscription's own start and end months clipped to both ends of the period.
+// Subscriptions billed less often than monthly are charged only in the months their billing periods start in,
+// weekly ones once for every week starting in the month; with Amortize every charge is spread evenly
+// over the months of its billing period instead. Months in which a subscription is paused are not charged.
+// Prices are converted into the requested currency with the exchange rates of each month;
+// the total is rounded to the minor units of that currency.
+func (c *SubscriptionService) GetSummary(ctx context.Context, req *models.GetSummaryReq) (models.Decimal, error) {
+	basis, err := c.costBasis(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := new(big.Rat)
+	for _, sub := range basis.subs {
+		cost, err := basis.period.cost(&sub, basis.timelines[sub.ID], basis.converter)
+		if err != nil {
+			c.log.Error("Failed to calculate subscription cost", zap.String("id", sub.ID.String()), zap.Error(err))
+			return "", err
+		}
+		sum.Add(sum, cost)
+	}
+	total := formatTotal(sum, basis.converter.target)
+
+	c.log.Debug("Summary calculated", zap.Int("subscriptions", len(basis.subs)), zap.String("total", string(total)))
+	return total, nil
+}
+
+// GetGroupedSummary calculates the total cost of subscriptions as in GetSummary together with the cost
+// of every group of the requested kind, sorted by key. Every group is rounded separately.
+func (c *SubscriptionService) GetGroupedSummary(ctx context.Context, req *models.GetGroupedSummaryReq) (*models.GroupedSummary, error) {
+	groupKeys, err := groupKeysFunc(req.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	if groupKeys == nil {
+		return nil, newValidationError("group by is required")
+	}
+
+	basis, err := c.costBasis(ctx, &req.GetSummaryReq)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := new(big.Rat)
+	groups := make(map[string]*big.Rat)
+	for _, sub := range basis.subs {
+		cost, err := basis.period.cost(&sub, basis.timelines[sub.ID], basis.converter)
+		if err != nil {
+			c.log.Error("Failed to calculate subscription cost", zap.String("id", sub.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		if cost.Sign() == 0 {
+			continue
+		}
+		sum.Add(sum, cost)
+		for _, key := range groupKeys(&sub) {
+			addAmount(groups, key, cost)
+		}
+	}
+
+	summary := &models.GroupedSummary{
+		Total:    formatTotal(sum, basis.converter.target),
+		Currency: basis.converter.target,
+		Groups:   groupCosts(groups, basis.converter.target),
+	}
+	c.log.Debug("Grouped summary calculated", zap.Int("subscriptions", len(basis.subs)), zap.Int("groups", len(summary.Groups)))
+	return summary, nil
+}
+
+// GetBreakdown calculates the cost of subscriptions for every month of the requested period.
+// Months are returned in chronological order; when GroupBy is set, each month also contains
+// the cost per service name, user, category or tag, sorted by key.
+// Costs are converted as in GetSummary and every month and group is rounded separately.
+// If the period has no beginning, the breakdown starts at the earliest matching subscription.
+func (c *SubscriptionService) GetBreakdown(ctx context.Context, req *models.GetBreakdownReq) ([]models.MonthlyCost, error) {
+	groupKeys, err := groupKeysFunc(req.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	basis, err := c.costBasis(ctx, &req.GetSummaryReq)
+	if err != nil {
+		return nil, err
+	}
+	p := basis.period
+
+	// Collect the cost of every month, and of every group within it.
+	totals := make(map[time.Time]*big.Rat)
+	groups := make(map[time.Time]map[string]*big.Rat)
+	first := p.from
+	for _, sub := range basis.subs {
+		start, end, ok, err := p.activeRange(&sub)
+		if err != nil {
+			c.log.Error("Invalid subscription dates", zap.String("id", sub.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		b, err := newBilling(&sub)
+		if err != nil {
+			c.log.Error("Invalid subscription dates", zap.String("id", sub.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		t := basis.timelines[sub.ID]
+		for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+			amount, err := p.monthCost(&sub, b, t, basis.converter, month)
+			if err != nil {
+				c.log.Error("Failed to calculate subscription cost", zap.String("id", sub.ID.String()), zap.Error(err))
+				return nil, err
+			}
+			if amount == nil {
+				continue
+			}
+			addAmount(totals, month, amount)
+			if groupKeys == nil {
+				continue
+			}
+			if groups[month] == nil {
+				groups[month] = make(map[string]*big.Rat)
+			}
+			for _, key := range groupKeys(&sub) {
+				addAmount(groups[month], key, amount)
+			}
+		}
+	}
+	if first.IsZero() {
+		// Nothing matched an unbounded period, so there are no months to report.
+		return []models.MonthlyCost{}, nil
+	}
+
+	breakdown := make([]models.MonthlyCost, 0, monthsBetween(first, p.to)+1)
+	for month := first; !month.After(p.to); month = month.AddDate(0, 1, 0) {
+		total := totals[month]
+		if total == nil {
+			total = new(big.Rat)
+		}
+		entry := models.MonthlyCost{Month: month.Format(monthLayout), Total: formatTotal(total, basis.converter.target)}
+		if groupKeys != nil {
+			entry.Groups = groupCosts(groups[month], basis.converter.target)
+		}
+		breakdown = append(breakdown, entry)
+	}
+
+	c.log.Debug("Breakdown calculated", zap.Int("subscriptions", len(basis.subs)), zap.Int("months", len(breakdown)))
+	return breakdown, nil
+}
+
+// groupKeysFunc returns the function giving the keys of the groups a subscription belongs to when costs
+// are grouped by groupBy, or nil if groupBy is empty. A subscription belongs to the group of each of its tags,
+// and to the group with an empty key if it has no tags or no category.
+func groupKeysFunc(groupBy string) (func(sub *models.Subscription) []string, error) {
+	switch groupBy {
+	case "":
+		return nil, nil
+	case models.GroupByServiceName:
+		return func(sub *models.Subscription) []string { return []string{sub.ServiceName} }, nil
+	case models.GroupByUserID:
+		return func(sub *models.Subscription) []string { return []string{sub.UserID.String()} }, nil
+	case models.GroupByCategory:
+		return func(sub *models.Subscription) []string { return []string{sub.Category} }, nil
+	case models.GroupByTag:
+		return func(sub *models.Subscription) []string {
+			if len(sub.Tags) == 0 {
+				return []string{""}
+			}
+			return sub.Tags
+		}, nil
+	default:
+		return nil, newValidationError("unsupported group by %q", groupBy)
+	}
+}
+
+// groupCosts returns the costs of the groups sorted by key, each rounded to the minor units of the currency.
+func groupCosts(groups map[string]*big.Rat, currency string) []models.GroupCost {
+	costs := make([]models.GroupCost, 0, len(groups))
+	for key, total := range groups {
+		costs = append(costs, models.GroupCost{Key: key, Total: formatTotal(total, currency)})
+	}
+	sort.Slice(costs, func(i, j int) bool { return costs[i].Key < costs[j].Key })
+	return costs
+}
+
+// addAmount adds the amount to the sum stored under the key.
+func addAmount[K comparable](sums map[K]*big.Rat, key K, amount *big.Rat) {
+	if sums[key] == nil {
+		sums[key] = new(big.Rat)
+	}
+	sums[key].Add(sums[key], amount)
+}
+
+// costBasis is everything the cost of subscriptions in a period is calculated from.
+type costBasis struct {
+	period    period
+	subs      []models.Subscription
+	timelines map[uuid.UUID]timeline
+	converter converter
+}
+
+// costBasis resolves the period and currency of the request and loads the subscriptions that overlap
+// the period together with their timelines of pauses and prices, and the exchange rates needed
+// to convert their prices.
 // It transforms the GetSummaryReq (which uses time.Time) into a GetSummary (which uses strings for dates)
-// suitable for the repository layer, and then delegates the call.
-func (c *SubscriptionService) GetSummary(req *models.GetSummaryReq) (int, error) {
-	var fromStr, toStr string
-	// Format the 'From' date from time.Time to string format "01-2006" if it's not a zero value.
-	if !req.From.IsZero() {
-		fromStr = req.From.Format("01-2006")
+// suitable for the repository layer.
+func (c *SubscriptionService) costBasis(ctx context.Context, req *models.GetSummaryReq) (*costBasis, error) {
+	p := newPeriod(req.From, req.To, time.Now())
+	p.amortize = req.Amortize
+	if p.to.Before(p.from) {
+		return nil, newValidationError("period end %s is before its start %s", p.to.Format(monthLayout), p.from.Format(monthLayout))
+	}
+	target, err := ValidateCurrency(req.Currency)
+	if err != nil {
+		return nil, newValidationError("%s %q", err, req.Currency)
 	}
-	// Format the 'To' date from time.Time to string format "01-2006" if it's not a zero value.
-	if !req.To.IsZero() {
-		toStr = req.To.Format("01-2006")
+
+	var fromStr string
+	// Format the 'From' date from time.Time to string format "01-2006" if it's not a zero value.
+	if !p.from.IsZero() {
+		fromStr = p.from.Format(monthLayout)
 	}
 
 	// Create a GetSummary model for the repository layer.
 	sum := models.GetSummary{
 		From:        fromStr,
-		To:          toStr,
+		To:          p.to.Format(monthLayout),
 		UserID:      req.UserID,
 		ServiceName: req.ServiceName,
 	}
 
-	// Delegate the summary calculation to the repository.
-	return c.repository.GetSummary(&sum)
+	subs, err := c.repository.ListSubsInPeriod(ctx, &sum)
+	if err != nil {
+		return nil, err
+	}
+	basis := &costBasis{period: p, subs: subs, converter: converter{target: target}}
+	if len(subs) == 0 {
+		return basis, nil
+	}
+
+	if basis.timelines, err = c.loadTimelines(ctx, subs); err != nil {
+		return nil, err
+	}
+
+	// Rates are needed for every currency other than the target one the subscriptions are charged in.
+	foreign := make(map[string]bool)
+	for i := range subs {
+		if subs[i].Currency != target {
+			foreign[subs[i].Currency] = true
+		}
+		for _, price := range basis.timelines[subs[i].ID].prices {
+			if price.currency != target {
+				foreign[price.currency] = true
+			}
+		}
+	}
+	if len(foreign) > 0 {
+		if basis.converter.rates, err = c.loadRates(ctx, foreign, target, p.to); err != nil {
+			return nil, err
+		}
+	}
+	return basis, nil
 }
 
-// ListSubs retrieves a list of subscriptions based on the provided filter.
-// It delegates the operation to the underlying repository.
-func (c *SubscriptionService) ListSubs(filter models.SubscriptionFilter) ([]models.Subscription, error) {
-	return c.repository.ListSubs(filter)
+// loadTimelines loads the pauses and the price history of the subscriptions.
+func (c *SubscriptionService) loadTimelines(ctx context.Context, subs []models.Subscription) (map[uuid.UUID]timeline, error) {
+	ids := make([]uuid.UUID, len(subs))
+	for i := range subs {
+		ids[i] = subs[i].ID
+	}
+	pauses, err := c.repository.ListPauses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := c.repository.ListPrices(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	timelines := make(map[uuid.UUID]timeline, len(subs))
+	for _, id := range ids {
+		var t timeline
+		if t.pauses, err = parsePauses(pauses[id]); err != nil {
+			c.log.Error("Invalid pause dates", zap.String("id", id.String()), zap.Error(err))
+			return nil, err
+		}
+		if t.prices, err = parsePrices(prices[id]); err != nil {
+			c.log.Error("Invalid price history", zap.String("id", id.String()), zap.Error(err))
+			return nil, err
+		}
+		timelines[id] = t
+	}
+	return timelines, nil
+}
+
+// loadRates loads the exchange rates needed to convert the foreign currencies into the target one
+// in the months up to 'until'. Rates are quoted in models.BaseCurrency, so the rates of the target
+// currency are needed as well.
+func (c *SubscriptionService) loadRates(ctx context.Context, foreign map[string]bool, target string, until time.Time) (rateTable, error) {
+	currencies := make([]string, 0, len(foreign)+1)
+	for currency := range foreign {
+		if currency != models.BaseCurrency {
+			currencies = append(currencies, currency)
+		}
+	}
+	if target != models.BaseCurrency {
+		currencies = append(currencies, target)
+	}
+	sort.Strings(currencies)
+
+	rates, err := c.repository.ListRates(ctx, currencies, until.Format(monthLayout))
+	if err != nil {
+		return nil, err
+	}
+	table, err := parseRates(rates)
+	if err != nil {
+		c.log.Error("Invalid exchange rates", zap.Error(err))
+		return nil, err
+	}
+	return table, nil
+}
+
+// ListSubs retrieves a page of subscriptions based on the provided filter and list parameters.
+// One extra row is requested from the repository to find out whether another page exists;
+// if it does, NextCursor points to the last subscription of the returned page.
+func (c *SubscriptionService) ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) (*models.SubsPage, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		return nil, newValidationError("invalid page limit %d", limit)
+	}
+
+	params.Limit = limit + 1
+	subs, err := c.repository.ListSubs(ctx, filter, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.SubsPage{Items: subs}
+	if len(subs) > limit {
+		page.Items = subs[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = models.Cursor{
+			SortBy: params.SortBy,
+			Desc:   params.Desc,
+			Value:  sortValue(&last, params.SortBy),
+			ID:     last.ID,
+		}.Encode()
+	}
+	if page.Items == nil {
+		page.Items = []models.Subscription{}
+	}
+	return page, nil
+}
+
+// ExportSubs passes every subscription matching the filter to fn, in the sort order of params,
+// as it is read from the database. The page size and cursor of params are ignored.
+// Iteration stops at the first error returned by fn.
+func (c *SubscriptionService) ExportSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error {
+	params.Limit = 0
+	params.Cursor = nil
+	return c.repository.StreamSubs(ctx, filter, params, fn)
+}
+
+// sortValue returns the value of the sort field of the subscription as stored in a cursor.
+func sortValue(sub *models.Subscription, sortBy string) string {
+	switch sortBy {
+	case models.SortByPrice:
+		return strconv.FormatInt(sub.Price, 10)
+	case models.SortByServiceName:
+		return sub.ServiceName
+	default:
+		return sub.StartDate
+	}
 }
 
 // GetSub retrieves a single subscription by its ID.
 // It delegates the operation to the underlying repository.
-func (c *SubscriptionService) GetSub(id uuid.UUID) (*models.Subscription, error) {
-	return c.repository.GetSub(id)
+func (c *SubscriptionService) GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return c.repository.GetSub(ctx, id)
 }
 
 // SubscriptionExists checks if a subscription with the given ID exists.
 // It delegates the operation to the underlying repository.
-func (c *SubscriptionService) SubscriptionExists(id uuid.UUID) (bool, error) {
-	return c.repository.SubscriptionExists(id)
+func (c *SubscriptionService) SubscriptionExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	return c.repository.SubscriptionExists(ctx, id)
 }
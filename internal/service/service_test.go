@@ -0,0 +1,1386 @@
+package service
+
+import (
+	"Effective_Mobile/internal/importer"
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockSubsRepository is a mock implementation of the repository interface
+type MockSubsRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubsRepository) CreateSubs(ctx context.Context, subs *models.Subscription) error {
+	args := m.Called(ctx, subs)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) UpdateSubs(ctx context.Context, id uuid.UUID, version int, newSubs *models.Subscription) (uuid.UUID, error) {
+	args := m.Called(ctx, id, version, newSubs)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockSubsRepository) DeleteSubs(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) BatchCreateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]error, error) {
+	args := m.Called(ctx, subs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockSubsRepository) BatchUpdateSubs(ctx context.Context, subs []*models.Subscription, atomic bool) ([]uuid.UUID, []error, error) {
+	args := m.Called(ctx, subs, atomic)
+	if args.Get(1) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]uuid.UUID), args.Get(1).([]error), args.Error(2)
+}
+
+func (m *MockSubsRepository) BatchDeleteSubs(ctx context.Context, ids []uuid.UUID, atomic bool) ([]error, error) {
+	args := m.Called(ctx, ids, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+// CopySubs drains next like the real COPY does and records the subscriptions it returned.
+func (m *MockSubsRepository) CopySubs(ctx context.Context, next func() (*models.Subscription, error)) (int64, error) {
+	var subs []*models.Subscription
+	for {
+		sub, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		subs = append(subs, sub)
+	}
+	args := m.Called(ctx, subs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams) ([]models.Subscription, error) {
+	args := m.Called(ctx, filter, params)
+	return args.Get(0).([]models.Subscription), args.Error(1)
+}
+
+// StreamSubs passes the subscriptions set up for the call to fn and then returns the set up error.
+func (m *MockSubsRepository) StreamSubs(ctx context.Context, filter models.SubscriptionFilter, params models.ListParams, fn func(sub *models.Subscription) error) error {
+	args := m.Called(ctx, filter, params)
+	for _, sub := range args.Get(0).([]models.Subscription) {
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSubsRepository) ChangeStatus(ctx context.Context, id uuid.UUID, version int, change *models.StatusChange) (int, error) {
+	args := m.Called(ctx, id, version, change)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListPauses(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Pause, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]models.Pause), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListPrices(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.PricePeriod, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]models.PricePeriod), args.Error(1)
+}
+
+func (m *MockSubsRepository) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) DeleteRate(ctx context.Context, currency string, month string) error {
+	args := m.Called(ctx, currency, month)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) ListRates(ctx context.Context, currencies []string, until string) ([]models.ExchangeRate, error) {
+	args := m.Called(ctx, currencies, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ExchangeRate), args.Error(1)
+}
+
+func (m *MockSubsRepository) CreateService(ctx context.Context, svc *models.Service) error {
+	args := m.Called(ctx, svc)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) UpdateService(ctx context.Context, svc *models.Service) error {
+	args := m.Called(ctx, svc)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Service), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListServices(ctx context.Context, category string) ([]models.Service, error) {
+	args := m.Called(ctx, category)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Service), args.Error(1)
+}
+
+func (m *MockSubsRepository) CreateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListUsers(ctx context.Context) ([]models.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockSubsRepository) SetBudget(ctx context.Context, budget *models.Budget) error {
+	args := m.Called(ctx, budget)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) DeleteBudget(ctx context.Context, userID uuid.UUID, category string) error {
+	args := m.Called(ctx, userID, category)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) ListBudgets(ctx context.Context, userID uuid.UUID) ([]models.Budget, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Budget), args.Error(1)
+}
+
+func (m *MockSubsRepository) ListUpcomingSubs(ctx context.Context, from string, to string, after uuid.UUID, limit int) ([]models.Subscription, error) {
+	args := m.Called(ctx, from, to, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Subscription), args.Error(1)
+}
+
+func (m *MockSubsRepository) QueueReminders(ctx context.Context, reminders []models.Reminder) (int64, error) {
+	args := m.Called(ctx, reminders)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubsRepository) ClaimReminders(ctx context.Context, from string, limit int, lease time.Duration, maxAttempts int) ([]models.Reminder, error) {
+	args := m.Called(ctx, from, limit, lease, maxAttempts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Reminder), args.Error(1)
+}
+
+func (m *MockSubsRepository) MarkReminderSent(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.BudgetAlert, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BudgetAlert), args.Error(1)
+}
+
+func (m *MockSubsRepository) CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	args := m.Called(ctx, alert)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSubsRepository) ClaimAlerts(ctx context.Context, limit int, lease time.Duration, maxAttempts int) ([]models.BudgetAlert, error) {
+	args := m.Called(ctx, limit, lease, maxAttempts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BudgetAlert), args.Error(1)
+}
+
+func (m *MockSubsRepository) MarkAlertSent(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) ListSubsInPeriod(ctx context.Context, sum *models.GetSummary) ([]models.Subscription, error) {
+	args := m.Called(ctx, sum)
+	return args.Get(0).([]models.Subscription), args.Error(1)
+}
+
+func (m *MockSubsRepository) GetSub(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubsRepository) SubscriptionExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSubsRepository) ReserveIdempotencyKey(ctx context.Context, key string, requestHash string, ttl time.Duration, lease time.Duration) (*models.IdempotencyRecord, error) {
+	args := m.Called(ctx, key, requestHash, ttl, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockSubsRepository) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, headers map[string]string, response []byte) error {
+	args := m.Called(ctx, key, statusCode, headers, response)
+	return args.Error(0)
+}
+
+func (m *MockSubsRepository) PurgeIdempotencyKeys(ctx context.Context, limit int) (int64, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSubsRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, event *models.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestListSubs(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	filter := models.SubscriptionFilter{}
+	subs := []models.Subscription{
+		{ID: uuid.New(), Price: 100, StartDate: "2025-01-01"},
+		{ID: uuid.New(), Price: 200, StartDate: "2025-02-01"},
+		{ID: uuid.New(), Price: 300, StartDate: "2025-03-01"},
+	}
+
+	// Test case 1: More rows than the limit produce a cursor to the last returned item
+	params := models.ListParams{Limit: 2, SortBy: models.SortByPrice, Desc: true}
+	mockRepo.On("ListSubs", mock.Anything, filter, models.ListParams{Limit: 3, SortBy: models.SortByPrice, Desc: true}).Return(subs, nil).Once()
+
+	page, err := service.ListSubs(context.Background(), filter, params)
+	assert.NoError(t, err)
+	assert.Equal(t, subs[:2], page.Items)
+	cursor, err := models.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Cursor{SortBy: models.SortByPrice, Desc: true, Value: "200", ID: subs[1].ID}, cursor)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 2: Last page has no cursor
+	params = models.ListParams{Limit: 5, SortBy: models.SortByStartDate}
+	mockRepo.On("ListSubs", mock.Anything, filter, models.ListParams{Limit: 6, SortBy: models.SortByStartDate}).Return(subs, nil).Once()
+
+	page, err = service.ListSubs(context.Background(), filter, params)
+	assert.NoError(t, err)
+	assert.Equal(t, subs, page.Items)
+	assert.Empty(t, page.NextCursor)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 3: Empty result is an empty page
+	mockRepo.On("ListSubs", mock.Anything, filter, models.ListParams{Limit: 6, SortBy: models.SortByStartDate}).Return([]models.Subscription(nil), nil).Once()
+
+	page, err = service.ListSubs(context.Background(), filter, params)
+	assert.NoError(t, err)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 4: Invalid limit
+	_, err = service.ListSubs(context.Background(), filter, models.ListParams{})
+	assert.ErrorIs(t, err, ErrValidation)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetSummary(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	req := &models.GetSummaryReq{
+		From: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+	expectedSum := &models.GetSummary{From: "01-2025", To: "12-2025"}
+
+	// Test case 1: Subscriptions are clipped to both ends of the period, paused months are not charged,
+	// every month is charged the price in force in it
+	subs := []models.Subscription{
+		// Active for 6 months inside the period: 07-2025..12-2025, paused in 09-2025..10-2025.
+		{ID: uuid.New(), Price: 40000, Currency: "RUB", StartDate: "2025-07-01"},
+		// Started before the period, ends inside it: 01-2025..03-2025.
+		{ID: uuid.New(), Price: 10000, Currency: "RUB", StartDate: "2024-06-01", EndDate: strPtr("2025-03-31")},
+		// Covers the whole period and beyond: 12 months, the price changed from 5 to 10 in 07-2025.
+		{ID: uuid.New(), Price: 1000, Currency: "RUB", StartDate: "2020-01-01", EndDate: strPtr("2030-01-31")},
+		// Single month subscription priced 1.99.
+		{ID: uuid.New(), Price: 199, Currency: "RUB", StartDate: "2025-05-01", EndDate: strPtr("2025-05-31")},
+	}
+	mockRepo.On("ListSubsInPeriod", mock.Anything, expectedSum).Return(subs, nil).Once()
+	mockRepo.On("ListPauses", mock.Anything, []uuid.UUID{subs[0].ID, subs[1].ID, subs[2].ID, subs[3].ID}).Return(map[uuid.UUID][]models.Pause{
+		subs[0].ID: {{StartDate: "09-2025", EndDate: strPtr("10-2025")}},
+	}, nil).Once()
+	mockRepo.On("ListPrices", mock.Anything, []uuid.UUID{subs[0].ID, subs[1].ID, subs[2].ID, subs[3].ID}).Return(map[uuid.UUID][]models.PricePeriod{
+		subs[2].ID: {{EffectiveFrom: "01-2020", Price: 500, Currency: "RUB"}, {EffectiveFrom: "07-2025", Price: 1000, Currency: "RUB"}},
+	}, nil).Once()
+
+	total, err := service.GetSummary(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Decimal("1991.99"), total) // 400*4 + 100*3 + 5*6 + 10*6 + 1.99
+	mockRepo.AssertExpectations(t)
+
+	// Test case 2: Repository error
+	mockRepo.On("ListSubsInPeriod", mock.Anything, expectedSum).Return([]models.Subscription{}, errors.New("db error")).Once()
+
+	total, err = service.GetSummary(context.Background(), req)
+	assert.Error(t, err)
+	assert.Empty(t, total)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 3: Period end before its start
+	_, err = service.GetSummary(context.Background(), &models.GetSummaryReq{From: req.To, To: req.From})
+	assert.ErrorIs(t, err, ErrValidation)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 4: Costs are converted into the requested currency and the total is rounded to its minor units
+	subs = []models.Subscription{
+		{ID: uuid.New(), Price: 90000, Currency: "RUB", StartDate: "2025-01-01", EndDate: strPtr("2025-03-31")},
+		{ID: uuid.New(), Price: 1000, Currency: "EUR", StartDate: "2025-02-01", EndDate: strPtr("2025-02-28")},
+	}
+	rates := []models.ExchangeRate{
+		{Currency: "EUR", Month: "01-2024", Rate: "100"},
+		{Currency: "USD", Month: "01-2025", Rate: "90"},
+	}
+	expectRates := func(rates []models.ExchangeRate) {
+		mockRepo.On("ListSubsInPeriod", mock.Anything, expectedSum).Return(subs, nil).Once()
+		mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+		mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+		mockRepo.On("ListRates", mock.Anything, []string{"EUR", "USD"}, "12-2025").Return(rates, nil).Once()
+	}
+	expectRates(rates)
+
+	total, err = service.GetSummary(context.Background(), &models.GetSummaryReq{From: req.From, To: req.To, Currency: "usd"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Decimal("41.11"), total) // 3 * 900/90 + 10*100/90
+	mockRepo.AssertExpectations(t)
+
+	// Test case 5: Missing exchange rate
+	expectRates(rates[:1])
+
+	_, err = service.GetSummary(context.Background(), &models.GetSummaryReq{From: req.From, To: req.To, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrValidation)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetGroupedSummary(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	period := models.GetSummaryReq{
+		From: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+	expectedSum := &models.GetSummary{From: "01-2025", To: "12-2025"}
+	subs := []models.Subscription{
+		{ID: uuid.New(), Price: 10000, Currency: "RUB", StartDate: "2025-01-01", EndDate: strPtr("2025-03-31"), Category: "video", Tags: []string{"family", "work"}},
+		{ID: uuid.New(), Price: 5000, Currency: "RUB", StartDate: "2025-01-01", EndDate: strPtr("2025-02-28"), Tags: []string{"work"}},
+		{ID: uuid.New(), Price: 2000, Currency: "RUB", StartDate: "2025-01-01", EndDate: strPtr("2025-01-31"), Category: "video"},
+	}
+	expectSubs := func() {
+		mockRepo.On("ListSubsInPeriod", mock.Anything, expectedSum).Return(subs, nil).Once()
+		mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+		mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+	}
+
+	// Test case 1: Subscriptions without a category are in the group with an empty key
+	expectSubs()
+	summary, err := service.GetGroupedSummary(context.Background(), &models.GetGroupedSummaryReq{GetSummaryReq: period, GroupBy: models.GroupByCategory})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.GroupedSummary{Total: "420.00", Currency: "RUB", Groups: []models.GroupCost{
+		{Key: "", Total: "100.00"},
+		{Key: "video", Total: "320.00"},
+	}}, summary)
+
+	// Test case 2: A subscription counts towards each of its tags
+	expectSubs()
+	summary, err = service.GetGroupedSummary(context.Background(), &models.GetGroupedSummaryReq{GetSummaryReq: period, GroupBy: models.GroupByTag})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.GroupedSummary{Total: "420.00", Currency: "RUB", Groups: []models.GroupCost{
+		{Key: "", Total: "20.00"},
+		{Key: "family", Total: "300.00"},
+		{Key: "work", Total: "400.00"},
+	}}, summary)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 3: Grouping is required
+	_, err = service.GetGroupedSummary(context.Background(), &models.GetGroupedSummaryReq{GetSummaryReq: period})
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = service.GetGroupedSummary(context.Background(), &models.GetGroupedSummaryReq{GetSummaryReq: period, GroupBy: "status"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Work", "family", "work "})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"family", "work"}, tags)
+
+	tags, err = NormalizeTags(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = NormalizeTags([]string{"work", " "})
+	assert.EqualError(t, err, "invalid tag")
+	_, err = NormalizeTags([]string{strings.Repeat("a", 51)})
+	assert.EqualError(t, err, "invalid tag")
+	many := make([]string, 21)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag%d", i)
+	}
+	_, err = NormalizeTags(many)
+	assert.EqualError(t, err, "too many tags")
+}
+
+func TestGetBreakdown(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	userA, userB := uuid.New(), uuid.New()
+	subs := []models.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 40000, Currency: "RUB", UserID: userA, StartDate: "2024-12-01", EndDate: strPtr("2025-01-31")},
+		{ID: uuid.New(), ServiceName: "Spotify", Price: 20000, Currency: "RUB", UserID: userB, StartDate: "2025-01-01"},
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 5050, Currency: "RUB", UserID: userB, StartDate: "2025-02-01"},
+	}
+
+	// Test case 1: Grouped by service name within a bounded period
+	req := &models.GetBreakdownReq{
+		GetSummaryReq: models.GetSummaryReq{
+			From: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		GroupBy: models.GroupByServiceName,
+	}
+	mockRepo.On("ListSubsInPeriod", mock.Anything, &models.GetSummary{From: "01-2025", To: "03-2025"}).Return(subs, nil).Once()
+	// Spotify is paused since 03-2025.
+	mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{
+		subs[1].ID: {{StartDate: "03-2025"}},
+	}, nil).Once()
+	// Spotify cost 150 before 02-2025.
+	mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{
+		subs[1].ID: {{EffectiveFrom: "01-2025", Price: 15000, Currency: "RUB"}, {EffectiveFrom: "02-2025", Price: 20000, Currency: "RUB"}},
+	}, nil).Once()
+
+	breakdown, err := service.GetBreakdown(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MonthlyCost{
+		{Month: "01-2025", Total: "550.00", Groups: []models.GroupCost{{Key: "Netflix", Total: "400.00"}, {Key: "Spotify", Total: "150.00"}}},
+		{Month: "02-2025", Total: "250.50", Groups: []models.GroupCost{{Key: "Netflix", Total: "50.50"}, {Key: "Spotify", Total: "200.00"}}},
+		{Month: "03-2025", Total: "50.50", Groups: []models.GroupCost{{Key: "Netflix", Total: "50.50"}}},
+	}, breakdown)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 2: Unbounded beginning starts at the earliest subscription
+	req = &models.GetBreakdownReq{
+		GetSummaryReq: models.GetSummaryReq{To: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	mockRepo.On("ListSubsInPeriod", mock.Anything, &models.GetSummary{To: "01-2025"}).Return(subs, nil).Once()
+	mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+	mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+
+	breakdown, err = service.GetBreakdown(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MonthlyCost{
+		{Month: "12-2024", Total: "400.00"},
+		{Month: "01-2025", Total: "600.00"},
+	}, breakdown)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 3: Unsupported group by
+	_, err = service.GetBreakdown(context.Background(), &models.GetBreakdownReq{GroupBy: "price"})
+	assert.ErrorIs(t, err, ErrValidation)
+	mockRepo.AssertExpectations(t)
+
+	// Test case 4: A yearly charge falls in a single month, or is spread over its 12 months when amortized
+	yearly := []models.Subscription{
+		{ID: uuid.New(), ServiceName: "Kinopoisk", Price: 299000, Currency: "RUB", BillingInterval: models.IntervalYear, IntervalCount: 1, StartDate: "2025-02-01"},
+	}
+	quarter := models.GetSummaryReq{
+		From: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for range 2 {
+		mockRepo.On("ListSubsInPeriod", mock.Anything, &models.GetSummary{From: "01-2025", To: "03-2025"}).Return(yearly, nil).Once()
+		mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+		mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+	}
+
+	breakdown, err = service.GetBreakdown(context.Background(), &models.GetBreakdownReq{GetSummaryReq: quarter})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MonthlyCost{
+		{Month: "01-2025", Total: "0.00"},
+		{Month: "02-2025", Total: "2990.00"},
+		{Month: "03-2025", Total: "0.00"},
+	}, breakdown)
+
+	quarter.Amortize = true
+	breakdown, err = service.GetBreakdown(context.Background(), &models.GetBreakdownReq{GetSummaryReq: quarter})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MonthlyCost{
+		{Month: "01-2025", Total: "0.00"},
+		{Month: "02-2025", Total: "249.17"},
+		{Month: "03-2025", Total: "249.17"},
+	}, breakdown)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPeriodCost(t *testing.T) {
+	now := time.Date(2025, time.June, 10, 0, 0, 0, 0, time.UTC)
+	rub := converter{target: models.BaseCurrency}
+
+	// Test case 1: Open period end defaults to the current month
+	p := newPeriod(time.Time{}, time.Time{}, now)
+	cost, err := p.cost(&models.Subscription{Price: 100, Currency: "RUB", StartDate: "2025-01-01"}, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", cost.RatString())
+
+	// Test case 2: Subscription entirely outside the period
+	p = newPeriod(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), now)
+	cost, err = p.cost(&models.Subscription{Price: 100, Currency: "RUB", StartDate: "2025-04-01"}, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", cost.RatString())
+
+	// Test case 3: Period across a year boundary
+	p = newPeriod(time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), now)
+	cost, err = p.cost(&models.Subscription{Price: 100, Currency: "RUB", StartDate: "2024-12-01"}, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", cost.RatString())
+
+	// Test case 4: Invalid stored date
+	_, err = p.cost(&models.Subscription{Price: 100, Currency: "RUB", StartDate: "2025-01"}, timeline{}, rub)
+	assert.Error(t, err)
+
+	// Test case 5: Paused months are excluded, an open pause lasts until the end of the period
+	pauses, err := parsePauses([]models.Pause{{StartDate: "10-2024", EndDate: strPtr("11-2024")}, {StartDate: "02-2025"}})
+	assert.NoError(t, err)
+	cost, err = p.cost(&models.Subscription{Price: 100, Currency: "RUB", StartDate: "2024-09-01"}, timeline{pauses: pauses}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", cost.RatString())
+
+	// Test case 6: Every month is charged the price in force in it, earlier months the earliest price
+	prices, err := parsePrices([]models.PricePeriod{{EffectiveFrom: "12-2024", Price: 29900, Currency: "RUB"}, {EffectiveFrom: "02-2025", Price: 39900, Currency: "RUB"}})
+	assert.NoError(t, err)
+	cost, err = p.cost(&models.Subscription{Price: 39900, Currency: "RUB", StartDate: "2024-09-01"}, timeline{prices: prices}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "1296", cost.RatString())
+
+	// Test case 7: Prices are converted with the rate of each month, into a currency other than the base one
+	rates, err := parseRates([]models.ExchangeRate{
+		{Currency: "EUR", Month: "01-2024", Rate: "100"},
+		{Currency: "JPY", Month: "01-2024", Rate: "0.6"},
+		{Currency: "USD", Month: "01-2024", Rate: "80"},
+		{Currency: "USD", Month: "01-2025", Rate: "90"},
+	})
+	assert.NoError(t, err)
+	eur := converter{target: "EUR", rates: rates}
+	cost, err = p.cost(&models.Subscription{Price: 1000, Currency: "USD", StartDate: "2024-11-01"}, timeline{}, eur)
+	assert.NoError(t, err)
+	assert.Equal(t, "34", cost.RatString()) // 8 + 8 + 9 + 9 EUR
+
+	// Test case 8: Prices in a currency without minor units
+	cost, err = p.cost(&models.Subscription{Price: 1000, Currency: "JPY", StartDate: "2024-11-01"}, timeline{}, eur)
+	assert.NoError(t, err)
+	assert.Equal(t, "24", cost.RatString()) // 4 * 1000*0.6/100 EUR
+
+	// Test case 9: Missing rate
+	cost, err = p.cost(&models.Subscription{Price: 1000, Currency: "GBP", StartDate: "2024-11-01"}, timeline{}, eur)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Nil(t, cost)
+
+	// Test case 10: A yearly subscription is charged once a year, or a twelfth in every month when amortized
+	yearly := &models.Subscription{Price: 12000, Currency: "RUB", BillingInterval: models.IntervalYear, IntervalCount: 1, StartDate: "2024-01-01"}
+	cost, err = p.cost(yearly, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "120", cost.RatString())
+	amortized := p
+	amortized.amortize = true
+	cost, err = amortized.cost(yearly, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "40", cost.RatString())
+
+	// Test case 11: A weekly subscription is charged for every week starting in the period
+	weekly := &models.Subscription{Price: 700, Currency: "RUB", BillingInterval: models.IntervalWeek, IntervalCount: 1, StartDate: "2024-11-01"}
+	cost, err = p.cost(weekly, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "126", cost.RatString()) // 5 + 4 + 5 + 4 weeks
+	cost, err = amortized.cost(weekly, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "120", cost.RatString()) // 120 days
+
+	// Test case 12: Partial first and last months of a monthly subscription are pro-rated by days
+	partial := &models.Subscription{Price: 31000, Currency: "RUB", StartDate: "2024-12-17", EndDate: strPtr("2025-02-07")}
+	cost, err = p.cost(partial, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "1075/2", cost.RatString()) // 15/31 + 1 + 7/28 months
+
+	// Test case 13: A yearly subscription ending before its anniversary is not charged again
+	yearly.EndDate = strPtr("2024-12-31")
+	cost, err = p.cost(yearly, timeline{}, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", cost.RatString())
+}
+
+func TestBilling(t *testing.T) {
+	month := func(m time.Month, year int) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	// Test case 1: Yearly billing is charged in the month of every anniversary
+	b := billing{start: month(time.March, 2024), interval: models.IntervalYear, count: 1}
+	assert.Equal(t, 0, b.charges(month(time.February, 2024)))
+	assert.Equal(t, 1, b.charges(month(time.March, 2024)))
+	assert.Equal(t, 0, b.charges(month(time.April, 2024)))
+	assert.Equal(t, 1, b.charges(month(time.March, 2025)))
+	assert.Equal(t, "1/12", b.share(month(time.July, 2024)).RatString())
+	assert.Equal(t, "0", b.share(month(time.January, 2024)).RatString())
+
+	// Test case 2: Every second quarter
+	b = billing{start: month(time.January, 2025), interval: models.IntervalQuarter, count: 2}
+	assert.Equal(t, 0, b.charges(month(time.April, 2025)))
+	assert.Equal(t, 1, b.charges(month(time.July, 2025)))
+	assert.Equal(t, "1/6", b.share(month(time.April, 2025)).RatString())
+
+	// Test case 3: Weekly billing is charged for every week starting in the month
+	b = billing{start: month(time.January, 2025), interval: models.IntervalWeek, count: 1}
+	assert.Equal(t, 5, b.charges(month(time.January, 2025)))
+	assert.Equal(t, 4, b.charges(month(time.February, 2025)))
+	assert.Equal(t, "31/7", b.share(month(time.January, 2025)).RatString())
+	b.count = 2
+	assert.Equal(t, 2, b.charges(month(time.February, 2025)))
+
+	// Test case 4: The zero value is charged every month
+	assert.Equal(t, 1, billing{}.charges(month(time.May, 2025)))
+	assert.Equal(t, "1", billing{}.share(month(time.May, 2025)).RatString())
+
+	// Test case 5: Monthly billing is pro-rated by the days of the month the subscription lasts
+	b = billing{start: time.Date(2025, time.January, 22, 0, 0, 0, 0, time.UTC), end: time.Date(2025, time.March, 9, 0, 0, 0, 0, time.UTC)}
+	assert.Equal(t, "10/31", b.factor(month(time.January, 2025), false).RatString())
+	assert.Equal(t, "1", b.factor(month(time.February, 2025), false).RatString())
+	assert.Equal(t, "9/31", b.factor(month(time.March, 2025), false).RatString())
+	assert.Equal(t, "0", b.factor(month(time.April, 2025), false).RatString())
+
+	// Test case 6: Charges fall on the day of the start, at the end of months that are shorter
+	b = billing{start: time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC), interval: models.IntervalMonth, count: 2,
+		end: time.Date(2024, time.May, 30, 0, 0, 0, 0, time.UTC)}
+	assert.Equal(t, 1, b.charges(month(time.March, 2024)))
+	assert.Equal(t, 0, b.charges(month(time.May, 2024))) // due on May 31st, after the end
+	assert.Equal(t, time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC), addMonths(time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), 12))
+
+	// Test case 7: Weekly charges after the end are not counted
+	b = billing{start: month(time.January, 2025), end: time.Date(2025, time.January, 14, 0, 0, 0, 0, time.UTC), interval: models.IntervalWeek, count: 1}
+	assert.Equal(t, 2, b.charges(month(time.January, 2025)))
+	assert.Equal(t, "2", b.share(month(time.January, 2025)).RatString())
+	_, ok := b.next(time.Date(2025, time.January, 9, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok) // due on January 15th, after the end
+
+	// Test case 8: The next charge is due on the day itself or on the next day a billing period starts
+	b = billing{start: time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC), interval: models.IntervalQuarter, count: 1}
+	next, ok := b.next(time.Date(2024, time.April, 30, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.April, 30, 0, 0, 0, 0, time.UTC), next)
+	next, _ = b.next(time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, time.July, 31, 0, 0, 0, 0, time.UTC), next)
+	next, _ = b.next(time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, b.start, next)
+	b = billing{start: month(time.January, 2025), interval: models.IntervalWeek, count: 2}
+	next, _ = b.next(time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, time.January, 29, 0, 0, 0, 0, time.UTC), next)
+
+	// Test case 9: A subscription with a free trial is charged from the day after the trial
+	b, err := newBilling(&models.Subscription{StartDate: "2025-01-10", TrialEndDate: strPtr("2025-01-23"), BillingInterval: models.IntervalMonth, IntervalCount: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "8/31", b.factor(month(time.January, 2025), false).RatString())
+	next, _ = b.next(time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, time.January, 24, 0, 0, 0, 0, time.UTC), next)
+	assert.False(t, b.lasts(time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, b.active(time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestFormatTotal(t *testing.T) {
+	assert.Equal(t, models.Decimal("0.01"), formatTotal(big.NewRat(5, 1000), "RUB"))
+	assert.Equal(t, models.Decimal("2.33"), formatTotal(big.NewRat(7, 3), "USD"))
+	assert.Equal(t, models.Decimal("-0.01"), formatTotal(big.NewRat(-5, 1000), "RUB"))
+	assert.Equal(t, models.Decimal("3"), formatTotal(big.NewRat(5, 2), "JPY"))
+	assert.Equal(t, models.Decimal("0.00"), formatTotal(new(big.Rat), "RUB"))
+}
+
+func TestValidateRate(t *testing.T) {
+	rate := &models.ExchangeRate{Currency: "usd", Month: "01-2025", Rate: "92.35"}
+	assert.NoError(t, ValidateRate(rate))
+	assert.Equal(t, models.ExchangeRate{Currency: "USD", Month: "01-2025", Rate: "92.35"}, *rate)
+
+	assert.Error(t, ValidateRate(&models.ExchangeRate{Currency: "RUB", Month: "01-2025", Rate: "1"}))
+	assert.Error(t, ValidateRate(&models.ExchangeRate{Currency: "US", Month: "01-2025", Rate: "1"}))
+	assert.Error(t, ValidateRate(&models.ExchangeRate{Currency: "USD", Month: "2025-01", Rate: "1"}))
+	assert.Error(t, ValidateRate(&models.ExchangeRate{Currency: "USD", Month: "01-2025", Rate: "0.00"}))
+	assert.Error(t, ValidateRate(&models.ExchangeRate{Currency: "USD", Month: "01-2025", Rate: "1e3"}))
+}
+
+func TestValidateServiceReq(t *testing.T) {
+	price := models.Decimal("299.00")
+	svc, err := ValidateServiceReq(&models.ServiceReq{Name: " Yandex  Plus ", Category: " music ", DefaultPrice: &price, Currency: "rub", Website: "https://plus.yandex.ru"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Service{Name: "Yandex  Plus", Slug: "yandex-plus", Category: "music", DefaultPrice: 29900, Currency: "RUB", Website: "https://plus.yandex.ru"}, *svc)
+
+	zero, fraction := models.Decimal("0"), models.Decimal("1.999")
+	_, err = ValidateServiceReq(&models.ServiceReq{Name: "---"})
+	assert.EqualError(t, err, "invalid service name")
+	_, err = ValidateServiceReq(&models.ServiceReq{Name: "Netflix", DefaultPrice: &zero})
+	assert.EqualError(t, err, "invalid default price")
+	_, err = ValidateServiceReq(&models.ServiceReq{Name: "Netflix", DefaultPrice: &fraction})
+	assert.ErrorContains(t, err, "invalid default price")
+	_, err = ValidateServiceReq(&models.ServiceReq{Name: "Netflix", Website: "ftp://netflix.com"})
+	assert.EqualError(t, err, "invalid website")
+	_, err = ValidateServiceReq(&models.ServiceReq{Name: "Netflix", Currency: "XX"})
+	assert.Error(t, err)
+}
+
+func TestUserOverview(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	user := &models.User{ID: uuid.New(), Currency: "RUB", Timezone: "Europe/Moscow", MonthlyBudget: 150000}
+	monthly := models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 10000, Currency: "RUB", StartDate: "2025-01-15", Status: models.StatusActive}
+	yearly := models.Subscription{ID: uuid.New(), ServiceName: "Yandex Plus", Price: 120000, Currency: "RUB", BillingInterval: models.IntervalYear, StartDate: "2024-04-01", Status: models.StatusActive}
+	canceled := models.Subscription{ID: uuid.New(), ServiceName: "Spotify", Price: 5000, Currency: "RUB", StartDate: "2025-01-01", EndDate: strPtr("2025-02-28"), Status: models.StatusCanceled}
+
+	// Test case 1: It is already April 1st in the time zone of the user, when the yearly subscription renews
+	now := time.Date(2025, time.March, 31, 22, 30, 0, 0, time.UTC)
+	mockRepo.On("ListSubsInPeriod", mock.Anything, &models.GetSummary{From: "01-2025", To: "04-2025", UserID: &user.ID}).
+		Return([]models.Subscription{monthly, yearly, canceled}, nil).Once()
+	mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+	mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+	overview, err := service.userOverview(context.Background(), user, now)
+	assert.NoError(t, err)
+	budget := models.Decimal("1500.00")
+	assert.Equal(t, &models.UserOverview{
+		UserID:              user.ID,
+		Currency:            "RUB",
+		ActiveSubscriptions: 2,
+		MonthlyCost:         "1300.00",
+		MonthlyBudget:       &budget,
+		// 17/31 of January, February to April of the monthly subscription, the yearly charge and two months of the canceled one
+		YearToDate: "1654.84",
+		NextRenewals: []models.Renewal{
+			{SubscriptionID: yearly.ID, ServiceName: "Yandex Plus", Date: "2025-04-01", Price: "1200.00", Currency: "RUB"},
+			{SubscriptionID: monthly.ID, ServiceName: "Netflix", Date: "2025-04-15", Price: "100.00", Currency: "RUB"},
+		},
+	}, overview)
+
+	// Test case 2: User not found
+	mockRepo.On("GetUser", mock.Anything, user.ID).Return(nil, ErrNotFound).Once()
+	_, err = service.GetUserOverview(context.Background(), user.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidateUserReq(t *testing.T) {
+	budget := models.Decimal("1500.00")
+	user, err := ValidateUserReq(&models.UserReq{DisplayName: " Ivan ", Email: "ivan@example.com", Currency: "usd", MonthlyBudget: &budget})
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{DisplayName: "Ivan", Email: "ivan@example.com", Currency: "USD", Timezone: "UTC", MonthlyBudget: 150000}, *user)
+
+	zero := models.Decimal("0")
+	_, err = ValidateUserReq(&models.UserReq{DisplayName: " "})
+	assert.EqualError(t, err, "invalid display name")
+	_, err = ValidateUserReq(&models.UserReq{DisplayName: "Ivan", Email: "Ivan <ivan@example.com>"})
+	assert.EqualError(t, err, "invalid email")
+	_, err = ValidateUserReq(&models.UserReq{DisplayName: "Ivan", Timezone: "Mars/Olympus"})
+	assert.EqualError(t, err, "invalid timezone")
+	_, err = ValidateUserReq(&models.UserReq{DisplayName: "Ivan", Timezone: "Local"})
+	assert.EqualError(t, err, "invalid timezone")
+	_, err = ValidateUserReq(&models.UserReq{DisplayName: "Ivan", MonthlyBudget: &zero})
+	assert.EqualError(t, err, "invalid monthly budget")
+}
+
+func TestEvaluateBudgets(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	notifier := new(MockNotifier)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, notifier, logger)
+
+	user := &models.User{ID: uuid.New(), Currency: "RUB", Timezone: "Asia/Vladivostok", MonthlyBudget: 100000}
+	budgets := []models.Budget{
+		{UserID: user.ID, Category: "music", Amount: 30000, Currency: "RUB"},
+		{UserID: user.ID, Category: "video", Amount: 1000, Currency: "USD"},
+	}
+	subs := []models.Subscription{
+		{ID: uuid.New(), Price: 39900, Currency: "RUB", StartDate: "2025-01-01", Category: "music"},
+		{ID: uuid.New(), Price: 59900, Currency: "RUB", StartDate: "2025-01-01", Category: "video"},
+	}
+	// It is already May in the time zone of the user.
+	now := time.Date(2025, time.April, 30, 20, 0, 0, 0, time.UTC)
+	month := &models.GetSummary{From: "05-2025", To: "05-2025", UserID: &user.ID}
+	expect := func() {
+		mockRepo.On("GetUser", mock.Anything, user.ID).Return(user, nil).Once()
+		mockRepo.On("ListBudgets", mock.Anything, user.ID).Return(budgets, nil).Once()
+		// The costs are calculated once for every currency of the budgets.
+		mockRepo.On("ListSubsInPeriod", mock.Anything, month).Return(subs, nil).Twice()
+		mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Twice()
+		mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Twice()
+		mockRepo.On("ListRates", mock.Anything, []string{"USD"}, "05-2025").
+			Return([]models.ExchangeRate{{Currency: "USD", Month: "01-2025", Rate: "80"}}, nil).Once()
+	}
+
+	// Test case 1: The music budget is exceeded and recorded without waiting for the notifier;
+	// the overall budget and 7.49 USD of video are not
+	expect()
+	mockRepo.On("CreateAlert", mock.Anything, mock.MatchedBy(func(alert *models.BudgetAlert) bool {
+		return alert.UserID == user.ID && alert.Category == "music" && alert.Month == "05-2025" && alert.Budget == 30000 && alert.Spent == 39900 && alert.Currency == "RUB"
+	})).Return(true, nil).Once()
+	assert.NoError(t, service.evaluateBudgets(context.Background(), user.ID, now))
+
+	// Test case 2: An alert already raised in the month is not recorded again
+	expect()
+	mockRepo.On("CreateAlert", mock.Anything, mock.Anything).Return(false, nil).Once()
+	assert.NoError(t, service.evaluateBudgets(context.Background(), user.ID, now))
+
+	// Test case 3: Users without budgets are not evaluated
+	mockRepo.On("GetUser", mock.Anything, user.ID).Return(&models.User{ID: user.ID, Currency: "RUB", Timezone: "UTC"}, nil).Once()
+	mockRepo.On("ListBudgets", mock.Anything, user.ID).Return([]models.Budget{}, nil).Once()
+	assert.NoError(t, service.evaluateBudgets(context.Background(), user.ID, now))
+
+	mockRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestSendAlerts(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	notifier := new(MockNotifier)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, notifier, logger)
+
+	delivered := models.BudgetAlert{ID: uuid.New(), UserID: uuid.New(), Category: "music", Month: "05-2025"}
+	failed := models.BudgetAlert{ID: uuid.New(), UserID: uuid.New(), Month: "05-2025"}
+
+	// Test case 1: Delivered alerts are marked as sent; failed ones are left to be claimed again
+	mockRepo.On("ClaimAlerts", mock.Anything, 10, deliveryLease, maxDeliveryAttempts).Return([]models.BudgetAlert{delivered, failed}, nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(event *models.Event) bool {
+		return event.ID == delivered.ID && event.Type == models.EventBudgetExceeded && event.Data.(*models.BudgetAlert).Category == "music"
+	})).Return(nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(event *models.Event) bool {
+		return event.ID == failed.ID
+	})).Return(errors.New("connection refused")).Once()
+	mockRepo.On("MarkAlertSent", mock.Anything, delivered.ID).Return(nil).Once()
+	claimed, err := service.SendAlerts(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+
+	// Test case 2: Nothing is claimed without a notifier
+	claimed, err = NewSubscriptionService(mockRepo, 0, nil, logger).SendAlerts(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	mockRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestValidateBudgetReq(t *testing.T) {
+	budget, err := ValidateBudgetReq(" music ", &models.BudgetReq{Amount: "300", Currency: "usd"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Budget{Category: "music", Amount: 30000, Currency: "USD"}, *budget)
+
+	_, err = ValidateBudgetReq(" ", &models.BudgetReq{Amount: "300"})
+	assert.EqualError(t, err, "invalid category")
+	_, err = ValidateBudgetReq("music", &models.BudgetReq{Amount: "0"})
+	assert.EqualError(t, err, "invalid amount")
+	_, err = ValidateBudgetReq("music", &models.BudgetReq{Amount: "1.5", Currency: "JPY"})
+	assert.ErrorContains(t, err, "invalid amount")
+}
+
+func TestQueueReminders(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	renewing := models.Subscription{ID: uuid.New(), UserID: uuid.New(), ServiceName: "Music", Price: 34900, Currency: "RUB", StartDate: "2025-01-10"}
+	trial := models.Subscription{ID: uuid.New(), UserID: uuid.New(), ServiceName: "Video", Price: 59900, Currency: "RUB", StartDate: "2025-02-25", TrialEndDate: strPtr("2025-03-09")}
+	later := models.Subscription{ID: uuid.New(), UserID: uuid.New(), ServiceName: "Books", Price: 19900, Currency: "RUB", StartDate: "2025-01-20"}
+	paused := models.Subscription{ID: uuid.New(), UserID: uuid.New(), ServiceName: "News", Price: 9900, Currency: "RUB", StartDate: "2025-01-09"}
+	// The day is taken in UTC.
+	now := time.Date(2025, time.March, 8, 23, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	// Test case 1: Charges and trial ends within three days are queued at the price in force then;
+	// charges after them or in a paused month are not
+	mockRepo.On("ListUpcomingSubs", mock.Anything, "2025-03-08", "2025-03-11", uuid.Nil, upcomingPageSize).Return([]models.Subscription{renewing, trial, later, paused}, nil).Once()
+	mockRepo.On("ListPauses", mock.Anything, []uuid.UUID{renewing.ID, trial.ID, later.ID, paused.ID}).
+		Return(map[uuid.UUID][]models.Pause{paused.ID: {{StartDate: "03-2025"}}}, nil).Once()
+	mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{renewing.ID: {
+		{EffectiveFrom: "01-2025", Price: 29900, Currency: "RUB"},
+		{EffectiveFrom: "03-2025", Price: 34900, Currency: "RUB"},
+	}}, nil).Once()
+	var queued []models.Reminder
+	mockRepo.On("QueueReminders", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		queued = args.Get(1).([]models.Reminder)
+	}).Return(int64(2), nil).Once()
+	count, err := service.QueueReminders(context.Background(), now, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	for i := range queued {
+		assert.NotEqual(t, uuid.Nil, queued[i].ID)
+		queued[i].ID = uuid.Nil
+	}
+	assert.Equal(t, []models.Reminder{
+		{SubscriptionID: renewing.ID, UserID: renewing.UserID, Kind: models.ReminderRenewal, DueDate: "2025-03-10", ServiceName: "Music", Price: 34900, Currency: "RUB"},
+		{SubscriptionID: trial.ID, UserID: trial.UserID, Kind: models.ReminderRenewal, DueDate: "2025-03-10", ServiceName: "Video", Price: 59900, Currency: "RUB"},
+		{SubscriptionID: trial.ID, UserID: trial.UserID, Kind: models.ReminderTrialEnd, DueDate: "2025-03-09", ServiceName: "Video", Price: 59900, Currency: "RUB"},
+	}, queued)
+
+	// Test case 2: Nothing is queued without upcoming subscriptions
+	mockRepo.On("ListUpcomingSubs", mock.Anything, "2025-03-08", "2025-03-11", uuid.Nil, upcomingPageSize).Return([]models.Subscription{}, nil).Once()
+	count, err = service.QueueReminders(context.Background(), now, 3)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Test case 3: A full page of subscriptions is followed by the page after its last subscription
+	page := make([]models.Subscription, upcomingPageSize)
+	for i := range page {
+		page[i] = models.Subscription{ID: uuid.New(), UserID: uuid.New(), ServiceName: "Books", Price: 19900, Currency: "RUB", StartDate: "2025-01-20"}
+	}
+	page[len(page)-1] = renewing
+	mockRepo.On("ListUpcomingSubs", mock.Anything, "2025-03-08", "2025-03-11", uuid.Nil, upcomingPageSize).Return(page, nil).Once()
+	mockRepo.On("ListPauses", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.Pause{}, nil).Once()
+	mockRepo.On("ListPrices", mock.Anything, mock.Anything).Return(map[uuid.UUID][]models.PricePeriod{}, nil).Once()
+	mockRepo.On("QueueReminders", mock.Anything, mock.MatchedBy(func(reminders []models.Reminder) bool {
+		return len(reminders) == 1 && reminders[0].SubscriptionID == renewing.ID
+	})).Return(int64(1), nil).Once()
+	mockRepo.On("ListUpcomingSubs", mock.Anything, "2025-03-08", "2025-03-11", renewing.ID, upcomingPageSize).Return([]models.Subscription{}, nil).Once()
+	count, err = service.QueueReminders(context.Background(), now, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendReminders(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	notifier := new(MockNotifier)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, notifier, logger)
+
+	delivered := models.Reminder{ID: uuid.New(), SubscriptionID: uuid.New(), Kind: models.ReminderTrialEnd, DueDate: "2025-03-09"}
+	failed := models.Reminder{ID: uuid.New(), SubscriptionID: uuid.New(), Kind: models.ReminderRenewal, DueDate: "2025-03-10"}
+	now := time.Date(2025, time.March, 8, 12, 0, 0, 0, time.UTC)
+
+	// Test case 1: Delivered reminders are marked as sent; failed ones are left to be claimed again
+	mockRepo.On("ClaimReminders", mock.Anything, "2025-03-08", 10, deliveryLease, maxDeliveryAttempts).Return([]models.Reminder{delivered, failed}, nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(event *models.Event) bool {
+		return event.ID == delivered.ID && event.Type == models.EventTrialEnding
+	})).Return(nil).Once()
+	notifier.On("Notify", mock.Anything, mock.MatchedBy(func(event *models.Event) bool {
+		return event.ID == failed.ID && event.Type == models.EventRenewalUpcoming
+	})).Return(errors.New("connection refused")).Once()
+	mockRepo.On("MarkReminderSent", mock.Anything, delivered.ID).Return(nil).Once()
+	claimed, err := service.SendReminders(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+
+	// Test case 2: Nothing is claimed without a notifier
+	claimed, err = NewSubscriptionService(mockRepo, 0, nil, logger).SendReminders(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	mockRepo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	// Test case 1: New key is reserved
+	mockRepo.On("ReserveIdempotencyKey", mock.Anything, "new", "hash", DefaultIdempotencyTTL, idempotencyLease).Return(nil, nil).Once()
+	record, err := service.BeginIdempotentRequest(context.Background(), "new", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	// Test case 2: Stored response is replayed
+	stored := &models.IdempotencyRecord{Key: "done", RequestHash: "hash", StatusCode: 200, Response: []byte(`{}`)}
+	mockRepo.On("ReserveIdempotencyKey", mock.Anything, "done", "hash", DefaultIdempotencyTTL, idempotencyLease).Return(stored, nil).Once()
+	record, err = service.BeginIdempotentRequest(context.Background(), "done", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, stored, record)
+
+	// Test case 3: Key reused with a different request
+	mockRepo.On("ReserveIdempotencyKey", mock.Anything, "done", "other", DefaultIdempotencyTTL, idempotencyLease).Return(stored, nil).Once()
+	_, err = service.BeginIdempotentRequest(context.Background(), "done", "other")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Test case 4: First request is still in progress
+	pending := &models.IdempotencyRecord{Key: "pending", RequestHash: "hash"}
+	mockRepo.On("ReserveIdempotencyKey", mock.Anything, "pending", "hash", DefaultIdempotencyTTL, idempotencyLease).Return(pending, nil).Once()
+	_, err = service.BeginIdempotentRequest(context.Background(), "pending", "hash")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchCreateSubs(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	// Test case 1: Batch is passed to the repository
+	subs := []*models.Subscription{{ID: uuid.New()}, {ID: uuid.New()}}
+	itemErr := fmt.Errorf("failed to create subscription: %w", ErrConflict)
+	mockRepo.On("BatchCreateSubs", mock.Anything, subs, false).Return([]error{nil, itemErr}, nil).Once()
+
+	errs, err := service.BatchCreateSubs(context.Background(), subs, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, itemErr}, errs)
+
+	// Test case 2: Empty and oversized batches are rejected
+	_, err = service.BatchCreateSubs(context.Background(), nil, true)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.BatchDeleteSubs(context.Background(), make([]uuid.UUID, MaxBatchSize+1), true)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateSubsOwners(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	oldOwner := &models.User{ID: uuid.New(), Currency: "RUB", Timezone: "UTC"}
+	newOwner := &models.User{ID: uuid.New(), Currency: "RUB", Timezone: "UTC"}
+	expectBudgets := func(users ...*models.User) {
+		for _, user := range users {
+			mockRepo.On("GetUser", mock.Anything, user.ID).Return(user, nil).Once()
+			mockRepo.On("ListBudgets", mock.Anything, user.ID).Return([]models.Budget{}, nil).Once()
+		}
+	}
+
+	// Test case 1: A subscription moved to another user is checked against the budgets of both users
+	sub := &models.Subscription{ID: uuid.New(), UserID: newOwner.ID}
+	mockRepo.On("UpdateSubs", mock.Anything, sub.ID, 3, sub).Return(oldOwner.ID, nil).Once()
+	expectBudgets(oldOwner, newOwner)
+	assert.NoError(t, service.UpdateSubs(context.Background(), sub.ID, 3, sub))
+
+	// Test case 2: The previous owners of the updated subscriptions of a batch are checked as well
+	subs := []*models.Subscription{{ID: uuid.New(), UserID: newOwner.ID}, {ID: uuid.New(), UserID: newOwner.ID}}
+	itemErr := fmt.Errorf("subscription version 1: %w", ErrPreconditionFailed)
+	mockRepo.On("BatchUpdateSubs", mock.Anything, subs, false).Return([]uuid.UUID{oldOwner.ID, uuid.Nil}, []error{nil, itemErr}, nil).Once()
+	expectBudgets(newOwner, oldOwner)
+	errs, err := service.BatchUpdateSubs(context.Background(), subs, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, itemErr}, errs)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportSubs(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	userID := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	file := "service_name,price,user_id,start_date,end_date\n" +
+		"Yandex Plus,400," + userID + ",07-2025,\n" +
+		",400," + userID + ",07-2025,\n" +
+		"Netflix,abc," + userID + ",07-2025,\n" +
+		"Netflix,300," + userID + ",08-2025,01-2025\n" +
+		"Netflix,299.90," + userID + ",08-2025,12-2025\n"
+
+	// Test case 1: Valid rows are copied, invalid ones are reported by line
+	reader, err := importer.NewReader(strings.NewReader(file), importer.Options{Format: importer.FormatCSV})
+	assert.NoError(t, err)
+	mockRepo.On("CopySubs", mock.Anything, mock.MatchedBy(func(subs []*models.Subscription) bool {
+		return len(subs) == 2 && subs[0].ServiceName == "Yandex Plus" && subs[0].EndDate == nil &&
+			subs[1].StartDate == "2025-08-01" && *subs[1].EndDate == "2025-12-31" && subs[1].Price == 29990 && subs[0].ID != subs[1].ID
+	})).Return(int64(2), nil).Once()
+	// The budgets of the user are evaluated once for the whole import
+	user := &models.User{ID: uuid.MustParse(userID), Currency: "RUB", Timezone: "UTC"}
+	mockRepo.On("GetUser", mock.Anything, user.ID).Return(user, nil).Once()
+	mockRepo.On("ListBudgets", mock.Anything, user.ID).Return([]models.Budget{}, nil).Once()
+
+	report, err := service.ImportSubs(context.Background(), reader)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), report.Imported)
+	assert.Equal(t, 3, report.Rejected)
+	assert.Equal(t, []models.ImportError{
+		{Line: 3, Error: "invalid service name"},
+		{Line: 4, Error: "invalid price: not a decimal number"},
+		{Line: 5, Error: "end date is before start date"},
+	}, report.Errors)
+
+	// Test case 2: Unreadable file fails the whole import
+	reader, err = importer.NewReader(iotest.ErrReader(errors.New("connection reset")), importer.Options{Format: importer.FormatNDJSON})
+	assert.NoError(t, err)
+
+	_, err = service.ImportSubs(context.Background(), reader)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportSubs(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	// Test case 1: Page size and cursor are dropped, the sort order is kept
+	filter := models.SubscriptionFilter{OpenEnded: true}
+	params := models.ListParams{Limit: 50, SortBy: models.SortByPrice, Desc: true, Cursor: &models.Cursor{ID: uuid.New()}}
+	subs := []models.Subscription{{ID: uuid.New()}, {ID: uuid.New()}}
+	mockRepo.On("StreamSubs", mock.Anything, filter, models.ListParams{SortBy: models.SortByPrice, Desc: true}).Return(subs, nil).Once()
+
+	var exported []uuid.UUID
+	err := service.ExportSubs(context.Background(), filter, params, func(sub *models.Subscription) error {
+		exported = append(exported, sub.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{subs[0].ID, subs[1].ID}, exported)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransition(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		sub    models.Subscription
+		action string
+		mode   string
+		want   *models.StatusChange
+		err    bool
+	}{
+		{
+			name:   "pause active",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusActive},
+			action: actionPause,
+			want:   &models.StatusChange{Status: models.StatusPaused, OpenPause: strPtr("06-2025")},
+		},
+		{
+			name:   "pause before start",
+			sub:    models.Subscription{StartDate: "2025-09-01", Status: models.StatusActive},
+			action: actionPause,
+			want:   &models.StatusChange{Status: models.StatusPaused, OpenPause: strPtr("09-2025")},
+		},
+		{
+			name:   "pause before start in the middle of a month",
+			sub:    models.Subscription{StartDate: "2025-09-15", Status: models.StatusActive},
+			action: actionPause,
+			want:   &models.StatusChange{Status: models.StatusPaused, OpenPause: strPtr("09-2025")},
+		},
+		{
+			name:   "pause ended",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-05-31"), Status: models.StatusActive},
+			action: actionPause,
+			err:    true,
+		},
+		{
+			name:   "pause paused",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusPaused},
+			action: actionPause,
+			err:    true,
+		},
+		{
+			name:   "resume paused",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-12-31"), Status: models.StatusPaused},
+			action: actionResume,
+			want:   &models.StatusChange{Status: models.StatusActive, EndDate: strPtr("2025-12-31"), ClosePause: strPtr("05-2025")},
+		},
+		{
+			name:   "resume active",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusActive},
+			action: actionResume,
+			err:    true,
+		},
+		{
+			name:   "cancel at period end",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusActive},
+			action: actionCancel,
+			mode:   models.CancelAtPeriodEnd,
+			want:   &models.StatusChange{Status: models.StatusCanceled, EndDate: strPtr("2025-06-30")},
+		},
+		{
+			name:   "cancel immediately while paused",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusPaused},
+			action: actionCancel,
+			mode:   models.CancelImmediately,
+			want:   &models.StatusChange{Status: models.StatusCanceled, EndDate: strPtr("2025-05-31"), ClosePause: strPtr("05-2025")},
+		},
+		{
+			name:   "cancel keeps earlier end",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-03-31"), Status: models.StatusActive},
+			action: actionCancel,
+			mode:   models.CancelAtPeriodEnd,
+			want:   &models.StatusChange{Status: models.StatusCanceled, EndDate: strPtr("2025-03-31")},
+		},
+		{
+			name:   "cancel keeps earlier end in the middle of a month",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-06-10"), Status: models.StatusActive},
+			action: actionCancel,
+			mode:   models.CancelAtPeriodEnd,
+			want:   &models.StatusChange{Status: models.StatusCanceled, EndDate: strPtr("2025-06-10")},
+		},
+		{
+			name:   "cancel immediately before first paid month",
+			sub:    models.Subscription{StartDate: "2025-06-01", Status: models.StatusActive},
+			action: actionCancel,
+			mode:   models.CancelImmediately,
+			err:    true,
+		},
+		{
+			name:   "cancel canceled",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-06-30"), Status: models.StatusCanceled},
+			action: actionCancel,
+			mode:   models.CancelAtPeriodEnd,
+			err:    true,
+		},
+		{
+			name:   "reactivate before end",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-06-30"), Status: models.StatusCanceled},
+			action: actionReactivate,
+			want:   &models.StatusChange{Status: models.StatusActive},
+		},
+		{
+			name:   "reactivate after end",
+			sub:    models.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-02-28"), Status: models.StatusCanceled},
+			action: actionReactivate,
+			want: &models.StatusChange{Status: models.StatusActive,
+				AddPause: &models.Pause{StartDate: "03-2025", EndDate: strPtr("05-2025")}},
+		},
+		{
+			name:   "reactivate active",
+			sub:    models.Subscription{StartDate: "2025-01-01", Status: models.StatusActive},
+			action: actionReactivate,
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := transition(&tt.sub, tt.action, tt.mode, now)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrInvalidTransition)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, change)
+		})
+	}
+}
+
+func TestCancelSubs(t *testing.T) {
+	mockRepo := new(MockSubsRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewSubscriptionService(mockRepo, 0, nil, logger)
+
+	id := uuid.New()
+	lastDay := monthEnd(time.Now()).Format(dateLayout)
+
+	// Test case 1: Subscription is canceled at the end of the current month
+	mockRepo.On("GetSub", mock.Anything, id).Return(&models.Subscription{ID: id, StartDate: "2020-01-01", Version: 2, Status: models.StatusActive}, nil).Once()
+	mockRepo.On("ChangeStatus", mock.Anything, id, 2, &models.StatusChange{Status: models.StatusCanceled, EndDate: &lastDay}).Return(3, nil).Once()
+
+	sub, err := service.CancelSubs(context.Background(), id, 2, models.CancelAtPeriodEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusCanceled, sub.Status)
+	assert.Equal(t, lastDay, *sub.EndDate)
+	assert.Equal(t, 3, sub.Version)
+
+	// Test case 2: Stale version
+	mockRepo.On("GetSub", mock.Anything, id).Return(&models.Subscription{ID: id, StartDate: "2020-01-01", Version: 3, Status: models.StatusCanceled}, nil).Once()
+
+	_, err = service.CancelSubs(context.Background(), id, 2, models.CancelAtPeriodEnd)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	// Test case 3: Unsupported mode
+	_, err = service.CancelSubs(context.Background(), id, 0, "tomorrow")
+	assert.ErrorIs(t, err, ErrValidation)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"math/big"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Embedded time zone database, so that user time zones resolve on hosts without one.
+	_ "time/tzdata"
+)
+
+// Length limits of the text fields of users.
+const (
+	maxDisplayNameLength = 255
+	maxEmailLength       = 320
+	maxTimezoneLength    = 64
+)
+
+// maxRenewals is the number of upcoming renewals listed in a user overview.
+const maxRenewals = 5
+
+// CreateUser registers the user.
+// Returns ErrConflict if the ID or the email is taken.
+func (c *SubscriptionService) CreateUser(ctx context.Context, user *models.User) error {
+	if err := c.repository.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	c.log.Info("User created", zap.String("id", user.ID.String()))
+	return nil
+}
+
+// UpdateUser replaces the user with the ID of user.
+// Returns ErrNotFound if there is no such user, or ErrConflict if another user has the email.
+func (c *SubscriptionService) UpdateUser(ctx context.Context, user *models.User) error {
+	if err := c.repository.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	c.log.Info("User updated", zap.String("id", user.ID.String()))
+	return nil
+}
+
+// DeleteUser removes the user.
+// Returns ErrNotFound if there is no such user, or ErrConflict if the user still has subscriptions.
+func (c *SubscriptionService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return c.repository.DeleteUser(ctx, id)
+}
+
+// GetUser returns the user with the ID, or ErrNotFound.
+func (c *SubscriptionService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return c.repository.GetUser(ctx, id)
+}
+
+// ListUsers returns all users ordered by display name.
+func (c *SubscriptionService) ListUsers(ctx context.Context) ([]models.User, error) {
+	return c.repository.ListUsers(ctx)
+}
+
+// GetUserOverview summarizes the spending of the user in their preferred currency as of the current day
+// in their time zone: the number of active subscriptions, the cost of the current month and of the year
+// so far, and the next renewals of active subscriptions. Returns ErrNotFound if there is no such user.
+func (c *SubscriptionService) GetUserOverview(ctx context.Context, id uuid.UUID) (*models.UserOverview, error) {
+	user, err := c.repository.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.userOverview(ctx, user, time.Now())
+}
+
+// userOverview builds the overview of the user as of the instant now.
+func (c *SubscriptionService) userOverview(ctx context.Context, user *models.User, now time.Time) (*models.UserOverview, error) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		c.log.Error("Invalid user time zone", zap.String("id", user.ID.String()), zap.Error(err))
+		return nil, fmt.Errorf("invalid time zone %q: %w", user.Timezone, err)
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	month := monthStart(today)
+
+	basis, err := c.costBasis(ctx, &models.GetSummaryReq{
+		From:     time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:       month,
+		UserID:   &user.ID,
+		Currency: user.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &models.UserOverview{
+		UserID:       user.ID,
+		Currency:     basis.converter.target,
+		NextRenewals: []models.Renewal{},
+	}
+	yearToDate, monthly := new(big.Rat), new(big.Rat)
+	for _, sub := range basis.subs {
+		start, end, ok, err := basis.period.activeRange(&sub)
+		if err != nil {
+			c.log.Error("Invalid subscription dates", zap.String("id", sub.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		b, err := newBilling(&sub)
+		if err != nil {
+			c.log.Error("Invalid subscription dates", zap.String("id", sub.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		t := basis.timelines[sub.ID]
+		for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+			amount, err := basis.period.monthCost(&sub, b, t, basis.converter, m)
+			if err != nil {
+				c.log.Error("Failed to calculate subscription cost", zap.String("id", sub.ID.String()), zap.Error(err))
+				return nil, err
+			}
+			if amount == nil {
+				continue
+			}
+			yearToDate.Add(yearToDate, amount)
+			if m.Equal(month) {
+				monthly.Add(monthly, amount)
+			}
+		}
+
+		if sub.Status != models.StatusActive || !b.active(today) {
+			continue
+		}
+		overview.ActiveSubscriptions++
+		if charge, ok := b.next(today); ok {
+			price, currency := t.prices.at(monthStart(charge), &sub)
+			overview.NextRenewals = append(overview.NextRenewals, models.Renewal{
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.ServiceName,
+				Date:           charge.Format(dateLayout),
+				Price:          models.FormatAmount(price, currency),
+				Currency:       currency,
+			})
+		}
+	}
+
+	sort.SliceStable(overview.NextRenewals, func(i, j int) bool {
+		return overview.NextRenewals[i].Date < overview.NextRenewals[j].Date
+	})
+	if len(overview.NextRenewals) > maxRenewals {
+		overview.NextRenewals = overview.NextRenewals[:maxRenewals]
+	}
+	overview.MonthlyCost = formatTotal(monthly, basis.converter.target)
+	overview.YearToDate = formatTotal(yearToDate, basis.converter.target)
+	if user.MonthlyBudget != 0 {
+		budget := models.FormatAmount(user.MonthlyBudget, user.Currency)
+		overview.MonthlyBudget = &budget
+	}
+
+	c.log.Debug("User overview calculated", zap.String("id", user.ID.String()), zap.Int("subscriptions", len(basis.subs)))
+	return overview, nil
+}
+
+// ValidateUserReq checks a user received from a client: a non-empty display name, a valid email
+// if given, a valid currency code, an IANA time zone (UTC if not given) and a positive monthly budget
+// in that currency if given. Returns the user described by the request, without an ID.
+func ValidateUserReq(req *models.UserReq) (*models.User, error) {
+	name := strings.TrimSpace(req.DisplayName)
+	if name == "" || utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return nil, errors.New("invalid display name")
+	}
+	email := strings.TrimSpace(req.Email)
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email || len(email) > maxEmailLength {
+			return nil, errors.New("invalid email")
+		}
+	}
+	currency, err := ValidateCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	// LoadLocation treats "Local" as the zone of the server, which is not a zone a user can be in.
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" || len(timezone) > maxTimezoneLength {
+		return nil, errors.New("invalid timezone")
+	}
+	var budget int64
+	if req.MonthlyBudget != nil {
+		if budget, err = models.ParseAmount(*req.MonthlyBudget, currency); err != nil {
+			return nil, fmt.Errorf("invalid monthly budget: %s", err)
+		}
+		if budget <= 0 {
+			return nil, errors.New("invalid monthly budget")
+		}
+	}
+	return &models.User{
+		DisplayName:   name,
+		Email:         email,
+		Currency:      currency,
+		Timezone:      timezone,
+		MonthlyBudget: budget,
+	}, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"Effective_Mobile/internal/models"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// currencyPattern matches ISO 4217 currency codes.
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// maxIntervalCount is the largest number of billing intervals between two charges of a subscription.
+const maxIntervalCount = 100
+
+// Limits of the tags of a subscription.
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+// ValidateSubReq performs validation on subscription data received from a client or read from an import file.
+// It checks for a service given by ID or by a non-empty service name, valid currency code, positive price with no more decimal places
+// than the currency has, supported billing interval, valid user ID, and correct date formats; the optional
+// end date must not be before the start date and the optional trial end date must fall within the subscription.
+// The currency, billing interval and dates are normalized in place, the dates to YYYY-MM-DD.
+// Returns the subscription described by the request, without an ID, or an error if validation fails.
+func ValidateSubReq(sub *models.SubReq) (*models.Subscription, error) {
+	// Validate the service: a catalog ID, or a name whose slug identifies it.
+	var serviceID uuid.UUID
+	if sub.ServiceID != nil {
+		if *sub.ServiceID == uuid.Nil {
+			return nil, errors.New("invalid service id")
+		}
+		serviceID = *sub.ServiceID
+	} else if models.Slug(sub.ServiceName) == "" {
+		return nil, errors.New("invalid service name")
+	}
+	sub.ServiceName = strings.TrimSpace(sub.ServiceName)
+	// Validate Currency, which defaults to the base currency.
+	currency, err := ValidateCurrency(sub.Currency)
+	if err != nil {
+		return nil, err
+	}
+	sub.Currency = currency
+	// Validate Price, a decimal in the currency stored in its minor units.
+	price, err := models.ParseAmount(sub.Price, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price: %s", err)
+	}
+	if price <= 0 {
+		return nil, errors.New("invalid price")
+	}
+	// Validate the billing interval, which defaults to a single month.
+	switch sub.BillingInterval {
+	case "":
+		sub.BillingInterval = models.IntervalMonth
+	case models.IntervalWeek, models.IntervalMonth, models.IntervalQuarter, models.IntervalYear:
+	default:
+		return nil, errors.New("invalid billing interval")
+	}
+	if sub.IntervalCount == 0 {
+		sub.IntervalCount = 1
+	}
+	if sub.IntervalCount < 0 || sub.IntervalCount > maxIntervalCount {
+		return nil, errors.New("invalid interval count")
+	}
+	// Validate UserID
+	if sub.UserID == uuid.Nil {
+		return nil, errors.New("invalid user id")
+	}
+	// Validate the optional category and tags.
+	category := strings.TrimSpace(sub.Category)
+	if utf8.RuneCountInString(category) > maxCategoryLength {
+		return nil, errors.New("invalid category")
+	}
+	tags, err := NormalizeTags(sub.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse and validate StartDate, a date or a month starting on its first day.
+	startDate, err := parseDate(sub.StartDate, false)
+	if err != nil {
+		return nil, errors.New("invalid start date")
+	}
+	sub.StartDate = startDate.Format(dateLayout)
+	// Parse and validate optional EndDate, a date or a month ending on its last day.
+	if sub.EndDate != nil {
+		endDate, err := parseDate(*sub.EndDate, true)
+		if err != nil {
+			return nil, errors.New("invalid end date")
+		}
+		if endDate.Before(startDate) {
+			return nil, errors.New("end date is before start date")
+		}
+		*sub.EndDate = endDate.Format(dateLayout)
+	}
+	// Parse and validate the optional TrialEndDate, which must fall within the subscription.
+	if sub.TrialEndDate != nil {
+		trialEnd, err := parseDate(*sub.TrialEndDate, true)
+		if err != nil {
+			return nil, errors.New("invalid trial end date")
+		}
+		if trialEnd.Before(startDate) {
+			return nil, errors.New("trial end date is before start date")
+		}
+		if sub.EndDate != nil && *sub.EndDate < trialEnd.Format(dateLayout) {
+			return nil, errors.New("trial end date is after end date")
+		}
+		*sub.TrialEndDate = trialEnd.Format(dateLayout)
+	}
+
+	return &models.Subscription{
+		ServiceID:       serviceID,
+		ServiceName:     sub.ServiceName,
+		Price:           price,
+		Currency:        currency,
+		BillingInterval: sub.BillingInterval,
+		IntervalCount:   sub.IntervalCount,
+		UserID:          sub.UserID,
+		StartDate:       sub.StartDate,
+		EndDate:         sub.EndDate,
+		TrialEndDate:    sub.TrialEndDate,
+		Category:        category,
+		Tags:            tags,
+	}, nil
+}
+
+// NormalizeTags trims the tags and converts them to lower case, and returns them sorted without duplicates,
+// or nil if there are none. Returns an error if a tag is empty or too long, or if there are too many tags.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, errors.New("invalid tag")
+		}
+		set[tag] = true
+	}
+	if len(set) > maxTags {
+		return nil, errors.New("too many tags")
+	}
+	normalized := make([]string, 0, len(set))
+	for tag := range set {
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// parseDate parses a subscription date given as an ISO 8601 date (YYYY-MM-DD), as an RFC 3339 timestamp,
+// of which only the date is kept, or as a MM-YYYY month. A month stands for its first day,
+// or for its last day if last is set, so that it covers the whole month as a start or end date.
+func parseDate(s string, last bool) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, s); err == nil {
+		return date, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, s); err == nil {
+		return time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	month, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last {
+		return monthEnd(month), nil
+	}
+	return month, nil
+}
+
+// ValidateCurrency checks that code is an ISO 4217 currency code, in any letter case.
+// Returns the code in upper case, or models.BaseCurrency if code is empty.
+func ValidateCurrency(code string) (string, error) {
+	if code == "" {
+		return models.BaseCurrency, nil
+	}
+	code = strings.ToUpper(code)
+	if !currencyPattern.MatchString(code) {
+		return "", errors.New("invalid currency")
+	}
+	return code, nil
+}
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Даты подписок хранятся как DATE (первое число месяца) вместо строк MM-YYYY,
+-- чтобы сравнение периодов работало корректно на границе годов.
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_start_date_check;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE DATE USING to_date(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE DATE USING to_date(end_date, 'MM-YYYY');
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_start_date_check CHECK (EXTRACT(DAY FROM start_date) = 1),
+    ADD CONSTRAINT subscriptions_end_date_check CHECK (EXTRACT(DAY FROM end_date) = 1 AND end_date >= start_date);
+
+
+-- +goose Down
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_start_date_check;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE VARCHAR(7) USING to_char(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE VARCHAR(7) USING to_char(end_date, 'MM-YYYY');
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_start_date_check CHECK (start_date ~ '^\d{2}-\d{4}$'),
+    ADD CONSTRAINT subscriptions_end_date_check CHECK (end_date ~ '^\d{2}-\d{4}$');
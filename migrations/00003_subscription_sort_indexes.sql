@@ -0,0 +1,11 @@
+-- +goose Up
+-- Индексы для постраничной выборки по курсору: сортировка по полю с id в качестве второго ключа
+CREATE INDEX idx_subscriptions_price_id ON subscriptions(price, id);
+CREATE INDEX idx_subscriptions_start_date_id ON subscriptions(start_date, id);
+CREATE INDEX idx_subscriptions_service_name_id ON subscriptions(service_name, id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_price_id;
+DROP INDEX IF EXISTS idx_subscriptions_start_date_id;
+DROP INDEX IF EXISTS idx_subscriptions_service_name_id;
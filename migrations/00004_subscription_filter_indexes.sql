@@ -0,0 +1,7 @@
+-- +goose Up
+-- Индекс для поиска по началу названия сервиса без учета регистра
+CREATE INDEX idx_subscriptions_service_name_lower ON subscriptions(lower(service_name) text_pattern_ops);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_service_name_lower;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Сохраненные ответы на запросы с заголовком Idempotency-Key.
+-- status_code и response пусты, пока первый запрос с ключом еще выполняется.
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER,
+    response BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Для удаления устаревших ключей
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
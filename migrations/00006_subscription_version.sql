@@ -0,0 +1,8 @@
+-- +goose Up
+-- Версия подписки для оптимистичной блокировки: увеличивается при каждом изменении
+-- и передается клиенту в заголовке ETag.
+ALTER TABLE subscriptions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS version;
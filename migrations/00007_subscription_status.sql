@@ -0,0 +1,20 @@
+-- +goose Up
+-- Состояние подписки в жизненном цикле: активна, приостановлена или отменена.
+ALTER TABLE subscriptions ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CONSTRAINT subscriptions_status_check CHECK (status IN ('active', 'paused', 'canceled'));
+
+-- Периоды приостановки подписки: месяцы с start_date по end_date включительно не оплачиваются.
+-- end_date пуст, пока подписка приостановлена; открытый период может быть только один.
+CREATE TABLE subscription_pauses (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL CHECK (EXTRACT(DAY FROM start_date) = 1),
+    end_date DATE CHECK (EXTRACT(DAY FROM end_date) = 1 AND end_date >= start_date),
+    PRIMARY KEY (subscription_id, start_date)
+);
+
+CREATE UNIQUE INDEX idx_subscription_pauses_open ON subscription_pauses(subscription_id) WHERE end_date IS NULL;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS subscription_pauses;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- История цен подписки: каждая цена действует с месяца effective_from до месяца, с которого действует следующая.
+-- Для месяцев до первой записи применяется самая ранняя цена.
+CREATE TABLE subscription_prices (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL CHECK (EXTRACT(DAY FROM effective_from) = 1),
+    price INTEGER NOT NULL CHECK (price > 0),
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+-- Текущая цена существующих подписок считается действующей с начала подписки.
+INSERT INTO subscription_prices (subscription_id, effective_from, price)
+SELECT id, start_date, price FROM subscriptions;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS subscription_prices;
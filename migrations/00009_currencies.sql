@@ -0,0 +1,21 @@
+-- +goose Up
+-- Валюта цены подписки (код ISO 4217). Существующие подписки считаются оплаченными в рублях.
+ALTER TABLE subscriptions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB'
+    CONSTRAINT subscriptions_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE subscription_prices ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB'
+    CONSTRAINT subscription_prices_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Курсы валют: стоимость одной единицы валюты в рублях, действующая с месяца month
+-- до месяца следующего курса той же валюты.
+CREATE TABLE exchange_rates (
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'RUB'),
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (currency, month)
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE subscription_prices DROP COLUMN IF EXISTS currency;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
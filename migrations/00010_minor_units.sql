@@ -0,0 +1,37 @@
+-- +goose Up
+-- Цены хранятся в минимальных единицах валюты (копейках, центах), чтобы суммы считались без округлений.
+-- Число знаков после запятой зависит от валюты по ISO 4217: у иены их нет, у кувейтского динара три.
+ALTER TABLE subscriptions ALTER COLUMN price TYPE BIGINT;
+ALTER TABLE subscription_prices ALTER COLUMN price TYPE BIGINT;
+
+UPDATE subscriptions SET price = price * CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    WHEN currency IN ('CLF', 'UYW') THEN 10000
+    ELSE 100
+END;
+UPDATE subscription_prices SET price = price * CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    WHEN currency IN ('CLF', 'UYW') THEN 10000
+    ELSE 100
+END;
+
+
+-- +goose Down
+-- Дробная часть цен при откате отбрасывается.
+UPDATE subscription_prices SET price = GREATEST(price / CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    WHEN currency IN ('CLF', 'UYW') THEN 10000
+    ELSE 100
+END, 1);
+UPDATE subscriptions SET price = GREATEST(price / CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    WHEN currency IN ('CLF', 'UYW') THEN 10000
+    ELSE 100
+END, 1);
+
+ALTER TABLE subscription_prices ALTER COLUMN price TYPE INTEGER;
+ALTER TABLE subscriptions ALTER COLUMN price TYPE INTEGER;
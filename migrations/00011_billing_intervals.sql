@@ -0,0 +1,13 @@
+-- +goose Up
+-- Период оплаты подписки: списание происходит каждые interval_count периодов billing_interval,
+-- начиная с месяца начала подписки. Существующие подписки оплачиваются ежемесячно.
+ALTER TABLE subscriptions
+    ADD COLUMN billing_interval TEXT NOT NULL DEFAULT 'month'
+        CONSTRAINT subscriptions_billing_interval_check CHECK (billing_interval IN ('week', 'month', 'quarter', 'year')),
+    ADD COLUMN interval_count INTEGER NOT NULL DEFAULT 1
+        CONSTRAINT subscriptions_interval_count_check CHECK (interval_count BETWEEN 1 AND 100);
+
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS interval_count;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_interval;
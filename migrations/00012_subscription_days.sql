@@ -0,0 +1,21 @@
+-- +goose Up
+-- Даты подписки хранятся с точностью до дня. Дата окончания — последний оплачиваемый день,
+-- поэтому у существующих подписок она переносится с первого на последний день месяца.
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_start_date_check;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+UPDATE subscriptions SET end_date = (end_date + interval '1 month - 1 day')::date WHERE end_date IS NOT NULL;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_end_date_check CHECK (end_date >= start_date);
+
+
+-- +goose Down
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+UPDATE subscriptions SET start_date = date_trunc('month', start_date)::date;
+UPDATE subscriptions SET end_date = date_trunc('month', end_date)::date WHERE end_date IS NOT NULL;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_start_date_check CHECK (EXTRACT(DAY FROM start_date) = 1),
+    ADD CONSTRAINT subscriptions_end_date_check CHECK (EXTRACT(DAY FROM end_date) = 1 AND end_date >= start_date);
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Каталог сервисов. slug — название в нижнем регистре, в котором все символы, кроме букв и цифр,
+-- заменены дефисом; он однозначно определяет сервис. Цена по умолчанию хранится в минимальных единицах валюты.
+CREATE TABLE services (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL CONSTRAINT services_slug_key UNIQUE,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    default_price BIGINT CONSTRAINT services_default_price_check CHECK (default_price > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CONSTRAINT services_currency_check CHECK (currency ~ '^[A-Z]{3}$'),
+    website VARCHAR(2048) NOT NULL DEFAULT ''
+);
+CREATE INDEX services_category_idx ON services (category);
+
+-- Названия существующих подписок с одинаковым slug объединяются в один сервис,
+-- названием которого становится самое частое из них, а валютой — самая частая валюта подписок.
+CREATE FUNCTION pg_temp.service_slug(name TEXT) RETURNS TEXT AS $$
+    SELECT trim(BOTH '-' FROM regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g'))
+$$ LANGUAGE SQL IMMUTABLE;
+
+INSERT INTO services (id, name, slug, currency)
+SELECT gen_random_uuid(), mode() WITHIN GROUP (ORDER BY btrim(service_name)), slug, mode() WITHIN GROUP (ORDER BY currency)
+FROM (SELECT service_name, currency, pg_temp.service_slug(service_name) AS slug FROM subscriptions) AS named
+GROUP BY slug;
+
+ALTER TABLE subscriptions ADD COLUMN service_id UUID;
+UPDATE subscriptions
+SET service_id = services.id, service_name = services.name
+FROM services
+WHERE services.slug = pg_temp.service_slug(subscriptions.service_name);
+
+-- service_name остается в подписках копией названия сервиса для фильтров, сортировки и группировки.
+ALTER TABLE subscriptions
+    ALTER COLUMN service_id SET NOT NULL,
+    ADD CONSTRAINT subscriptions_service_id_fkey FOREIGN KEY (service_id) REFERENCES services (id);
+CREATE INDEX subscriptions_service_id_idx ON subscriptions (service_id);
+
+
+-- +goose Down
+-- Объединенные названия подписок не восстанавливаются.
+DROP INDEX IF EXISTS subscriptions_service_id_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS services;
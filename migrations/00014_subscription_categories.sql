@@ -0,0 +1,22 @@
+-- +goose Up
+-- Категория и теги подписки. Подписка без категории получает категорию своего сервиса из каталога;
+-- теги — произвольные метки в нижнем регистре.
+ALTER TABLE subscriptions
+    ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE subscriptions
+SET category = services.category
+FROM services
+WHERE services.id = subscriptions.service_id AND services.category <> '';
+
+-- Индексы для фильтров по категории и по тегам (оператор @>)
+CREATE INDEX subscriptions_category_idx ON subscriptions (category);
+CREATE INDEX subscriptions_tags_idx ON subscriptions USING GIN (tags);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS subscriptions_tags_idx;
+DROP INDEX IF EXISTS subscriptions_category_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tags;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category;
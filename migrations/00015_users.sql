@@ -0,0 +1,27 @@
+-- +goose Up
+-- Пользователи: отображаемое имя, email, предпочитаемая валюта, часовой пояс и месячный бюджет
+-- в минимальных единицах этой валюты. Email уникален без учета регистра.
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(320),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CONSTRAINT users_currency_check CHECK (currency ~ '^[A-Z]{3}$'),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    monthly_budget BIGINT CONSTRAINT users_monthly_budget_check CHECK (monthly_budget > 0)
+);
+CREATE UNIQUE INDEX users_email_key ON users (lower(email));
+
+-- Пользователи существующих подписок регистрируются без имени и email.
+INSERT INTO users (id)
+SELECT DISTINCT user_id FROM subscriptions;
+
+-- Проверка откладывается до конца транзакции, чтобы импорт мог зарегистрировать пользователей
+-- после записи подписок командой COPY.
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id)
+        DEFERRABLE INITIALLY DEFERRED;
+
+
+-- +goose Down
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_fkey;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Месячные бюджеты пользователей по категориям подписок. Общий бюджет пользователя хранится
+-- в users.monthly_budget. Сумма указывается в минимальных единицах валюты бюджета.
+CREATE TABLE budgets (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL CHECK (category <> ''),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    PRIMARY KEY (user_id, category)
+);
+
+-- Превышения бюджетов: не более одного на бюджет в месяц. Пустая категория — общий бюджет.
+CREATE TABLE budget_alerts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    budget BIGINT NOT NULL,
+    spent BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT budget_alerts_month_key UNIQUE (user_id, category, month)
+);
+CREATE INDEX budget_alerts_created_at_idx ON budget_alerts (created_at);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS budget_alerts;
+DROP TABLE IF EXISTS budgets;
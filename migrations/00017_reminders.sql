@@ -0,0 +1,32 @@
+-- +goose Up
+-- Последний день бесплатного пробного периода подписки. Списания начинаются на следующий день.
+ALTER TABLE subscriptions
+    ADD COLUMN trial_end_date DATE,
+    ADD CONSTRAINT subscriptions_trial_end_date_check CHECK (trial_end_date >= start_date);
+
+-- Напоминания о предстоящих списаниях и окончании пробных периодов — очередь для отправки.
+-- Не более одного напоминания каждого вида на подписку и дату. Реплики забирают напоминания
+-- через SELECT ... FOR UPDATE SKIP LOCKED и помечают claimed_until, чтобы не отправить их дважды;
+-- неотправленное напоминание повторяется после истечения claimed_until.
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('renewal', 'trial_end')),
+    due_date DATE NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    price BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    claimed_until TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT reminders_due_key UNIQUE (subscription_id, kind, due_date)
+);
+-- Индекс для выборки неотправленных напоминаний
+CREATE INDEX reminders_pending_idx ON reminders (due_date) WHERE sent_at IS NULL;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS reminders;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end_date;
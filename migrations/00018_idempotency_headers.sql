@@ -0,0 +1,7 @@
+-- +goose Up
+-- Заголовки сохраненного ответа (ETag), которые повторяются вместе с ним.
+ALTER TABLE idempotency_keys ADD COLUMN headers JSONB;
+
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS headers;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Превышения бюджетов отправляются уведомителю в фоне, как напоминания: реплики забирают их
+-- через SELECT ... FOR UPDATE SKIP LOCKED и помечают claimed_until; неотправленное превышение
+-- повторяется после истечения claimed_until. Ранее записанные превышения уже отправлялись.
+ALTER TABLE budget_alerts
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN claimed_until TIMESTAMPTZ,
+    ADD COLUMN sent_at TIMESTAMPTZ;
+UPDATE budget_alerts SET sent_at = created_at;
+-- Индекс для выборки неотправленных превышений
+CREATE INDEX budget_alerts_pending_idx ON budget_alerts (created_at) WHERE sent_at IS NULL;
+
+
+-- +goose Down
+DROP INDEX IF EXISTS budget_alerts_pending_idx;
+ALTER TABLE budget_alerts
+    DROP COLUMN IF EXISTS sent_at,
+    DROP COLUMN IF EXISTS claimed_until,
+    DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Срок, до которого ключ зарезервирован за выполняющимся запросом. Если процесс завершился, не сохранив ответ,
+-- после этого срока ключ занимает повторный запрос, а не получает 409 до истечения expires_at.
+ALTER TABLE idempotency_keys ADD COLUMN claimed_until TIMESTAMPTZ;
+UPDATE idempotency_keys SET claimed_until = created_at + interval '5 minutes' WHERE status_code IS NULL;
+
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS claimed_until;